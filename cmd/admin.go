@@ -6,27 +6,65 @@ package cmd
 import (
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
 	"github.com/spf13/cobra"
 )
 
-// PasswordCmd represents the password command
-var PasswordCmd = &cobra.Command{
+// AdminCmd represents the password command
+var AdminCmd = &cobra.Command{
 	Use:     "admin",
 	Aliases: []string{"password"},
-	Short:   "Show admin user's info",
+	Short:   "Show admin user's info and some operations about admin user's password",
 	Run: func(cmd *cobra.Command, args []string) {
 		Init()
 		admin, err := op.GetAdmin()
 		if err != nil {
 			utils.Log.Errorf("failed get admin user: %+v", err)
 		} else {
-			utils.Log.Infof("admin user's info: \nusername: %s\npassword: %s", admin.Username, admin.Password)
+			utils.Log.Infof("admin user's info: \nusername: %s", admin.Username)
+			utils.Log.Infof("the password is hashed and can't be shown, use `alist admin random` or `alist admin set NEW_PASSWORD` to reset it")
 		}
 	},
 }
 
+var RandomPasswordCmd = &cobra.Command{
+	Use:   "random",
+	Short: "Reset admin user's password to a random string",
+	Run: func(cmd *cobra.Command, args []string) {
+		setAdminPassword(random.String(8))
+	},
+}
+
+var SetPasswordCmd = &cobra.Command{
+	Use:   "set NEW_PASSWORD",
+	Short: "Set admin user's password",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setAdminPassword(args[0])
+	},
+}
+
+func setAdminPassword(pwd string) {
+	Init()
+	admin, err := op.GetAdmin()
+	if err != nil {
+		utils.Log.Errorf("failed get admin user: %+v", err)
+		return
+	}
+	admin.Password = pwd
+	if err := op.UpdateUser(admin); err != nil {
+		utils.Log.Errorf("failed update admin user: %+v", err)
+		return
+	}
+	utils.Log.Infof("admin user has been updated:")
+	utils.Log.Infof("username: %s", admin.Username)
+	utils.Log.Infof("password: %s", pwd)
+}
+
 func init() {
-	RootCmd.AddCommand(PasswordCmd)
+	RootCmd.AddCommand(AdminCmd)
+	AdminCmd.AddCommand(RandomPasswordCmd)
+	AdminCmd.AddCommand(SetPasswordCmd)
 
 	// Here you will define your flags and configuration settings.
 
@@ -2,12 +2,15 @@ package cmd
 
 import (
 	"github.com/alist-org/alist/v3/drivers/aliyundrive_share"
+	"net"
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/alist-org/alist/v3/internal/bootstrap"
 	"github.com/alist-org/alist/v3/internal/bootstrap/data"
+	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	log "github.com/sirupsen/logrus"
 )
@@ -44,3 +47,24 @@ func initDaemon() {
 		pid = id
 	}
 }
+
+// serverRunning check if the server of the config is listening,
+// the data shouldn't be changed offline while it's running
+func serverRunning() bool {
+	if conf.Conf == nil || conf.Conf.Port <= 0 {
+		return false
+	}
+	host := conf.Conf.Address
+	switch host {
+	case "", "0.0.0.0":
+		host = "127.0.0.1"
+	case "::", "[::]":
+		host = "::1"
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(conf.Conf.Port)), time.Second)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
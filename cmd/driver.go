@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// driverCmd represents the driver command
+var driverCmd = &cobra.Command{
+	Use:   "driver",
+	Short: "Show the drivers and the fields of their storages",
+}
+
+// driverInfos returns the drivers of this binary, or the running server with --server
+func driverInfos() (map[string]driver.Info, error) {
+	if !isRemote() {
+		return op.GetDriverInfoMap(), nil
+	}
+	var infos map[string]driver.Info
+	err := newRemoteClient().call(http.MethodGet, "/admin/driver/list", nil, nil, &infos)
+	return infos, err
+}
+
+func printItems(title string, items []driver.Item) {
+	rows := [][]string{{title, "TYPE", "DEFAULT", "REQUIRED", "OPTIONS", "HELP"}}
+	for _, item := range items {
+		rows = append(rows, []string{item.Name, item.Type, item.Default, strconv.FormatBool(item.Required), item.Options, item.Help})
+	}
+	printTable(rows)
+}
+
+func init() {
+	RootCmd.AddCommand(driverCmd)
+	addRemoteFlags(driverCmd)
+	addOutputFlags(driverCmd)
+
+	driverCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List drivers",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			infos, err := driverInfos()
+			exitOnErr(err, "failed list drivers")
+			if jsonOutput {
+				printJSON(infos)
+				return
+			}
+			names := make([]string, 0, len(infos))
+			for name := range infos {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			rows := [][]string{{"NAME", "LOCAL SORT", "ONLY LOCAL", "ONLY PROXY", "NO CACHE", "NO UPLOAD", "DEFAULT ROOT"}}
+			for _, name := range names {
+				c := infos[name].Config
+				rows = append(rows, []string{name, strconv.FormatBool(c.LocalSort), strconv.FormatBool(c.OnlyLocal),
+					strconv.FormatBool(c.OnlyProxy), strconv.FormatBool(c.NoCache), strconv.FormatBool(c.NoUpload), c.DefaultRoot})
+			}
+			printTable(rows)
+		},
+	})
+
+	driverCmd.AddCommand(&cobra.Command{
+		Use:   "info DRIVER",
+		Short: "Show the config of the driver and the fields of its storages",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			infos, err := driverInfos()
+			exitOnErr(err, "failed get drivers")
+			info, ok := infos[args[0]]
+			if !ok {
+				exitOnErr(errors.Errorf("driver [%s] not found", args[0]), "failed get driver")
+			}
+			if jsonOutput {
+				printJSON(info)
+				return
+			}
+			c := info.Config
+			printTable([][]string{
+				{"name", c.Name},
+				{"local_sort", strconv.FormatBool(c.LocalSort)},
+				{"only_local", strconv.FormatBool(c.OnlyLocal)},
+				{"only_proxy", strconv.FormatBool(c.OnlyProxy)},
+				{"no_cache", strconv.FormatBool(c.NoCache)},
+				{"no_upload", strconv.FormatBool(c.NoUpload)},
+				{"need_ms", strconv.FormatBool(c.NeedMs)},
+				{"default_root", c.DefaultRoot},
+				{"alert", c.Alert},
+			})
+			fmt.Println()
+			printItems("COMMON", info.Common)
+			fmt.Println()
+			printItems("ADDITION", info.Additional)
+		},
+	})
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/bundle"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// ExportCmd represents the export command
+var ExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export storages, metas, users and settings to a bundle",
+	Run: func(cmd *cobra.Command, args []string) {
+		Init()
+		var include []string
+		if exportInclude != "" {
+			include = strings.Split(exportInclude, ",")
+		}
+		b, err := bundle.Export(bundle.ExportOptions{Include: include, Secrets: exportSecrets, Passphrase: exportPassphrase})
+		if err != nil {
+			utils.Log.Fatalf("failed export: %+v", err)
+		}
+		data, err := bundle.Marshal(b, exportFormat)
+		if err != nil {
+			utils.Log.Fatalf("failed marshal bundle: %+v", err)
+		}
+		output := exportOutput
+		if output == "" {
+			output = "alist-bundle." + exportFormat
+		}
+		if err := os.WriteFile(output, data, 0600); err != nil {
+			utils.Log.Fatalf("failed write bundle: %+v", err)
+		}
+		utils.Log.Infof("exported %d storages, %d metas, %d users and %d settings to %s",
+			len(b.Storages), len(b.Metas), len(b.Users), len(b.Settings), output)
+	},
+}
+
+var (
+	exportOutput     string
+	exportFormat     string
+	exportSecrets    string
+	exportPassphrase string
+	exportInclude    string
+)
+
+func init() {
+	RootCmd.AddCommand(ExportCmd)
+	ExportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "the file of the bundle, alist-bundle.<format> by default")
+	ExportCmd.Flags().StringVar(&exportFormat, "format", bundle.FormatJSON, "the format of the bundle, json or yaml")
+	ExportCmd.Flags().StringVar(&exportSecrets, "secrets", bundle.SecretsRedact, "how to export the secrets, plain, redact or encrypt")
+	ExportCmd.Flags().StringVar(&exportPassphrase, "passphrase", "", "the passphrase to encrypt the secrets")
+	ExportCmd.Flags().StringVar(&exportInclude, "include", "", "the kinds to export separated by comma, storage, meta, user and setting, all by default")
+}
@@ -0,0 +1,375 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	stdpath "path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// fsCmd represents the fs command
+var fsCmd = &cobra.Command{
+	Use:   "fs",
+	Short: "Operate the files of the storages",
+	Long: `Operate the files of the storages by the mount paths.
+Offline, the storages are loaded and the drivers run in this process, so prefer --server
+while the server is running, the tokens refreshed by the drivers may conflict otherwise.
+With --server, the paths are relative to the base path of the user of the token.`,
+}
+
+func fsPath(path string) string {
+	return utils.FixAndCleanPath(path)
+}
+
+func fsPaths(paths []string) []string {
+	res := make([]string, 0, len(paths))
+	for _, path := range paths {
+		res = append(res, fsPath(path))
+	}
+	return res
+}
+
+func printObjs(objs []fsObj, long bool) {
+	if jsonOutput {
+		printJSON(objs)
+		return
+	}
+	if !long {
+		for _, obj := range objs {
+			if obj.IsDir {
+				fmt.Println(obj.Name + "/")
+			} else {
+				fmt.Println(obj.Name)
+			}
+		}
+		return
+	}
+	rows := [][]string{{"TYPE", "SIZE", "MODIFIED", "NAME"}}
+	for _, obj := range objs {
+		typ, size := "-", strconv.FormatInt(obj.Size, 10)
+		if obj.IsDir {
+			typ, size = "d", "-"
+		}
+		rows = append(rows, []string{typ, size, obj.Modified.Local().Format("2006-01-02 15:04:05"), obj.Name})
+	}
+	printTable(rows)
+}
+
+// formatBytes format the bytes in the binary units
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// waitCopyTasks wait the copy tasks of the ids, the progress is printed to stderr.
+// The progress of the sub tasks are rolled up to the tasks, so only the tasks added are watched
+func waitCopyTasks(b fsBackend, ids []string) error {
+	ours := map[string]bool{}
+	for _, id := range ids {
+		ours[id] = true
+	}
+	var done []fsTask
+	for {
+		undone, allDone, err := b.CopyTasks()
+		if err != nil {
+			return err
+		}
+		running := map[string]bool{}
+		var pending []fsTask
+		for _, t := range undone {
+			if ours[t.ID] {
+				running[t.ID] = true
+				pending = append(pending, t)
+			}
+		}
+		// the task may be done between listing the undone and the done ones
+		done = done[:0]
+		for _, t := range allDone {
+			if ours[t.ID] && !running[t.ID] {
+				done = append(done, t)
+			}
+		}
+		if len(pending) == 0 {
+			break
+		}
+		if !jsonOutput {
+			finished := len(ids) - len(pending)
+			progress, speed := 100*finished, int64(0)
+			for _, t := range pending {
+				progress += t.Progress
+				speed += t.Speed
+			}
+			current := pending[0]
+			for _, t := range pending {
+				if t.State == task.RUNNING {
+					current = t
+					break
+				}
+			}
+			_, _ = fmt.Fprintf(os.Stderr, "\r\033[K[%d/%d tasks] %d%% %s/s %s", finished, len(ids),
+				progress/len(ids), formatBytes(speed), current.Name)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	if !jsonOutput {
+		_, _ = fmt.Fprintf(os.Stderr, "\r\033[K[%d/%d tasks] done\n", len(ids), len(ids))
+	}
+	var failed []string
+	for _, t := range done {
+		if t.State != task.SUCCEEDED {
+			failed = append(failed, fmt.Sprintf("%s: %s %s", t.Name, t.State, t.Error))
+		}
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("%d copy tasks failed:\n%s", len(failed), strings.Join(failed, "\n"))
+	}
+	return nil
+}
+
+// openInput open the local file or stdin, stdin is stored in a temp file to get the size,
+// the returned func clean the temp file
+func openInput(name string) (*os.File, int64, func(), error) {
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, 0, nil, errors.WithStack(err)
+		}
+		stat, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+			return nil, 0, nil, errors.WithStack(err)
+		}
+		if stat.IsDir() {
+			_ = f.Close()
+			return nil, 0, nil, errors.Errorf("%s is a directory", name)
+		}
+		return f, stat.Size(), func() {}, nil
+	}
+	f, err := os.CreateTemp("", "alist-put-*")
+	if err != nil {
+		return nil, 0, nil, errors.WithStack(err)
+	}
+	clean := func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}
+	size, err := io.Copy(f, os.Stdin)
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		clean()
+		return nil, 0, nil, errors.WithStack(err)
+	}
+	return f, size, clean, nil
+}
+
+func init() {
+	RootCmd.AddCommand(fsCmd)
+	addRemoteFlags(fsCmd)
+	addOutputFlags(fsCmd)
+
+	var long, refresh bool
+	ls := &cobra.Command{
+		Use:   "ls [PATH]",
+		Short: "List a folder",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			path := "/"
+			if len(args) > 0 {
+				path = fsPath(args[0])
+			}
+			b := newFsBackend(path)
+			obj, err := b.Get(path)
+			exitOnErr(err, "failed get "+path)
+			if !obj.IsDir {
+				printObjs([]fsObj{*obj}, long)
+				return
+			}
+			objs, err := b.List(path, refresh)
+			exitOnErr(err, "failed list "+path)
+			printObjs(objs, long)
+		},
+	}
+	ls.Flags().BoolVarP(&long, "long", "l", false, "print the type, size and modified time")
+	ls.Flags().BoolVar(&refresh, "refresh", false, "refresh the cache of the folder")
+	fsCmd.AddCommand(ls)
+
+	fsCmd.AddCommand(&cobra.Command{
+		Use:   "mkdir PATH...",
+		Short: "Make folders, the parents are made as needed",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			paths := fsPaths(args)
+			b := newFsBackend(paths...)
+			for _, path := range paths {
+				exitOnErr(b.MakeDir(path), "failed make dir "+path)
+			}
+		},
+	})
+
+	fsCmd.AddCommand(&cobra.Command{
+		Use:   "rm PATH...",
+		Short: "Remove files or folders",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			paths := fsPaths(args)
+			b := newFsBackend(paths...)
+			for _, path := range paths {
+				if path == "/" {
+					exitOnErr(errors.New("the root can't be removed"), "failed remove "+path)
+				}
+				exitOnErr(b.Remove(path), "failed remove "+path)
+			}
+		},
+	})
+
+	fsCmd.AddCommand(&cobra.Command{
+		Use:   "mv SRC... DST_DIR",
+		Short: "Move files or folders into the folder",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			paths := fsPaths(args)
+			srcs, dst := paths[:len(paths)-1], paths[len(paths)-1]
+			b := newFsBackend(paths...)
+			for _, src := range srcs {
+				exitOnErr(b.Move(src, dst), "failed move "+src)
+			}
+		},
+	})
+
+	fsCmd.AddCommand(&cobra.Command{
+		Use:   "cp SRC... DST_DIR",
+		Short: "Copy files or folders into the folder recursively",
+		Long: `Copy files or folders into the folder recursively.
+The copy between the storages is done by the copy tasks, which are waited with the progress,
+the tasks can only be watched by the admin with --server.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			paths := fsPaths(args)
+			srcs, dst := paths[:len(paths)-1], paths[len(paths)-1]
+			b := newFsBackend(paths...)
+			var ids []string
+			for _, src := range srcs {
+				tasks, err := b.Copy(src, dst)
+				exitOnErr(err, "failed copy "+src)
+				ids = append(ids, tasks...)
+			}
+			if len(ids) == 0 {
+				return
+			}
+			if _, _, err := b.CopyTasks(); err != nil {
+				utils.Log.Warnf("the copy tasks have been added but can't be watched: %v", err)
+				return
+			}
+			exitOnErr(waitCopyTasks(b, ids), "failed copy")
+		},
+	})
+
+	fsCmd.AddCommand(&cobra.Command{
+		Use:   "cat PATH...",
+		Short: "Print the files to stdout",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			paths := fsPaths(args)
+			b := newFsBackend(paths...)
+			for _, path := range paths {
+				rc, err := b.Open(path)
+				exitOnErr(err, "failed open "+path)
+				_, err = io.Copy(os.Stdout, rc)
+				_ = rc.Close()
+				exitOnErr(errors.WithStack(err), "failed read "+path)
+			}
+		},
+	})
+
+	var output string
+	get := &cobra.Command{
+		Use:   "get PATH",
+		Short: "Download a file",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			path := fsPath(args[0])
+			if output == "" {
+				output = stdpath.Base(path)
+			}
+			b := newFsBackend(path)
+			rc, err := b.Open(path)
+			exitOnErr(err, "failed open "+path)
+			defer rc.Close()
+			if output == "-" {
+				_, err = io.Copy(os.Stdout, rc)
+				exitOnErr(errors.WithStack(err), "failed read "+path)
+				return
+			}
+			if stat, err := os.Stat(output); err == nil && stat.IsDir() {
+				output = filepath.Join(output, stdpath.Base(path))
+			}
+			f, err := os.Create(output)
+			exitOnErr(errors.WithStack(err), "failed create "+output)
+			n, err := io.Copy(f, rc)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			exitOnErr(errors.WithStack(err), "failed download "+path)
+			if jsonOutput {
+				printJSON(map[string]any{"path": output, "size": n})
+			} else {
+				utils.Log.Infof("%s has been downloaded to %s, %d bytes", path, output, n)
+			}
+		},
+	}
+	get.Flags().StringVarP(&output, "output", "o", "", "the local file or folder to save, - for stdout, the name of the file in the current folder by default")
+	fsCmd.AddCommand(get)
+
+	fsCmd.AddCommand(&cobra.Command{
+		Use:   "put SRC|- DST",
+		Short: "Upload a local file, or stdin with -",
+		Long: `Upload a local file, or stdin with -.
+The file is put into DST if it's an existing folder or ends with /, otherwise DST is the path of the file.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			src, dst := args[0], fsPath(args[1])
+			b := newFsBackend(dst)
+			intoDir := strings.HasSuffix(args[1], "/")
+			if !intoDir {
+				if obj, err := b.Get(dst); err == nil && obj.IsDir {
+					intoDir = true
+				}
+			}
+			if intoDir {
+				if src == "-" {
+					exitOnErr(errors.New("the path of the file is required to put stdin"), "invalid args")
+				}
+				dst = stdpath.Join(dst, filepath.Base(src))
+			}
+			f, size, clean, err := openInput(src)
+			exitOnErr(err, "failed open "+src)
+			err = b.Put(dst, f, size)
+			clean()
+			exitOnErr(err, "failed put "+dst)
+			if jsonOutput {
+				printJSON(map[string]any{"path": dst, "size": size})
+			} else {
+				utils.Log.Infof("%s has been uploaded, %d bytes", dst, size)
+			}
+		},
+	})
+}
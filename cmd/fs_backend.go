@@ -0,0 +1,354 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	stdpath "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+)
+
+// fsObj is the object printed by the fs commands
+type fsObj struct {
+	Name     string    `json:"name"`
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	IsDir    bool      `json:"is_dir"`
+	Modified time.Time `json:"modified"`
+}
+
+// fsTask is the copy task between the storages, the same as the task info of the api
+type fsTask struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	State    string `json:"state"`
+	Status   string `json:"status"`
+	Progress int    `json:"progress"`
+	Speed    int64  `json:"speed"`
+	Error    string `json:"error"`
+}
+
+// fsBackend operate the files by the drivers in process, or in the running server
+type fsBackend interface {
+	List(path string, refresh bool) ([]fsObj, error)
+	Get(path string) (*fsObj, error)
+	MakeDir(path string) error
+	Move(srcPath, dstDir string) error
+	// Copy returns the ids of the tasks added if the copy is done by the tasks
+	Copy(srcPath, dstDir string) ([]string, error)
+	CopyTasks() (undone, done []fsTask, err error)
+	Remove(path string) error
+	Open(path string) (io.ReadCloser, error)
+	// Put the file to the path, the size must be known
+	Put(dstPath string, r io.ReadCloser, size int64) error
+}
+
+// newFsBackend returns the backend, the storages containing or under the paths are loaded offline
+func newFsBackend(paths ...string) fsBackend {
+	if isRemote() {
+		return remoteFs{newRemoteClient()}
+	}
+	Init()
+	loadStorages(paths)
+	return localFs{}
+}
+
+// loadStorages load the enabled storages related to the paths synchronously
+func loadStorages(paths []string) {
+	storages, err := db.GetEnabledStorages()
+	if err != nil {
+		utils.Log.Fatalf("failed get enabled storages: %+v", err)
+	}
+	for i := range storages {
+		related := false
+		for _, path := range paths {
+			if utils.IsSubPath(storages[i].MountPath, path) || utils.IsSubPath(path, storages[i].MountPath) {
+				related = true
+				break
+			}
+		}
+		if !related {
+			continue
+		}
+		if err := op.LoadStorage(context.Background(), storages[i]); err != nil {
+			utils.Log.Errorf("failed load storage [%s]: %v", storages[i].MountPath, err)
+		} else {
+			utils.Log.Debugf("success load storage: [%s], driver: [%s]", storages[i].MountPath, storages[i].Driver)
+		}
+	}
+}
+
+func toFsObj(dir string, obj model.Obj) fsObj {
+	return fsObj{
+		Name:     obj.GetName(),
+		Path:     stdpath.Join(dir, obj.GetName()),
+		Size:     obj.GetSize(),
+		IsDir:    obj.IsDir(),
+		Modified: obj.ModTime(),
+	}
+}
+
+// localFs run the drivers in process
+type localFs struct{}
+
+func (localFs) List(path string, refresh bool) ([]fsObj, error) {
+	objs, err := fs.List(context.Background(), path, &fs.ListArgs{Refresh: refresh, NoLog: true})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]fsObj, 0, len(objs))
+	for _, obj := range objs {
+		res = append(res, toFsObj(path, obj))
+	}
+	return res, nil
+}
+
+func (localFs) Get(path string) (*fsObj, error) {
+	obj, err := fs.Get(context.Background(), path, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return nil, err
+	}
+	res := toFsObj(stdpath.Dir(path), obj)
+	// the root of the storage is named by the driver
+	res.Name, res.Path = stdpath.Base(path), path
+	return &res, nil
+}
+
+func (localFs) MakeDir(path string) error {
+	return fs.MakeDir(context.Background(), path)
+}
+
+func (localFs) Move(srcPath, dstDir string) error {
+	return fs.Move(context.Background(), srcPath, dstDir)
+}
+
+func (localFs) Copy(srcPath, dstDir string) ([]string, error) {
+	t, err := fs.CopyTask(context.Background(), srcPath, dstDir)
+	if t == nil {
+		return nil, err
+	}
+	return []string{strconv.FormatUint(t.ID, 10)}, err
+}
+
+func toFsTasks(tasks []*task.Task[uint64]) []fsTask {
+	res := make([]fsTask, 0, len(tasks))
+	for _, t := range tasks {
+		res = append(res, fsTask{
+			ID:       strconv.FormatUint(t.ID, 10),
+			Name:     t.Name,
+			State:    t.GetState(),
+			Status:   t.GetStatus(),
+			Progress: t.GetProgress(),
+			Speed:    t.GetStats().Speed,
+			Error:    t.GetErrMsg(),
+		})
+	}
+	return res
+}
+
+func (localFs) CopyTasks() ([]fsTask, []fsTask, error) {
+	return toFsTasks(fs.CopyTaskManager.ListUndone()), toFsTasks(fs.CopyTaskManager.ListDone()), nil
+}
+
+func (localFs) Remove(path string) error {
+	return fs.Remove(context.Background(), path)
+}
+
+func (localFs) Open(path string) (io.ReadCloser, error) {
+	link, _, err := fs.Link(context.Background(), path, model.LinkArgs{})
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case link.RangeReader != nil:
+		return link.RangeReader.RangeRead(context.Background(), http_range.Range{Start: 0, Length: -1})
+	case link.Data != nil:
+		return link.Data, nil
+	case link.FilePath != nil:
+		f, err := os.Open(*link.FilePath)
+		return f, errors.WithStack(err)
+	case link.URL != "":
+		req, err := http.NewRequest(http.MethodGet, link.URL, nil)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for h, val := range link.Header {
+			req.Header[h] = val
+		}
+		return download(req)
+	default:
+		return nil, errors.Errorf("the link of [%s] can only be handled by the server, use --server", path)
+	}
+}
+
+func (localFs) Put(dstPath string, r io.ReadCloser, size int64) error {
+	dir, name := stdpath.Split(dstPath)
+	return fs.PutDirectly(context.Background(), dir, &model.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     size,
+			Modified: time.Now(),
+		},
+		ReadCloser: r,
+		Mimetype:   utils.GetMimeType(name),
+		// the file is passed through so it's seekable, the temp file of stdin is removed by the caller
+		KeepFile: true,
+	})
+}
+
+// download do the request and returns the body if the status is ok
+func download(req *http.Request) (io.ReadCloser, error) {
+	res, err := common.HttpClient().Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		_ = res.Body.Close()
+		return nil, errors.Errorf("failed download: %s %s", res.Status, strings.TrimSpace(string(data)))
+	}
+	return res.Body, nil
+}
+
+// remoteFs operate the files through the fs api of the running server
+type remoteFs struct {
+	*remoteClient
+}
+
+type remoteObj struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	IsDir    bool      `json:"is_dir"`
+	Modified time.Time `json:"modified"`
+	RawURL   string    `json:"raw_url"`
+}
+
+func (r remoteFs) List(path string, refresh bool) ([]fsObj, error) {
+	var resp struct {
+		Content []remoteObj `json:"content"`
+	}
+	err := r.call(http.MethodPost, "/fs/list", nil, map[string]any{"path": path, "refresh": refresh}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]fsObj, 0, len(resp.Content))
+	for _, obj := range resp.Content {
+		res = append(res, fsObj{Name: obj.Name, Path: stdpath.Join(path, obj.Name), Size: obj.Size, IsDir: obj.IsDir, Modified: obj.Modified})
+	}
+	return res, nil
+}
+
+func (r remoteFs) get(path string) (*remoteObj, error) {
+	var obj remoteObj
+	if err := r.call(http.MethodPost, "/fs/get", nil, map[string]string{"path": path}, &obj); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
+
+func (r remoteFs) Get(path string) (*fsObj, error) {
+	obj, err := r.get(path)
+	if err != nil {
+		return nil, err
+	}
+	return &fsObj{Name: stdpath.Base(path), Path: path, Size: obj.Size, IsDir: obj.IsDir, Modified: obj.Modified}, nil
+}
+
+func (r remoteFs) MakeDir(path string) error {
+	return r.call(http.MethodPost, "/fs/mkdir", nil, map[string]string{"path": path}, nil)
+}
+
+func moveCopyReq(srcPath, dstDir string) map[string]any {
+	return map[string]any{
+		"src_dir": stdpath.Dir(srcPath),
+		"dst_dir": dstDir,
+		"names":   []string{stdpath.Base(srcPath)},
+	}
+}
+
+func (r remoteFs) Move(srcPath, dstDir string) error {
+	return r.call(http.MethodPost, "/fs/move", nil, moveCopyReq(srcPath, dstDir), nil)
+}
+
+func (r remoteFs) Copy(srcPath, dstDir string) ([]string, error) {
+	var resp struct {
+		Tasks []string `json:"tasks"`
+	}
+	err := r.call(http.MethodPost, "/fs/copy", nil, moveCopyReq(srcPath, dstDir), &resp)
+	return resp.Tasks, err
+}
+
+func (r remoteFs) CopyTasks() ([]fsTask, []fsTask, error) {
+	var undone, done []fsTask
+	if err := r.call(http.MethodGet, "/admin/task/copy/undone", nil, nil, &undone); err != nil {
+		return nil, nil, err
+	}
+	err := r.call(http.MethodGet, "/admin/task/copy/done", nil, nil, &done)
+	return undone, done, err
+}
+
+func (r remoteFs) Remove(path string) error {
+	return r.call(http.MethodPost, "/fs/remove", nil, map[string]any{
+		"dir":   stdpath.Dir(path),
+		"names": []string{stdpath.Base(path)},
+	}, nil)
+}
+
+func (r remoteFs) Open(path string) (io.ReadCloser, error) {
+	obj, err := r.get(path)
+	if err != nil {
+		return nil, err
+	}
+	if obj.IsDir {
+		return nil, errors.Errorf("[%s] is a folder", path)
+	}
+	// the raw url is signed if needed, and maybe relative to the server
+	rawURL := obj.RawURL
+	if strings.HasPrefix(rawURL, "/") {
+		rawURL = strings.TrimSuffix(remoteServer, "/") + rawURL
+	}
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return download(req)
+}
+
+func (r remoteFs) Put(dstPath string, rc io.ReadCloser, size int64) error {
+	defer rc.Close()
+	req, err := http.NewRequest(http.MethodPut, strings.TrimSuffix(remoteServer, "/")+"/api/fs/put", rc)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Authorization", remoteToken)
+	req.Header.Set("File-Path", url.PathEscape(dstPath))
+	req.Header.Set("Content-Type", utils.GetMimeType(dstPath))
+	res, err := common.HttpClient().Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer res.Body.Close()
+	var resp remoteResp
+	if err := utils.Json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return errors.Errorf("unexpected response of /fs/put: %s", res.Status)
+	}
+	if resp.Code != 200 {
+		return errors.Errorf("/fs/put: %s", resp.Message)
+	}
+	return nil
+}
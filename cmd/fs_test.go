@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var initDB sync.Once
+
+// setupLocalFs create the local storages at the temp dirs by the mount paths
+func setupLocalFs(t *testing.T, mountPaths ...string) []string {
+	initDB.Do(func() {
+		dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+		if err != nil {
+			panic("failed to connect database")
+		}
+		conf.Conf = conf.DefaultConfig()
+		db.Init(dB)
+	})
+	var dirs []string
+	for _, mountPath := range mountPaths {
+		dir := t.TempDir()
+		id, err := op.CreateStorage(context.Background(), model.Storage{
+			Driver:    "Local",
+			MountPath: mountPath,
+			Addition:  `{"root_folder_path":"` + filepath.ToSlash(dir) + `"}`,
+		})
+		if err != nil {
+			t.Fatalf("failed create storage: %+v", err)
+		}
+		t.Cleanup(func() {
+			_ = op.DeleteStorageById(context.Background(), id)
+		})
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+func TestLocalFsPut(t *testing.T) {
+	dirs := setupLocalFs(t, "/put")
+	src := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(src, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, size, clean, err := openInput(src)
+	if err != nil {
+		t.Fatalf("failed open: %+v", err)
+	}
+	defer clean()
+	if err := (localFs{}).Put("/put/b.txt", f, size); err != nil {
+		t.Fatalf("failed put: %+v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dirs[0], "b.txt")); err != nil || string(data) != "hello" {
+		t.Errorf("expect the file uploaded, got %q %v", data, err)
+	}
+	// the file of the user is not removed like the temp files
+	if !utils.Exists(src) {
+		t.Errorf("expect the local file kept")
+	}
+	if _, _, _, err := openInput(t.TempDir()); err == nil {
+		t.Errorf("expect the folder not opened")
+	}
+}
+
+func TestLocalFsCopy(t *testing.T) {
+	dirs := setupLocalFs(t, "/src", "/dst")
+	if err := os.WriteFile(filepath.Join(dirs[0], "a.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b := localFs{}
+	ids, err := b.Copy("/src/a.txt", "/dst")
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("expect a copy task, got %v %+v", ids, err)
+	}
+	if err := waitCopyTasks(b, ids); err != nil {
+		t.Fatalf("failed wait: %+v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dirs[1], "a.txt")); err != nil || string(data) != "hello" {
+		t.Errorf("expect the file copied, got %q %v", data, err)
+	}
+	// the copy in the same storage is done directly
+	if err := os.Mkdir(filepath.Join(dirs[0], "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if ids, err := b.Copy("/src/a.txt", "/src/sub"); err != nil || len(ids) != 0 {
+		t.Errorf("expect no task, got %v %+v", ids, err)
+	}
+}
+
+// fakeTasks returns the tasks as they are
+type fakeTasks struct {
+	fsBackend
+	undone, done []fsTask
+}
+
+func (f *fakeTasks) CopyTasks() ([]fsTask, []fsTask, error) {
+	undone := f.undone
+	// the tasks are done at the next listing
+	f.undone = nil
+	return undone, f.done, nil
+}
+
+func TestWaitCopyTasks(t *testing.T) {
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+	b := &fakeTasks{
+		undone: []fsTask{{ID: "2", State: task.RUNNING}, {ID: "3", State: task.RUNNING}},
+		done: []fsTask{
+			{ID: "1", Name: "copy of others", State: task.ERRORED},
+			{ID: "2", Name: "copy a", State: task.SUCCEEDED},
+			{ID: "3", Name: "copy b", State: task.ERRORED, Error: "failed"},
+		},
+	}
+	// the failed task of others is not ours
+	if err := waitCopyTasks(b, []string{"2"}); err != nil {
+		t.Errorf("expect only the tasks of the ids waited, got %+v", err)
+	}
+	if err := waitCopyTasks(b, []string{"2", "3"}); err == nil {
+		t.Errorf("expect the failed task reported")
+	}
+}
+
+func TestRemoteFsCopy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/fs/copy" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":200,"message":"success","data":{"message":"Added 1 tasks","tasks":["7"]}}`))
+	}))
+	defer server.Close()
+	defer func(s string) { remoteServer = s }(remoteServer)
+	remoteServer = server.URL
+	ids, err := remoteFs{newRemoteClient()}.Copy("/a/b.txt", "/c")
+	if err != nil || len(ids) != 1 || ids[0] != "7" {
+		t.Errorf("expect the ids of the tasks, got %v %+v", ids, err)
+	}
+}
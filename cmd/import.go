@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/bundle"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// ImportCmd represents the import command
+var ImportCmd = &cobra.Command{
+	Use:   "import BUNDLE_FILE",
+	Short: "Import storages, metas, users and settings from a bundle",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		Init()
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			utils.Log.Fatalf("failed read bundle: %+v", err)
+		}
+		b, err := bundle.Unmarshal(data)
+		if err != nil {
+			utils.Log.Fatalf("%+v", err)
+		}
+		changes, err := bundle.Import(context.Background(), b, bundle.ImportOptions{
+			Mode:       importMode,
+			DryRun:     importDryRun,
+			Passphrase: importPassphrase,
+		})
+		for _, change := range changes {
+			line := fmt.Sprintf("%s %s [%s]", change.Action, change.Kind, change.Key)
+			if len(change.Fields) > 0 {
+				line += ": " + strings.Join(change.Fields, ", ")
+			}
+			fmt.Println(line)
+		}
+		if err != nil {
+			utils.Log.Fatalf("failed import: %+v", err)
+		}
+		if importDryRun {
+			utils.Log.Infof("%d changes would be applied", len(changes))
+		} else {
+			utils.Log.Infof("%d changes have been applied", len(changes))
+		}
+	},
+}
+
+var (
+	importMode       string
+	importDryRun     bool
+	importPassphrase string
+)
+
+func init() {
+	RootCmd.AddCommand(ImportCmd)
+	ImportCmd.Flags().StringVar(&importMode, "mode", bundle.ModeMerge, "merge, or replace to also delete the items not in the bundle")
+	ImportCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "only show the changes without applying them")
+	ImportCmd.Flags().StringVar(&importPassphrase, "passphrase", "", "the passphrase to decrypt the secrets")
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alist-org/alist/v3/cmd/flags"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// print the machine-readable json instead of the tables
+var jsonOutput bool
+
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "print the output as json")
+}
+
+func printJSON(v any) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		utils.Log.Fatalf("failed marshal output: %+v", err)
+	}
+	fmt.Println(string(data))
+}
+
+// printTable print the rows aligned by the columns, the first row is the header
+func printTable(rows [][]string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, row := range rows {
+		_, _ = fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	_ = w.Flush()
+}
+
+// exitOnErr print the error and exit, as json if the output is json
+func exitOnErr(err error, msg string) {
+	if err == nil {
+		return
+	}
+	if jsonOutput {
+		printJSON(map[string]string{"error": fmt.Sprintf("%s: %v", msg, err)})
+		os.Exit(1)
+	}
+	if flags.Debug {
+		utils.Log.Fatalf("%s: %+v", msg, err)
+	}
+	utils.Log.Fatalf("%s: %v", msg, err)
+}
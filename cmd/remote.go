@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// the running server managed by the commands, the database is used offline if it's empty
+var (
+	remoteServer string
+	remoteToken  string
+)
+
+// addRemoteFlags add the flags to run the command against a running server through the api
+func addRemoteFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&remoteServer, "server", os.Getenv("ALIST_SERVER"),
+		"the address of the running server, e.g. http://localhost:5244, the database is used offline if empty (env ALIST_SERVER)")
+	cmd.PersistentFlags().StringVar(&remoteToken, "token", os.Getenv("ALIST_TOKEN"),
+		"the token or api token of the admin to access the server (env ALIST_TOKEN)")
+}
+
+func isRemote() bool {
+	return remoteServer != ""
+}
+
+// remoteClient call the api of the running server
+type remoteClient struct {
+	client *resty.Client
+}
+
+func newRemoteClient() *remoteClient {
+	client := resty.New().
+		SetBaseURL(strings.TrimSuffix(remoteServer, "/")+"/api").
+		SetTimeout(time.Minute).
+		SetHeader("Authorization", remoteToken)
+	return &remoteClient{client: client}
+}
+
+type remoteResp struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// call the api with the json body, the data of the response is unmarshalled to out if not nil
+func (c *remoteClient) call(method, path string, query map[string]string, body, out any) error {
+	req := c.client.R().SetQueryParams(query)
+	if body != nil {
+		req.SetBody(body)
+	}
+	res, err := req.Execute(method, path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var resp remoteResp
+	if err := json.Unmarshal(res.Body(), &resp); err != nil {
+		return errors.Errorf("unexpected response of %s: %s %s", path, res.Status(), res.String())
+	}
+	if resp.Code != 200 {
+		return errors.Errorf("%s: %s", path, resp.Message)
+	}
+	if out != nil && len(resp.Data) > 0 {
+		return errors.WithStack(json.Unmarshal(resp.Data, out))
+	}
+	return nil
+}
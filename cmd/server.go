@@ -13,8 +13,10 @@ import (
 	_ "github.com/alist-org/alist/v3/drivers"
 	"github.com/alist-org/alist/v3/internal/bootstrap"
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/mtls"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server"
+	"github.com/alist-org/alist/v3/server/middlewares"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -30,16 +32,27 @@ the address is defined in config file`,
 		Init()
 		bootstrap.InitAria2()
 		bootstrap.InitQbittorrent()
+		bootstrap.InitTaskManagers()
+		bootstrap.InitBlockCache()
+		bootstrap.InitListCache()
 		bootstrap.LoadStorages()
+		bootstrap.InitHealthCheck()
 		if !flags.Debug && !flags.Dev {
 			gin.SetMode(gin.ReleaseMode)
 		}
 		r := gin.New()
-		r.Use(gin.LoggerWithWriter(log.StandardLogger().Out), gin.RecoveryWithWriter(log.StandardLogger().Out))
+		r.Use(middlewares.EventsToken, gin.LoggerWithWriter(log.StandardLogger().Out), gin.RecoveryWithWriter(log.StandardLogger().Out))
 		server.Init(r)
 		base := fmt.Sprintf("%s:%d", conf.Conf.Address, conf.Conf.Port)
 		utils.Log.Infof("start server @ %s", base)
 		srv := &http.Server{Addr: base, Handler: r}
+		if conf.Conf.Scheme.Https {
+			tlsConfig, err := mtls.Init(conf.Conf.Scheme)
+			if err != nil {
+				utils.Log.Fatalf("failed init client certificate auth: %+v", err)
+			}
+			srv.TLSConfig = tlsConfig
+		}
 		go func() {
 			var err error
 			if conf.Conf.Scheme.Https {
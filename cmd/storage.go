@@ -4,8 +4,16 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
-	"github.com/alist-org/alist/v3/internal/db"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -13,40 +21,314 @@ import (
 var storageCmd = &cobra.Command{
 	Use:   "storage",
 	Short: "Manage storage",
+	Long: `Manage storage in the database offline, or in a running server through the admin api with --server.
+The storages are referenced by the id or the mount path.`,
+}
+
+// resolveStorage find the storage by the id or the mount path
+func resolveStorage(b storageBackend, idOrPath string) (*model.Storage, error) {
+	if id, err := strconv.ParseUint(idOrPath, 10, 64); err == nil {
+		return b.Get(uint(id))
+	}
+	storages, err := b.List()
+	if err != nil {
+		return nil, err
+	}
+	mountPath := utils.FixAndCleanPath(idOrPath)
+	for i := range storages {
+		if storages[i].MountPath == mountPath {
+			return &storages[i], nil
+		}
+	}
+	return nil, errors.Errorf("storage [%s] not found", mountPath)
+}
+
+// readInput read the file, or stdin if it's -
+func readInput(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(name)
+}
+
+func printStorages(storages []model.Storage) {
+	if jsonOutput {
+		printJSON(storages)
+		return
+	}
+	rows := [][]string{{"ID", "MOUNT PATH", "DRIVER", "ORDER", "ENABLED", "STATUS", "REMARK"}}
+	for _, s := range storages {
+		rows = append(rows, []string{strconv.Itoa(int(s.ID)), s.MountPath, s.Driver, strconv.Itoa(s.Order),
+			strconv.FormatBool(!s.Disabled), s.Status, s.Remark})
+	}
+	printTable(rows)
+}
+
+func printStorage(storage *model.Storage) {
+	if jsonOutput {
+		printJSON(storage)
+		return
+	}
+	fields, err := toJSONMap(storage)
+	exitOnErr(err, "failed convert storage")
+	addition := fields["addition"]
+	delete(fields, "addition")
+	var rows [][]string
+	for _, key := range sortedKeys(fields) {
+		rows = append(rows, []string{key, fmt.Sprint(fields[key])})
+	}
+	if additionMap, err := parseObject(fmt.Sprint(addition)); err == nil {
+		for _, key := range sortedKeys(additionMap) {
+			rows = append(rows, []string{"addition." + key, fmt.Sprint(additionMap[key])})
+		}
+	}
+	printTable(rows)
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func init() {
-	var mountPath string
-	var disable = &cobra.Command{
-		Use:   "disable",
-		Short: "Disable a storage",
+	RootCmd.AddCommand(storageCmd)
+	addRemoteFlags(storageCmd)
+	addOutputFlags(storageCmd)
+
+	storageCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List storages",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			storages, err := newStorageBackend().List()
+			exitOnErr(err, "failed list storages")
+			printStorages(storages)
+		},
+	})
+
+	storageCmd.AddCommand(&cobra.Command{
+		Use:   "get ID|MOUNT_PATH",
+		Short: "Show a storage",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			storage, err := resolveStorage(newStorageBackend(), args[0])
+			exitOnErr(err, "failed get storage")
+			printStorage(storage)
+		},
+	})
+
+	var createFlags storageFlags
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Create a storage",
+		Long: `Create a storage, the fields are taken from the defaults of the driver, --from, --addition, --set and the flags in order.
+e.g. alist storage create --driver Local --mount-path /local --set root_folder_path=/data --set show_hidden=true`,
+		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			Init()
-			storage, err := db.GetStorageByMountPath(mountPath)
-			if err != nil {
-				utils.Log.Errorf("failed to query storage: %+v", err)
+			b := newStorageBackend()
+			storage, err := createFlags.build(cmd, b, nil)
+			exitOnErr(err, "invalid storage")
+			id, err := b.Create(*storage)
+			exitOnErr(err, "failed create storage")
+			if jsonOutput {
+				printJSON(map[string]uint{"id": id})
 			} else {
-				storage.Disabled = true
-				err = db.UpdateStorage(storage)
-				if err != nil {
-					utils.Log.Errorf("failed to update storage: %+v", err)
-				} else {
-					utils.Log.Infof("Storage with mount path [%s] have been disabled", mountPath)
-				}
+				utils.Log.Infof("storage [%s] has been created with id %d", storage.MountPath, id)
 			}
 		},
 	}
-	disable.Flags().StringVarP(&mountPath, "mount-path", "m", "", "The mountPath of storage")
-	RootCmd.AddCommand(storageCmd)
-	storageCmd.AddCommand(disable)
+	createFlags.add(create, true)
+	storageCmd.AddCommand(create)
+
+	var updateFlags storageFlags
+	update := &cobra.Command{
+		Use:   "update ID|MOUNT_PATH",
+		Short: "Update a storage",
+		Long: `Update a storage, the given fields are changed and the others are kept.
+e.g. alist storage update /local --set show_hidden=false --set remark=backup`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			b := newStorageBackend()
+			old, err := resolveStorage(b, args[0])
+			exitOnErr(err, "failed get storage")
+			storage, err := updateFlags.build(cmd, b, old)
+			exitOnErr(err, "invalid storage")
+			exitOnErr(b.Update(*storage), "failed update storage")
+			if jsonOutput {
+				printJSON(map[string]uint{"id": storage.ID})
+			} else {
+				utils.Log.Infof("storage [%s] has been updated", storage.MountPath)
+			}
+		},
+	}
+	updateFlags.add(update, false)
+	storageCmd.AddCommand(update)
 
-	// Here you will define your flags and configuration settings.
+	for _, action := range []struct {
+		name, short, done string
+		fn                func(b storageBackend, id uint) error
+	}{
+		{"enable", "Enable a storage", "enabled", storageBackend.Enable},
+		{"disable", "Disable a storage", "disabled", storageBackend.Disable},
+		{"delete", "Delete a storage", "deleted", storageBackend.Delete},
+		{"reload", "Reload a storage in the running server", "reloaded", storageBackend.Reload},
+	} {
+		action := action
+		var mountPath string
+		cmd := &cobra.Command{
+			Use:   action.name + " ID|MOUNT_PATH",
+			Short: action.short,
+			Args:  cobra.MaximumNArgs(1),
+			Run: func(cmd *cobra.Command, args []string) {
+				if len(args) > 0 {
+					mountPath = args[0]
+				}
+				if mountPath == "" {
+					exitOnErr(errors.New("the id or mount path is required"), "invalid args")
+				}
+				b := newStorageBackend()
+				storage, err := resolveStorage(b, mountPath)
+				exitOnErr(err, "failed get storage")
+				exitOnErr(action.fn(b, storage.ID), "failed "+action.name+" storage")
+				if jsonOutput {
+					printJSON(map[string]uint{"id": storage.ID})
+				} else {
+					utils.Log.Infof("Storage with mount path [%s] have been %s", storage.MountPath, action.done)
+				}
+			},
+		}
+		// kept for the compatibility of `storage disable -m MOUNT_PATH`
+		cmd.Flags().StringVarP(&mountPath, "mount-path", "m", "", "The mountPath of storage")
+		storageCmd.AddCommand(cmd)
+	}
+}
+
+// storageFlags are the flags to build the storage to create or update
+type storageFlags struct {
+	from      string
+	driver    string
+	mountPath string
+	addition  string
+	set       []string
+	disabled  bool
+}
 
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// storageCmd.PersistentFlags().String("foo", "", "A help for foo")
+func (f *storageFlags) add(cmd *cobra.Command, create bool) {
+	cmd.Flags().StringVarP(&f.from, "from", "f", "", "the json file of the storage, - for stdin, the addition can be an object")
+	if create {
+		cmd.Flags().StringVar(&f.driver, "driver", "", "the driver of the storage, see `alist driver list`")
+	}
+	cmd.Flags().StringVarP(&f.mountPath, "mount-path", "m", "", "the mount path of the storage")
+	cmd.Flags().StringVar(&f.addition, "addition", "", "the json object of the addition of the driver, @FILE to read from the file")
+	cmd.Flags().StringArrayVar(&f.set, "set", nil, "set a field of the storage or the addition, KEY=VALUE, repeatable, see `alist driver info DRIVER`")
+	cmd.Flags().BoolVar(&f.disabled, "disabled", false, "whether the storage is disabled")
+}
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// storageCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+// build the storage from the flags, the fields of the old storage are kept if it's given,
+// otherwise the defaults of the driver are used
+func (f *storageFlags) build(cmd *cobra.Command, b storageBackend, old *model.Storage) (*model.Storage, error) {
+	fields, addition := map[string]any{}, map[string]any{}
+	var err error
+	if old != nil {
+		if fields, err = toJSONMap(old); err != nil {
+			return nil, err
+		}
+		if addition, err = parseObject(old.Addition); err != nil {
+			return nil, errors.WithMessage(err, "invalid addition of the storage")
+		}
+	}
+	var from map[string]any
+	if f.from != "" {
+		data, err := readInput(f.from)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if from, err = parseObject(string(data)); err != nil {
+			return nil, errors.WithMessagef(err, "invalid storage in %s", f.from)
+		}
+	}
+	driverName := fmt.Sprint(fields["driver"])
+	if old == nil {
+		if driverName, _ = from["driver"].(string); f.driver != "" {
+			driverName = f.driver
+		}
+		if driverName == "" {
+			return nil, errors.New("the driver is required")
+		}
+	}
+	info, err := b.DriverInfo(driverName)
+	if err != nil {
+		return nil, err
+	}
+	items := newItemIndex(info)
+	if old == nil {
+		fields, addition = items.defaults()
+	}
+	if from != nil {
+		if a, ok := from["addition"]; ok {
+			if s, ok := a.(string); ok {
+				if a, err = parseObject(s); err != nil {
+					return nil, errors.WithMessage(err, "invalid addition")
+				}
+			}
+			m, ok := a.(map[string]any)
+			if !ok {
+				return nil, errors.New("the addition should be an object")
+			}
+			mergeMap(addition, m)
+			delete(from, "addition")
+		}
+		// the id, driver and status can't be changed
+		for _, key := range []string{"id", "driver", "status", "modified"} {
+			delete(from, key)
+		}
+		mergeMap(fields, from)
+	}
+	if f.addition != "" {
+		data := []byte(f.addition)
+		if strings.HasPrefix(f.addition, "@") {
+			if data, err = readInput(f.addition[1:]); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+		m, err := parseObject(string(data))
+		if err != nil {
+			return nil, errors.WithMessage(err, "invalid addition")
+		}
+		mergeMap(addition, m)
+	}
+	for _, kv := range f.set {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, errors.Errorf("invalid --set %s, should be KEY=VALUE", kv)
+		}
+		if err := items.set(fields, addition, key, value); err != nil {
+			return nil, err
+		}
+	}
+	if f.mountPath != "" {
+		fields["mount_path"] = f.mountPath
+	}
+	if cmd.Flags().Changed("disabled") {
+		fields["disabled"] = f.disabled
+	}
+	fields["driver"] = driverName
+	if err := items.checkRequired(fields, addition); err != nil {
+		return nil, err
+	}
+	if fields["addition"], err = utils.Json.MarshalToString(addition); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var storage model.Storage
+	if err := fromJSONMap(fields, &storage); err != nil {
+		return nil, errors.WithMessage(err, "invalid fields of storage")
+	}
+	if old != nil {
+		storage.ID = old.ID
+	}
+	return &storage, nil
 }
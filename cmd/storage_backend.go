@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+)
+
+// storageBackend manage the storages in the database offline or in the running server
+type storageBackend interface {
+	List() ([]model.Storage, error)
+	Get(id uint) (*model.Storage, error)
+	Create(storage model.Storage) (uint, error)
+	Update(storage model.Storage) error
+	Enable(id uint) error
+	Disable(id uint) error
+	Delete(id uint) error
+	Reload(id uint) error
+	DriverInfo(name string) (driver.Info, error)
+}
+
+func newStorageBackend() storageBackend {
+	if isRemote() {
+		return remoteStorages{newRemoteClient()}
+	}
+	Init()
+	return localStorages{}
+}
+
+// localStorages change the database offline, so it refuses to change while the server is running,
+// the changes would be overwritten by the server or not loaded until restart
+type localStorages struct{}
+
+func (localStorages) checkOffline() error {
+	if serverRunning() {
+		return errors.New("the server is running, use --server to manage the storages through it")
+	}
+	return nil
+}
+
+func (localStorages) List() ([]model.Storage, error) {
+	storages, _, err := db.GetStorages(1, model.MaxInt)
+	return storages, err
+}
+
+func (localStorages) Get(id uint) (*model.Storage, error) {
+	return db.GetStorageById(id)
+}
+
+// Create the storage like the web, the driver is initialized to validate the storage
+func (l localStorages) Create(storage model.Storage) (uint, error) {
+	if err := l.checkOffline(); err != nil {
+		return 0, err
+	}
+	return op.CreateStorage(context.Background(), storage)
+}
+
+// Update the storage like the web, the driver is initialized again to validate the storage
+func (l localStorages) Update(storage model.Storage) error {
+	if err := l.checkOffline(); err != nil {
+		return err
+	}
+	old, err := db.GetStorageById(storage.ID)
+	if err != nil {
+		return err
+	}
+	// the storages are not loaded offline, load the old one to be dropped by op.UpdateStorage,
+	// its init error is ignored since it's initialized again with the changes
+	_ = op.LoadStorage(context.Background(), *old)
+	return op.UpdateStorage(context.Background(), storage)
+}
+
+func (l localStorages) setDisabled(id uint, disabled bool) error {
+	if err := l.checkOffline(); err != nil {
+		return err
+	}
+	storage, err := db.GetStorageById(id)
+	if err != nil {
+		return err
+	}
+	storage.Disabled = disabled
+	if disabled {
+		storage.SetStatus(op.DISABLED)
+	}
+	return db.UpdateStorage(storage)
+}
+
+func (l localStorages) Enable(id uint) error {
+	return l.setDisabled(id, false)
+}
+
+func (l localStorages) Disable(id uint) error {
+	return l.setDisabled(id, true)
+}
+
+func (l localStorages) Delete(id uint) error {
+	if err := l.checkOffline(); err != nil {
+		return err
+	}
+	return db.DeleteStorageById(id)
+}
+
+func (localStorages) Reload(id uint) error {
+	return errors.New("the storage can only be reloaded in the running server, use --server")
+}
+
+func (localStorages) DriverInfo(name string) (driver.Info, error) {
+	info, ok := op.GetDriverInfoMap()[name]
+	if !ok {
+		return info, errors.Errorf("driver [%s] not found", name)
+	}
+	return info, nil
+}
+
+// remoteStorages manage the storages through the admin api of the running server
+type remoteStorages struct {
+	*remoteClient
+}
+
+func (r remoteStorages) List() ([]model.Storage, error) {
+	var page struct {
+		Content []model.Storage `json:"content"`
+	}
+	err := r.call(http.MethodGet, "/admin/storage/list", nil, nil, &page)
+	return page.Content, err
+}
+
+func (r remoteStorages) Get(id uint) (*model.Storage, error) {
+	var storage model.Storage
+	if err := r.call(http.MethodGet, "/admin/storage/get", idQuery(id), nil, &storage); err != nil {
+		return nil, err
+	}
+	return &storage, nil
+}
+
+func (r remoteStorages) Create(storage model.Storage) (uint, error) {
+	var resp struct {
+		ID uint `json:"id"`
+	}
+	err := r.call(http.MethodPost, "/admin/storage/create", nil, storage, &resp)
+	return resp.ID, err
+}
+
+func (r remoteStorages) Update(storage model.Storage) error {
+	return r.call(http.MethodPost, "/admin/storage/update", nil, storage, nil)
+}
+
+func (r remoteStorages) Enable(id uint) error {
+	return r.call(http.MethodPost, "/admin/storage/enable", idQuery(id), nil, nil)
+}
+
+func (r remoteStorages) Disable(id uint) error {
+	return r.call(http.MethodPost, "/admin/storage/disable", idQuery(id), nil, nil)
+}
+
+func (r remoteStorages) Delete(id uint) error {
+	return r.call(http.MethodPost, "/admin/storage/delete", idQuery(id), nil, nil)
+}
+
+func (r remoteStorages) Reload(id uint) error {
+	return r.call(http.MethodPost, "/admin/storage/reload", idQuery(id), nil, nil)
+}
+
+func (r remoteStorages) DriverInfo(name string) (driver.Info, error) {
+	var info driver.Info
+	err := r.call(http.MethodGet, "/admin/driver/info", map[string]string{"driver": name}, nil, &info)
+	return info, err
+}
+
+func idQuery(id uint) map[string]string {
+	return map[string]string{"id": strconv.FormatUint(uint64(id), 10)}
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// parseObject parse the json object, the numbers are kept as they are
+func parseObject(str string) (map[string]any, error) {
+	m := map[string]any{}
+	if strings.TrimSpace(str) == "" {
+		return m, nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(str))
+	decoder.UseNumber()
+	if err := decoder.Decode(&m); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return m, nil
+}
+
+func toJSONMap(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return parseObject(string(data))
+}
+
+func fromJSONMap(m map[string]any, v any) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(json.Unmarshal(data, v))
+}
+
+func mergeMap(dst, src map[string]any) {
+	for k, v := range src {
+		dst[k] = v
+	}
+}
+
+// itemIndex index the items of the driver info by the names
+type itemIndex struct {
+	info       driver.Info
+	common     map[string]driver.Item
+	additional map[string]driver.Item
+}
+
+func newItemIndex(info driver.Info) *itemIndex {
+	ix := &itemIndex{info: info, common: map[string]driver.Item{}, additional: map[string]driver.Item{}}
+	for _, item := range info.Common {
+		ix.common[item.Name] = item
+	}
+	for _, item := range info.Additional {
+		ix.additional[item.Name] = item
+	}
+	return ix
+}
+
+func isNumberType(typ string) bool {
+	return typ == conf.TypeNumber || strings.HasPrefix(typ, "int") ||
+		strings.HasPrefix(typ, "uint") || strings.HasPrefix(typ, "float")
+}
+
+// itemValue convert the value to the type of the item
+func itemValue(item driver.Item, value string) (any, error) {
+	switch {
+	case item.Type == conf.TypeBool:
+		if value == "" {
+			return false, nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.Errorf("%s should be a bool, got %s", item.Name, value)
+		}
+		return b, nil
+	case isNumberType(item.Type):
+		if value == "" {
+			return json.Number("0"), nil
+		}
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, errors.Errorf("%s should be a number, got %s", item.Name, value)
+		}
+		return json.Number(value), nil
+	case item.Type == conf.TypeSelect && item.Options != "" && value != "":
+		if !utils.SliceContains(strings.Split(item.Options, ","), value) {
+			return nil, errors.Errorf("%s should be one of %s, got %s", item.Name, item.Options, value)
+		}
+	}
+	return value, nil
+}
+
+// defaults returns the default fields of the storage and the addition of the driver
+func (ix *itemIndex) defaults() (fields, addition map[string]any) {
+	fields, addition = map[string]any{}, map[string]any{}
+	for _, item := range ix.info.Common {
+		if item.Default == "" {
+			continue
+		}
+		if v, err := itemValue(item, item.Default); err == nil {
+			fields[item.Name] = v
+		}
+	}
+	// the addition is completed like the one created by the web
+	for _, item := range ix.info.Additional {
+		v, err := itemValue(item, item.Default)
+		if err != nil {
+			v = item.Default
+		}
+		addition[item.Name] = v
+	}
+	return fields, addition
+}
+
+// set the field of the storage or the addition by the name, addition.KEY is always the addition
+func (ix *itemIndex) set(fields, addition map[string]any, key, value string) error {
+	if name, ok := strings.CutPrefix(key, "addition."); ok {
+		item, ok := ix.additional[name]
+		if !ok {
+			return errors.Errorf("unknown addition %s of driver %s", name, ix.info.Config.Name)
+		}
+		v, err := itemValue(item, value)
+		addition[name] = v
+		return err
+	}
+	if key == "disabled" {
+		v, err := itemValue(driver.Item{Name: key, Type: conf.TypeBool}, value)
+		fields[key] = v
+		return err
+	}
+	if item, ok := ix.common[key]; ok {
+		v, err := itemValue(item, value)
+		fields[key] = v
+		return err
+	}
+	if item, ok := ix.additional[key]; ok {
+		v, err := itemValue(item, value)
+		addition[key] = v
+		return err
+	}
+	return errors.Errorf("unknown field %s of driver %s, see `alist driver info %s`", key, ix.info.Config.Name, ix.info.Config.Name)
+}
+
+// checkRequired check the required fields are not empty
+func (ix *itemIndex) checkRequired(fields, addition map[string]any) error {
+	check := func(items []driver.Item, m map[string]any) error {
+		for _, item := range items {
+			if !item.Required {
+				continue
+			}
+			if v, ok := m[item.Name]; !ok || v == nil || v == "" {
+				return errors.Errorf("%s is required", item.Name)
+			}
+		}
+		return nil
+	}
+	if err := check(ix.info.Common, fields); err != nil {
+		return err
+	}
+	return check(ix.info.Additional, addition)
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// fakeStorages keep the storages in memory
+type fakeStorages struct {
+	storageBackend
+	storages []model.Storage
+}
+
+func (f *fakeStorages) List() ([]model.Storage, error) {
+	return f.storages, nil
+}
+
+func (f *fakeStorages) Get(id uint) (*model.Storage, error) {
+	for i := range f.storages {
+		if f.storages[i].ID == id {
+			return &f.storages[i], nil
+		}
+	}
+	return nil, errors.Errorf("storage %d not found", id)
+}
+
+func (f *fakeStorages) DriverInfo(name string) (driver.Info, error) {
+	if name != "Local" {
+		return driver.Info{}, errors.Errorf("driver [%s] not found", name)
+	}
+	return driver.Info{
+		Common: []driver.Item{
+			{Name: "mount_path", Type: conf.TypeString, Required: true},
+			{Name: "order", Type: conf.TypeNumber},
+			{Name: "remark", Type: conf.TypeText},
+			{Name: "webdav_policy", Type: conf.TypeSelect, Options: "native_proxy,use_proxy_url", Default: "native_proxy", Required: true},
+		},
+		Additional: []driver.Item{
+			{Name: "root_folder_path", Type: conf.TypeString, Required: true},
+			{Name: "show_hidden", Type: conf.TypeBool, Default: "true"},
+			{Name: "thumb_cache_num", Type: conf.TypeNumber, Default: "16"},
+		},
+		Config: driver.Config{Name: "Local"},
+	}, nil
+}
+
+func TestResolveStorage(t *testing.T) {
+	b := &fakeStorages{storages: []model.Storage{
+		{ID: 1, MountPath: "/local"},
+		{ID: 2, MountPath: "/data/backup"},
+	}}
+	tests := []struct {
+		idOrPath string
+		id       uint
+		isErr    bool
+	}{
+		{idOrPath: "2", id: 2},
+		{idOrPath: "/local", id: 1},
+		{idOrPath: "data/backup/", id: 2},
+		{idOrPath: "3", isErr: true},
+		{idOrPath: "/none", isErr: true},
+	}
+	for _, tt := range tests {
+		storage, err := resolveStorage(b, tt.idOrPath)
+		if tt.isErr {
+			if err == nil {
+				t.Errorf("%s: expect error, got %+v", tt.idOrPath, storage)
+			}
+			continue
+		}
+		if err != nil || storage.ID != tt.id {
+			t.Errorf("%s: expect storage %d, got %+v %v", tt.idOrPath, tt.id, storage, err)
+		}
+	}
+}
+
+func TestStorageFlagsBuild(t *testing.T) {
+	from := filepath.Join(t.TempDir(), "storage.json")
+	if err := os.WriteFile(from, []byte(`{"driver":"Local","mount_path":"/from","status":"work","addition":{"root_folder_path":"/srv"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := &model.Storage{ID: 1, Driver: "Local", MountPath: "/local", Order: 3, Proxy: model.Proxy{WebdavPolicy: "native_proxy"}, Addition: `{"root_folder_path":"/data","show_hidden":true,"thumb_cache_num":16}`}
+	tests := []struct {
+		name     string
+		old      *model.Storage
+		args     []string
+		expect   func(s *model.Storage) bool
+		addition string
+		err      string
+	}{
+		{
+			name:     "create with the defaults",
+			args:     []string{"--driver", "Local", "-m", "/local", "--set", "root_folder_path=/data"},
+			expect:   func(s *model.Storage) bool { return s.MountPath == "/local" && s.WebdavPolicy == "native_proxy" },
+			addition: `{"root_folder_path":"/data","show_hidden":true,"thumb_cache_num":16}`,
+		},
+		{
+			name:     "set the fields and the addition by type",
+			args:     []string{"--driver", "Local", "--set", "mount_path=/a", "--set", "order=2", "--set", "addition.root_folder_path=/b", "--set", "show_hidden=false", "--set", "thumb_cache_num=8", "--set", "remark=a=b"},
+			expect:   func(s *model.Storage) bool { return s.MountPath == "/a" && s.Order == 2 && s.Remark == "a=b" },
+			addition: `{"root_folder_path":"/b","show_hidden":false,"thumb_cache_num":8}`,
+		},
+		{
+			name:     "from the file and the flags override it",
+			args:     []string{"--from", from, "--addition", `{"show_hidden":false}`, "--disabled"},
+			expect:   func(s *model.Storage) bool { return s.MountPath == "/from" && s.Disabled && s.Status == "" },
+			addition: `{"root_folder_path":"/srv","show_hidden":false,"thumb_cache_num":16}`,
+		},
+		{
+			name: "update keep the old fields",
+			old:  old,
+			args: []string{"--set", "remark=backup", "--set", "show_hidden=false"},
+			expect: func(s *model.Storage) bool {
+				return s.ID == 1 && s.MountPath == "/local" && s.Order == 3 && s.Remark == "backup"
+			},
+			addition: `{"root_folder_path":"/data","show_hidden":false,"thumb_cache_num":16}`,
+		},
+		{name: "driver required", args: []string{"-m", "/a"}, err: "the driver is required"},
+		{name: "unknown driver", args: []string{"--driver", "None"}, err: "not found"},
+		{name: "invalid set", args: []string{"--driver", "Local", "--set", "root_folder_path"}, err: "should be KEY=VALUE"},
+		{name: "unknown field", args: []string{"--driver", "Local", "--set", "none=1"}, err: "unknown field none"},
+		{name: "unknown addition", args: []string{"--driver", "Local", "--set", "addition.order=1"}, err: "unknown addition order"},
+		{name: "invalid bool", args: []string{"--driver", "Local", "--set", "show_hidden=yes"}, err: "should be a bool"},
+		{name: "invalid number", args: []string{"--driver", "Local", "--set", "order=first"}, err: "should be a number"},
+		{name: "invalid option", args: []string{"--driver", "Local", "--set", "webdav_policy=302_redirect"}, err: "should be one of"},
+		{name: "required", args: []string{"--driver", "Local", "-m", "/a"}, err: "root_folder_path is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f storageFlags
+			cmd := &cobra.Command{}
+			f.add(cmd, tt.old == nil)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("failed parse flags: %+v", err)
+			}
+			storage, err := f.build(cmd, &fakeStorages{}, tt.old)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expect error %s, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed build: %+v", err)
+			}
+			if storage.Driver != "Local" || !tt.expect(storage) {
+				t.Errorf("unexpected storage: %+v", storage)
+			}
+			if storage.Addition != tt.addition {
+				t.Errorf("expect addition %s, got %s", tt.addition, storage.Addition)
+			}
+		})
+	}
+}
+
+func TestLocalStoragesRefuseWhileRunning(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(c *conf.Config) { conf.Conf = c }(conf.Conf)
+	conf.Conf = conf.DefaultConfig()
+	conf.Conf.Port = l.Addr().(*net.TCPAddr).Port
+	if _, err := (localStorages{}).Create(model.Storage{Driver: "Local"}); err == nil || !strings.Contains(err.Error(), "server is running") {
+		t.Errorf("expect refused while the server is running, got %v", err)
+	}
+	if err := (localStorages{}).Delete(1); err == nil {
+		t.Errorf("expect refused while the server is running")
+	}
+	_ = l.Close()
+	if serverRunning() {
+		t.Errorf("expect the server not running after closed")
+	}
+}
@@ -39,14 +39,14 @@ func (d *Pan115) List(ctx context.Context, dir model.Obj, args model.ListArgs) (
 		return nil, err
 	}
 	return utils.SliceConvert(files, func(src driver115.File) (model.Obj, error) {
-		return src, nil
+		return &FileObj{File: src}, nil
 	})
 }
 
 func (d *Pan115) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
 	downloadInfo, err := d.client.
 		SetUserAgent(driver115.UA115Browser).
-		Download(file.(driver115.File).PickCode)
+		Download(file.(*FileObj).PickCode)
 	// recover for upload
 	d.client.SetUserAgent(driver115.UA115Desktop)
 	if err != nil {
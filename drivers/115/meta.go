@@ -13,11 +13,13 @@ type Addition struct {
 }
 
 var config = driver.Config{
-	Name:              "115 Cloud",
-	DefaultRoot:       "0",
-	OnlyProxy:         true,
-	OnlyLocal:         true,
-	NoOverwriteUpload: true,
+	Name:               "115 Cloud",
+	DefaultRoot:        "0",
+	OnlyProxy:          true,
+	OnlyLocal:          true,
+	NoOverwriteUpload:  true,
+	NeedSeekableStream: true,
+	RequestPolicy:      true,
 }
 
 func init() {
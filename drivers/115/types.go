@@ -1,8 +1,18 @@
 package _115
 
 import (
+	"strings"
+
 	"github.com/SheltonZhu/115driver/pkg/driver"
 	"github.com/alist-org/alist/v3/internal/model"
 )
 
-var _ model.Obj = (*driver.File)(nil)
+var _ model.Obj = (*FileObj)(nil)
+
+type FileObj struct {
+	driver.File
+}
+
+func (f *FileObj) Hash() (string, string) {
+	return "sha1", strings.ToLower(f.Sha1)
+}
@@ -17,6 +17,7 @@ func (d *Pan115) login() error {
 	}
 	d.client = driver.New(opts...)
 	d.client.SetHttpClient(base.HttpClient)
+	base.ApplyPolicy(d.client.Client, d.RequestPolicy)
 	cr := &driver.Credential{}
 	if d.Addition.QRCodeToken != "" {
 		s := &driver.QRCodeSession{
@@ -32,7 +32,12 @@ type Pan123 struct {
 }
 
 func (d *Pan123) Config() driver.Config {
-	return config
+	c := config
+	if !d.StreamUpload {
+		// the md5 of the whole file is needed before the upload
+		c.PreHash = []string{utils.MD5}
+	}
+	return c
 }
 
 func (d *Pan123) GetAddition() driver.Additional {
@@ -179,6 +184,7 @@ func (d *Pan123) Remove(ctx context.Context, obj model.Obj) error {
 func (d *Pan123) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
 	const DEFAULT int64 = 10485760
 	var uploadFile io.Reader
+	var etag string
 	h := md5.New()
 	if d.StreamUpload && stream.GetSize() > DEFAULT {
 		// 只计算前10MIB
@@ -193,6 +199,7 @@ func (d *Pan123) Put(ctx context.Context, dstDir model.Obj, stream model.FileStr
 		h.Write(num)
 		// 拼装
 		uploadFile = io.MultiReader(buf, stream)
+		etag = hex.EncodeToString(h.Sum(nil))
 	} else {
 		// 计算完整文件MD5
 		tempFile, err := utils.CreateTempFile(stream.GetReadCloser())
@@ -203,16 +210,19 @@ func (d *Pan123) Put(ctx context.Context, dstDir model.Obj, stream model.FileStr
 			_ = tempFile.Close()
 			_ = os.Remove(tempFile.Name())
 		}()
-		if _, err = io.Copy(h, tempFile); err != nil {
-			return err
-		}
-		_, err = tempFile.Seek(0, io.SeekStart)
-		if err != nil {
-			return err
+		// the md5 is computed before if the file is stored
+		if etag = stream.GetHash(utils.MD5); etag == "" {
+			if _, err = io.Copy(h, tempFile); err != nil {
+				return err
+			}
+			_, err = tempFile.Seek(0, io.SeekStart)
+			if err != nil {
+				return err
+			}
+			etag = hex.EncodeToString(h.Sum(nil))
 		}
 		uploadFile = tempFile
 	}
-	etag := hex.EncodeToString(h.Sum(nil))
 	data := base.Json{
 		"driveId":      0,
 		"duplicate":    2, // 2->覆盖 1->重命名 0->默认
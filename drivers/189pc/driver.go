@@ -27,7 +27,10 @@ type Cloud189PC struct {
 }
 
 func (y *Cloud189PC) Config() driver.Config {
-	return config
+	c := config
+	// the rapid upload computes the md5 of the whole file and the slices before the upload
+	c.NeedSeekableStream = y.RapidUpload
+	return c
 }
 
 func (y *Cloud189PC) GetAddition() driver.Additional {
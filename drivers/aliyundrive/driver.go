@@ -32,6 +32,7 @@ type AliDrive struct {
 	cron        *cron.Cron
 	DriveId     string
 	UserID      string
+	client      *resty.Client
 }
 
 func (d *AliDrive) Config() driver.Config {
@@ -45,6 +46,7 @@ func (d *AliDrive) GetAddition() driver.Additional {
 func (d *AliDrive) Init(ctx context.Context) error {
 	// TODO login / refresh token
 	//op.MustSaveDriverStorage(d)
+	d.client = base.NewPolicyClient(&d.Storage)
 	err := d.refreshToken()
 	if err != nil {
 		return err
@@ -164,7 +166,7 @@ func (d *AliDrive) Remove(ctx context.Context, obj model.Obj) error {
 }
 
 func (d *AliDrive) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
-	file := model.FileStream{
+	file := &model.FileStream{
 		Obj:        stream,
 		ReadCloser: stream,
 		Mimetype:   stream.GetMimetype(),
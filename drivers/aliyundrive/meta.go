@@ -16,8 +16,9 @@ type Addition struct {
 }
 
 var config = driver.Config{
-	Name:        "Aliyundrive",
-	DefaultRoot: "root",
+	Name:          "Aliyundrive",
+	DefaultRoot:   "root",
+	RequestPolicy: true,
 	Alert: `warning|There may be an infinite loop bug in this driver.
 Deprecated, no longer maintained and will be removed in a future version.
 We recommend using the official driver AliyundriveOpen.`,
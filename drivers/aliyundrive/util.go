@@ -61,7 +61,7 @@ func (d *AliDrive) refreshToken() error {
 	url := "https://auth.aliyundrive.com/v2/account/token"
 	var resp base.TokenResp
 	var e RespErr
-	_, err := base.RestyClient.R().
+	_, err := d.client.R().
 		//ForceContentType("application/json").
 		SetBody(base.Json{"refresh_token": d.RefreshToken, "grant_type": "refresh_token"}).
 		SetResult(&resp).
@@ -82,7 +82,7 @@ func (d *AliDrive) refreshToken() error {
 }
 
 func (d *AliDrive) request(url, method string, callback base.ReqCallback, resp interface{}) ([]byte, error, RespErr) {
-	req := base.RestyClient.R()
+	req := d.client.R()
 	state, ok := global.Load(d.UserID)
 	if !ok {
 		if url == "https://api.aliyundrive.com/v2/user/get" {
@@ -2,8 +2,6 @@ package aliyundrive_open
 
 import (
 	"context"
-	"io"
-	"math"
 	"net/http"
 	"time"
 
@@ -21,6 +19,7 @@ type AliyundriveOpen struct {
 	base string
 
 	DriveId string
+	client  *resty.Client
 
 	limitList func(ctx context.Context, dir model.Obj) ([]model.Obj, error)
 	limitLink func(ctx context.Context, file model.Obj) (*model.Link, error)
@@ -35,6 +34,7 @@ func (d *AliyundriveOpen) GetAddition() driver.Additional {
 }
 
 func (d *AliyundriveOpen) Init(ctx context.Context) error {
+	d.client = base.NewPolicyClient(&d.Storage)
 	res, err := d.request("/adrive/v1.0/user/getDriveInfo", http.MethodPost, nil)
 	if err != nil {
 		return err
@@ -150,59 +150,16 @@ func (d *AliyundriveOpen) Remove(ctx context.Context, obj model.Obj) error {
 }
 
 func (d *AliyundriveOpen) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
-	// rapid_upload is not currently supported
-	// 1. create
-	const DEFAULT int64 = 20971520
-	createData := base.Json{
-		"drive_id":        d.DriveId,
-		"parent_file_id":  dstDir.GetID(),
-		"name":            stream.GetName(),
-		"type":            "file",
-		"check_name_mode": "ignore",
-	}
-	count := 1
-	if stream.GetSize() > DEFAULT {
-		count = int(math.Ceil(float64(stream.GetSize()) / float64(DEFAULT)))
-		createData["part_info_list"] = makePartInfos(count)
-	}
-	var createResp CreateResp
-	_, err := d.request("/adrive/v1.0/openFile/create", http.MethodPost, func(req *resty.Request) {
-		req.SetBody(createData).SetResult(&createResp)
-	})
-	if err != nil {
-		return err
-	}
-	// 2. upload
-	preTime := time.Now()
-	for i := 1; i <= len(createResp.PartInfoList); i++ {
-		if utils.IsCanceled(ctx) {
-			return ctx.Err()
-		}
-		err = d.uploadPart(ctx, i, count, utils.NewMultiReadable(io.LimitReader(stream, DEFAULT)), &createResp, true)
-		if err != nil {
-			return err
-		}
-		if count > 0 {
-			up(i * 100 / count)
-		}
-		// refresh upload url if 50 minutes passed
-		if time.Since(preTime) > 50*time.Minute {
-			createResp.PartInfoList, err = d.getUploadUrl(count, createResp.FileId, createResp.UploadId)
-			if err != nil {
-				return err
-			}
-			preTime = time.Now()
-		}
-	}
-	// 3. complete
-	_, err = d.request("/adrive/v1.0/openFile/complete", http.MethodPost, func(req *resty.Request) {
-		req.SetBody(base.Json{
-			"drive_id":  d.DriveId,
-			"file_id":   createResp.FileId,
-			"upload_id": createResp.UploadId,
-		})
-	})
-	return err
+	return d.upload(ctx, dstDir, stream, new(model.UploadCheckpoint), up)
+}
+
+func (d *AliyundriveOpen) PutResumable(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, cp *model.UploadCheckpoint, up driver.UpdateProgress) error {
+	return d.upload(ctx, dstDir, stream, cp, up)
+}
+
+// AbortResumable do nothing, the file is not created until the upload is completed
+func (d *AliyundriveOpen) AbortResumable(ctx context.Context, cp *model.UploadCheckpoint) error {
+	return nil
 }
 
 func (d *AliyundriveOpen) Other(ctx context.Context, args model.OtherArgs) (interface{}, error) {
@@ -229,4 +186,17 @@ func (d *AliyundriveOpen) Other(ctx context.Context, args model.OtherArgs) (inte
 	return resp, nil
 }
 
+func (d *AliyundriveOpen) GetSpace(ctx context.Context) (int64, int64, error) {
+	res, err := d.request("/adrive/v1.0/user/getSpaceInfo", http.MethodPost, func(req *resty.Request) {
+		req.SetContext(ctx)
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	info := utils.Json.Get(res, "personal_space_info")
+	return info.Get("used_size").ToInt64(), info.Get("total_size").ToInt64(), nil
+}
+
 var _ driver.Driver = (*AliyundriveOpen)(nil)
+var _ driver.SpaceGetter = (*AliyundriveOpen)(nil)
+var _ driver.ResumablePut = (*AliyundriveOpen)(nil)
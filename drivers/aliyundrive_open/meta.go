@@ -28,6 +28,7 @@ var config = driver.Config{
 	NeedMs:            false,
 	DefaultRoot:       "root",
 	NoOverwriteUpload: true,
+	RequestPolicy:     true,
 }
 
 func init() {
@@ -1,6 +1,7 @@
 package aliyundrive_open
 
 import (
+	"strings"
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/model"
@@ -32,16 +33,28 @@ type File struct {
 	UpdatedAt     time.Time  `json:"updated_at"`
 }
 
-func fileToObj(f File) *model.ObjThumb {
-	return &model.ObjThumb{
-		Object: model.Object{
-			ID:       f.FileId,
-			Name:     f.Name,
-			Size:     f.Size,
-			Modified: f.UpdatedAt,
-			IsFolder: f.Type == "folder",
+type Object struct {
+	model.ObjThumb
+	ContentHash string
+}
+
+func (o *Object) Hash() (string, string) {
+	return "sha1", strings.ToLower(o.ContentHash)
+}
+
+func fileToObj(f File) *Object {
+	return &Object{
+		ObjThumb: model.ObjThumb{
+			Object: model.Object{
+				ID:       f.FileId,
+				Name:     f.Name,
+				Size:     f.Size,
+				Modified: f.UpdatedAt,
+				IsFolder: f.Type == "folder",
+			},
+			Thumbnail: model.Thumbnail{Thumbnail: f.Thumbnail},
 		},
-		Thumbnail: model.Thumbnail{Thumbnail: f.Thumbnail},
+		ContentHash: f.ContentHash,
 	}
 }
 
@@ -4,10 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/go-resty/resty/v2"
@@ -22,7 +28,7 @@ func (d *AliyundriveOpen) refreshToken() error {
 	}
 	var resp base.TokenResp
 	var e ErrResp
-	_, err := base.RestyClient.R().
+	_, err := d.client.R().
 		ForceContentType("application/json").
 		SetBody(base.Json{
 			"client_id":     d.ClientID,
@@ -48,7 +54,7 @@ func (d *AliyundriveOpen) refreshToken() error {
 }
 
 func (d *AliyundriveOpen) request(uri, method string, callback base.ReqCallback, retry ...bool) ([]byte, error) {
-	req := base.RestyClient.R()
+	req := d.client.R()
 	// TODO check whether access_token is expired
 	req.SetHeader("Authorization", "Bearer "+d.AccessToken)
 	if method == http.MethodPost {
@@ -110,6 +116,101 @@ func (d *AliyundriveOpen) getFiles(fileId string) ([]File, error) {
 	return res, nil
 }
 
+// partSize is the size of the parts to upload
+const partSize int64 = 20971520
+
+type uploadSession struct {
+	FileId   string `json:"file_id"`
+	UploadId string `json:"upload_id"`
+}
+
+// upload the parts of the file, the created file and the uploaded parts are kept in cp
+func (d *AliyundriveOpen) upload(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, cp *model.UploadCheckpoint, up driver.UpdateProgress) error {
+	// rapid_upload is not currently supported
+	count := 1
+	if stream.GetSize() > partSize {
+		count = int(math.Ceil(float64(stream.GetSize()) / float64(partSize)))
+	}
+	var session uploadSession
+	ok, err := cp.GetSession(&session)
+	if err != nil {
+		return err
+	}
+	var createResp CreateResp
+	if ok {
+		if cp.Offset%partSize != 0 && cp.Offset != stream.GetSize() {
+			return errs.UploadSessionExpired
+		}
+		createResp.FileId, createResp.UploadId = session.FileId, session.UploadId
+		createResp.PartInfoList, err = d.getUploadUrl(count, session.FileId, session.UploadId)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errs.UploadSessionExpired, err)
+		}
+	} else {
+		// 1. create
+		createData := base.Json{
+			"drive_id":        d.DriveId,
+			"parent_file_id":  dstDir.GetID(),
+			"name":            stream.GetName(),
+			"type":            "file",
+			"check_name_mode": "ignore",
+		}
+		if count > 1 {
+			createData["part_info_list"] = makePartInfos(count)
+		}
+		_, err = d.request("/adrive/v1.0/openFile/create", http.MethodPost, func(req *resty.Request) {
+			req.SetBody(createData).SetResult(&createResp)
+		})
+		if err != nil {
+			return err
+		}
+		session = uploadSession{FileId: createResp.FileId, UploadId: createResp.UploadId}
+		if err = cp.Commit(0, session); err != nil {
+			return err
+		}
+	}
+	// 2. upload
+	preTime := time.Now()
+	// the last part may be shorter
+	uploaded := int((cp.Offset + partSize - 1) / partSize)
+	for i := uploaded + 1; i <= len(createResp.PartInfoList); i++ {
+		if utils.IsCanceled(ctx) {
+			return ctx.Err()
+		}
+		err = d.uploadPart(ctx, i, count, utils.NewMultiReadable(io.LimitReader(stream, partSize)), &createResp, true)
+		if err != nil {
+			return err
+		}
+		offset := int64(i) * partSize
+		if offset > stream.GetSize() {
+			offset = stream.GetSize()
+		}
+		if err = cp.Commit(offset, session); err != nil {
+			return err
+		}
+		if count > 0 {
+			up(i * 100 / count)
+		}
+		// refresh upload url if 50 minutes passed
+		if time.Since(preTime) > 50*time.Minute {
+			createResp.PartInfoList, err = d.getUploadUrl(count, createResp.FileId, createResp.UploadId)
+			if err != nil {
+				return err
+			}
+			preTime = time.Now()
+		}
+	}
+	// 3. complete
+	_, err = d.request("/adrive/v1.0/openFile/complete", http.MethodPost, func(req *resty.Request) {
+		req.SetBody(base.Json{
+			"drive_id":  d.DriveId,
+			"file_id":   createResp.FileId,
+			"upload_id": createResp.UploadId,
+		})
+	})
+	return err
+}
+
 func makePartInfos(size int) []base.Json {
 	partInfoList := make([]base.Json, size)
 	for i := 0; i < size; i++ {
@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"mime/multipart"
+	"net/http"
 	"os"
 	stdpath "path"
 	"strconv"
@@ -17,6 +19,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/go-resty/resty/v2"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -24,6 +27,7 @@ type BaiduNetdisk struct {
 	model.Storage
 	Addition
 	AccessToken string
+	client      *resty.Client
 }
 
 func (d *BaiduNetdisk) Config() driver.Config {
@@ -35,6 +39,7 @@ func (d *BaiduNetdisk) GetAddition() driver.Additional {
 }
 
 func (d *BaiduNetdisk) Init(ctx context.Context) error {
+	d.client = base.NewPolicyClient(&d.Storage)
 	return d.refreshToken()
 }
 
@@ -52,6 +57,27 @@ func (d *BaiduNetdisk) List(ctx context.Context, dir model.Obj, args model.ListA
 	})
 }
 
+func (d *BaiduNetdisk) ListPage(ctx context.Context, dir model.Obj, args model.ListPageArgs) (*model.ObjsPage, error) {
+	// the cursor is the start of the page, the max limit is 1000
+	start, _ := strconv.Atoi(args.Cursor)
+	limit := args.PerPage
+	if limit > 1000 {
+		limit = 1000
+	}
+	files, err := d.getFilesPage(dir.GetPath(), start, limit)
+	if err != nil {
+		return nil, err
+	}
+	objs, _ := utils.SliceConvert(files, func(src File) (model.Obj, error) {
+		return fileToObj(src), nil
+	})
+	page := &model.ObjsPage{Objs: objs}
+	if len(files) == limit {
+		page.NextCursor = strconv.Itoa(start + limit)
+	}
+	return page, nil
+}
+
 func (d *BaiduNetdisk) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
 	if d.DownloadAPI == "crack" {
 		return d.linkCrack(file, args)
@@ -210,10 +236,24 @@ func (d *BaiduNetdisk) Put(ctx context.Context, dstDir model.Obj, stream model.F
 		}
 		u := "https://d.pcs.baidu.com/rest/2.0/pcs/superfile2"
 		params["partseq"] = strconv.Itoa(partseq)
-		res, err := base.RestyClient.R().
+		// the multipart body is built as bytes, so it's sent again as it is when the request is retried
+		body := &bytes.Buffer{}
+		w := multipart.NewWriter(body)
+		part, err := w.CreateFormFile("file", stream.GetName())
+		if err == nil {
+			_, err = part.Write(byteData)
+		}
+		if err == nil {
+			err = w.Close()
+		}
+		if err != nil {
+			return err
+		}
+		res, err := d.client.R().
 			SetContext(ctx).
 			SetQueryParams(params).
-			SetFileReader("file", stream.GetName(), bytes.NewReader(byteData)).
+			SetHeader("Content-Type", w.FormDataContentType()).
+			SetBody(body.Bytes()).
 			Post(u)
 		if err != nil {
 			return err
@@ -227,4 +267,17 @@ func (d *BaiduNetdisk) Put(ctx context.Context, dstDir model.Obj, stream model.F
 	return err
 }
 
+func (d *BaiduNetdisk) GetSpace(ctx context.Context) (int64, int64, error) {
+	var resp QuotaResp
+	_, err := d.request("https://pan.baidu.com/api/quota", http.MethodGet, func(req *resty.Request) {
+		req.SetContext(ctx).SetQueryParam("checkfree", "1")
+	}, &resp)
+	if err != nil {
+		return 0, 0, err
+	}
+	return resp.Used, resp.Total, nil
+}
+
 var _ driver.Driver = (*BaiduNetdisk)(nil)
+var _ driver.SpaceGetter = (*BaiduNetdisk)(nil)
+var _ driver.PagedLister = (*BaiduNetdisk)(nil)
@@ -16,8 +16,10 @@ type Addition struct {
 }
 
 var config = driver.Config{
-	Name:        "BaiduNetdisk",
-	DefaultRoot: "/",
+	Name:               "BaiduNetdisk",
+	DefaultRoot:        "/",
+	NeedSeekableStream: true,
+	RequestPolicy:      true,
 }
 
 func init() {
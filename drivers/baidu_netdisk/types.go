@@ -153,6 +153,13 @@ type DownloadResp2 struct {
 	RequestID int64 `json:"request_id"`
 }
 
+type QuotaResp struct {
+	Errno int   `json:"errno"`
+	Total int64 `json:"total"`
+	Free  int64 `json:"free"`
+	Used  int64 `json:"used"`
+}
+
 type PrecreateResp struct {
 	Path       string `json:"path"`
 	Uploadid   string `json:"uploadid"`
@@ -29,7 +29,7 @@ func (d *BaiduNetdisk) _refreshToken() error {
 	u := "https://openapi.baidu.com/oauth/2.0/token"
 	var resp base.TokenResp
 	var e TokenErrResp
-	_, err := base.RestyClient.R().SetResult(&resp).SetError(&e).SetQueryParams(map[string]string{
+	_, err := d.client.R().SetResult(&resp).SetError(&e).SetQueryParams(map[string]string{
 		"grant_type":    "refresh_token",
 		"refresh_token": d.RefreshToken,
 		"client_id":     d.ClientID,
@@ -50,7 +50,7 @@ func (d *BaiduNetdisk) _refreshToken() error {
 }
 
 func (d *BaiduNetdisk) request(furl string, method string, callback base.ReqCallback, resp interface{}) ([]byte, error) {
-	req := base.RestyClient.R()
+	req := d.client.R()
 	req.SetQueryParam("access_token", d.AccessToken)
 	if callback != nil {
 		callback(req)
@@ -92,10 +92,29 @@ func (d *BaiduNetdisk) post(pathname string, params map[string]string, data inte
 func (d *BaiduNetdisk) getFiles(dir string) ([]File, error) {
 	start := 0
 	limit := 200
+	res := make([]File, 0)
+	for {
+		files, err := d.getFilesPage(dir, start, limit)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			break
+		}
+		res = append(res, files...)
+		start += limit
+	}
+	return res, nil
+}
+
+// getFilesPage get at most limit files from the start
+func (d *BaiduNetdisk) getFilesPage(dir string, start, limit int) ([]File, error) {
 	params := map[string]string{
 		"method": "list",
 		"dir":    dir,
 		"web":    "web",
+		"start":  strconv.Itoa(start),
+		"limit":  strconv.Itoa(limit),
 	}
 	if d.OrderBy != "" {
 		params["order"] = d.OrderBy
@@ -103,22 +122,12 @@ func (d *BaiduNetdisk) getFiles(dir string) ([]File, error) {
 			params["desc"] = "1"
 		}
 	}
-	res := make([]File, 0)
-	for {
-		params["start"] = strconv.Itoa(start)
-		params["limit"] = strconv.Itoa(limit)
-		start += limit
-		var resp ListResp
-		_, err := d.get("/xpan/file", params, &resp)
-		if err != nil {
-			return nil, err
-		}
-		if len(resp.List) == 0 {
-			break
-		}
-		res = append(res, resp.List...)
+	var resp ListResp
+	_, err := d.get("/xpan/file", params, &resp)
+	if err != nil {
+		return nil, err
 	}
-	return res, nil
+	return resp.List, nil
 }
 
 func (d *BaiduNetdisk) linkOfficial(file model.Obj, args model.LinkArgs) (*model.Link, error) {
@@ -15,8 +15,9 @@ type Addition struct {
 }
 
 var config = driver.Config{
-	Name:      "BaiduPhoto",
-	LocalSort: true,
+	Name:               "BaiduPhoto",
+	LocalSort:          true,
+	NeedSeekableStream: true,
 }
 
 func init() {
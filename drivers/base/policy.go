@@ -0,0 +1,112 @@
+package base
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+const (
+	DefaultMaxRetries   = 3
+	DefaultRetryWait    = time.Millisecond * 500
+	DefaultRetryMaxWait = time.Second * 30
+)
+
+// NewPolicyClient return a resty client that follow the request policy of the storage
+func NewPolicyClient(storage *model.Storage) *resty.Client {
+	return ApplyPolicy(NewRestyClient(), storage.RequestPolicy)
+}
+
+// ApplyPolicy make the client retry on network error, 429 and 5xx (see shouldRetry) with
+// exponential backoff and jitter, honor Retry-After of the response,
+// and limit the request rate with a token bucket if qps is set
+func ApplyPolicy(client *resty.Client, policy model.RequestPolicy) *resty.Client {
+	retries := policy.MaxRetries
+	if retries == 0 {
+		retries = DefaultMaxRetries
+	} else if retries < 0 {
+		retries = 0
+	}
+	client.SetRetryCount(retries).
+		SetRetryWaitTime(DefaultRetryWait).
+		SetRetryMaxWaitTime(DefaultRetryMaxWait).
+		AddRetryCondition(shouldRetry).
+		SetRetryAfter(retryAfter)
+	if policy.QPS > 0 {
+		burst := int(policy.QPS)
+		if burst < 1 {
+			burst = 1
+		}
+		limiter := rate.NewLimiter(rate.Limit(policy.QPS), burst)
+		// the middleware is called on every attempt, so retries are limited as well
+		client.OnBeforeRequest(func(c *resty.Client, req *resty.Request) error {
+			return limiter.Wait(req.Context())
+		})
+	}
+	return client
+}
+
+// shouldRetry retry the idempotent requests on network error and 5xx,
+// the others are only retried if they are not processed by the upstream,
+// that is the connection failed or the upstream answered 429
+func shouldRetry(res *resty.Response, err error) bool {
+	if res == nil || res.Request == nil {
+		return err != nil && notSent(err)
+	}
+	// a streaming body can't be sent again
+	if _, ok := res.Request.Body.(io.Reader); ok {
+		return false
+	}
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		return idempotent(res.Request.Method) || notSent(err)
+	}
+	code := res.StatusCode()
+	if code == http.StatusTooManyRequests {
+		return true
+	}
+	return code >= http.StatusInternalServerError && idempotent(res.Request.Method)
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// notSent check if the request failed before it's sent, e.g. failed dial or resolve the host
+func notSent(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// retryAfter parse the Retry-After header, 0 means using the default backoff
+func retryAfter(c *resty.Client, res *resty.Response) (time.Duration, error) {
+	header := res.Header().Get("Retry-After")
+	if header == "" {
+		return 0, nil
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return time.Until(t), nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,112 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/go-resty/resty/v2"
+)
+
+func TestApplyPolicy_Retry(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&count, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+	client := ApplyPolicy(NewRestyClient(), model.RequestPolicy{MaxRetries: 3})
+	start := time.Now()
+	res, err := client.R().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.String() != "ok" || count != 3 {
+		t.Errorf("expect ok after 3 requests, got %s after %d", res.String(), count)
+	}
+	if time.Since(start) < time.Second {
+		t.Errorf("Retry-After is not honored")
+	}
+}
+
+func TestApplyPolicy_NoRetry(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	client := ApplyPolicy(NewRestyClient(), model.RequestPolicy{MaxRetries: -1})
+	_, _ = client.R().Get(server.URL)
+	if count != 1 {
+		t.Errorf("expect 1 request, got %d", count)
+	}
+}
+
+func TestApplyPolicy_QPS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	client := ApplyPolicy(NewRestyClient(), model.RequestPolicy{QPS: 5})
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		if _, err := client.R().Get(server.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 5 burst tokens, then 5 more at 5/s
+	if time.Since(start) < 900*time.Millisecond {
+		t.Errorf("qps is not limited: %s", time.Since(start))
+	}
+}
+
+func TestApplyPolicy_NotIdempotent(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	client := ApplyPolicy(NewRestyClient(), model.RequestPolicy{MaxRetries: 3})
+	// the post rejected by 429 is sent again, but not the one may be processed
+	_, _ = client.R().SetBody(map[string]string{"name": "a"}).Post(server.URL)
+	if count != 2 {
+		t.Errorf("expect 2 requests, got %d", count)
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	dial := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	read := &net.OpError{Op: "read", Err: errors.New("connection reset")}
+	tests := []struct {
+		method string
+		err    error
+		retry  bool
+	}{
+		{method: http.MethodGet, err: read, retry: true},
+		{method: http.MethodPost, err: read, retry: false},
+		{method: http.MethodPost, err: dial, retry: true},
+		{method: http.MethodGet, err: context.Canceled, retry: false},
+	}
+	for _, tt := range tests {
+		res := &resty.Response{Request: &resty.Request{Method: tt.method}}
+		if got := shouldRetry(res, tt.err); got != tt.retry {
+			t.Errorf("%s %v: expect retry %v, got %v", tt.method, tt.err, tt.retry, got)
+		}
+	}
+}
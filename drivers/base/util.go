@@ -1,30 +1,34 @@
 package base
 
 import (
+	"context"
 	"io"
-	"net/http"
-	"strconv"
 
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/pkg/http_range"
 	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
 )
 
-func HandleRange(link *model.Link, file io.ReadSeekCloser, header http.Header, size int64) {
-	if header.Get("Range") != "" {
-		r, err := http_range.ParseRange(header.Get("Range"), size)
-		if err == nil && len(r) > 0 {
-			_, err := file.Seek(r[0].Start, io.SeekStart)
-			if err == nil {
-				link.Data = utils.NewLimitReadCloser(file, func() error {
-					return file.Close()
-				}, r[0].Length)
-				link.Status = http.StatusPartialContent
-				link.Header = http.Header{
-					"Content-Range":  []string{r[0].ContentRange(size)},
-					"Content-Length": []string{strconv.FormatInt(r[0].Length, 10)},
-				}
+// NewSeekRangeReader return a RangeReader that open the file and seek to the start of each range,
+// so the drivers can serve any range of a file that is seekable
+func NewSeekRangeReader(open func(ctx context.Context) (io.ReadSeekCloser, error)) model.RangeReader {
+	return model.RangeReaderFunc(func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+		file, err := open(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if httpRange.Start > 0 {
+			if _, err = file.Seek(httpRange.Start, io.SeekStart); err != nil {
+				_ = file.Close()
+				return nil, errors.Wrapf(err, "failed seek to %d", httpRange.Start)
 			}
 		}
-	}
+		if httpRange.Length < 0 {
+			return file, nil
+		}
+		return utils.NewLimitReadCloser(file, func() error {
+			return file.Close()
+		}, httpRange.Length), nil
+	})
 }
@@ -2,6 +2,7 @@ package ftp
 
 import (
 	"context"
+	"io"
 	stdpath "path"
 
 	"github.com/alist-org/alist/v3/drivers/base"
@@ -64,13 +65,14 @@ func (d *FTP) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*m
 	if err := d.login(); err != nil {
 		return nil, err
 	}
-
-	r := NewFTPFileReader(d.conn, file.GetPath())
-	link := &model.Link{
-		Data: r,
-	}
-	base.HandleRange(link, r, args.Header, file.GetSize())
-	return link, nil
+	return &model.Link{
+		RangeReader: base.NewSeekRangeReader(func(ctx context.Context) (io.ReadSeekCloser, error) {
+			if err := d.login(); err != nil {
+				return nil, err
+			}
+			return NewFTPFileReader(d.conn, file.GetPath()), nil
+		}),
+	}, nil
 }
 
 func (d *FTP) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
@@ -112,58 +112,69 @@ func (d *GoogleDrive) Remove(ctx context.Context, obj model.Obj) error {
 }
 
 func (d *GoogleDrive) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
-	obj := stream.GetOld()
-	var (
-		e    Error
-		url  string
-		data base.Json
-		res  *resty.Response
-		err  error
-	)
-	if obj != nil {
-		url = fmt.Sprintf("https://www.googleapis.com/upload/drive/v3/files/%s?uploadType=resumable&supportsAllDrives=true", obj.GetID())
-		data = base.Json{}
-	} else {
-		data = base.Json{
-			"name":    stream.GetName(),
-			"parents": []string{dstDir.GetID()},
-		}
-		url = "https://www.googleapis.com/upload/drive/v3/files?uploadType=resumable&supportsAllDrives=true"
-	}
-	req := base.NoRedirectClient.R().
-		SetHeaders(map[string]string{
-			"Authorization":           "Bearer " + d.AccessToken,
-			"X-Upload-Content-Type":   stream.GetMimetype(),
-			"X-Upload-Content-Length": strconv.FormatInt(stream.GetSize(), 10),
-		}).
-		SetError(&e).SetBody(data).SetContext(ctx)
-	if obj != nil {
-		res, err = req.Patch(url)
-	} else {
-		res, err = req.Post(url)
-	}
+	putUrl, err := d.createUploadSession(ctx, dstDir, stream)
 	if err != nil {
 		return err
 	}
-	if e.Error.Code != 0 {
-		if e.Error.Code == 401 {
-			err = d.refreshToken()
-			if err != nil {
-				return err
-			}
-			return d.Put(ctx, dstDir, stream, up)
-		}
-		return fmt.Errorf("%s: %v", e.Error.Message, e.Error.Errors)
-	}
-	putUrl := res.Header().Get("location")
 	if stream.GetSize() < d.ChunkSize*1024*1024 {
 		_, err = d.request(putUrl, http.MethodPut, func(req *resty.Request) {
 			req.SetHeader("Content-Length", strconv.FormatInt(stream.GetSize(), 10)).SetBody(stream.GetReadCloser())
 		}, nil)
 	} else {
-		err = d.chunkUpload(ctx, stream, putUrl)
+		err = d.chunkUpload(ctx, stream, putUrl, new(model.UploadCheckpoint), up)
 	}
 	return err
 }
 
+type uploadSession struct {
+	Url string `json:"url"`
+}
+
+func (d *GoogleDrive) PutResumable(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, cp *model.UploadCheckpoint, up driver.UpdateProgress) error {
+	if stream.GetSize() < d.ChunkSize*1024*1024 && cp.Offset == 0 {
+		return d.Put(ctx, dstDir, stream, up)
+	}
+	var session uploadSession
+	ok, err := cp.GetSession(&session)
+	if err != nil {
+		return err
+	}
+	if ok {
+		received, err := d.uploadedBytes(ctx, session.Url, stream.GetSize())
+		if err != nil {
+			return err
+		}
+		if received != cp.Offset {
+			return errs.UploadSessionExpired
+		}
+	} else {
+		session.Url, err = d.createUploadSession(ctx, dstDir, stream)
+		if err != nil {
+			return err
+		}
+		if err = cp.Commit(0, session); err != nil {
+			return err
+		}
+	}
+	return d.chunkUpload(ctx, stream, session.Url, cp, up)
+}
+
+func (d *GoogleDrive) AbortResumable(ctx context.Context, cp *model.UploadCheckpoint) error {
+	var session uploadSession
+	if ok, err := cp.GetSession(&session); !ok || err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, session.Url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+d.AccessToken)
+	res, err := base.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
 var _ driver.Driver = (*GoogleDrive)(nil)
+var _ driver.ResumablePut = (*GoogleDrive)(nil)
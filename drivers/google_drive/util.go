@@ -14,6 +14,8 @@ import (
 	"time"
 
 	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/go-resty/resty/v2"
@@ -214,9 +216,95 @@ func (d *GoogleDrive) getFiles(id string) ([]File, error) {
 	return res, nil
 }
 
-func (d *GoogleDrive) chunkUpload(ctx context.Context, stream model.FileStreamer, url string) error {
+// createUploadSession returns the url of the resumable upload session
+func (d *GoogleDrive) createUploadSession(ctx context.Context, dstDir model.Obj, stream model.FileStreamer) (string, error) {
+	obj := stream.GetOld()
+	var (
+		e    Error
+		url  string
+		data base.Json
+		res  *resty.Response
+		err  error
+	)
+	if obj != nil {
+		url = fmt.Sprintf("https://www.googleapis.com/upload/drive/v3/files/%s?uploadType=resumable&supportsAllDrives=true", obj.GetID())
+		data = base.Json{}
+	} else {
+		data = base.Json{
+			"name":    stream.GetName(),
+			"parents": []string{dstDir.GetID()},
+		}
+		url = "https://www.googleapis.com/upload/drive/v3/files?uploadType=resumable&supportsAllDrives=true"
+	}
+	req := base.NoRedirectClient.R().
+		SetHeaders(map[string]string{
+			"Authorization":           "Bearer " + d.AccessToken,
+			"X-Upload-Content-Type":   stream.GetMimetype(),
+			"X-Upload-Content-Length": strconv.FormatInt(stream.GetSize(), 10),
+		}).
+		SetError(&e).SetBody(data).SetContext(ctx)
+	if obj != nil {
+		res, err = req.Patch(url)
+	} else {
+		res, err = req.Post(url)
+	}
+	if err != nil {
+		return "", err
+	}
+	if e.Error.Code != 0 {
+		if e.Error.Code == 401 {
+			err = d.refreshToken()
+			if err != nil {
+				return "", err
+			}
+			return d.createUploadSession(ctx, dstDir, stream)
+		}
+		return "", fmt.Errorf("%s: %v", e.Error.Message, e.Error.Errors)
+	}
+	return res.Header().Get("location"), nil
+}
+
+// uploadedBytes query the bytes received by the upload session
+func (d *GoogleDrive) uploadedBytes(ctx context.Context, url string, size int64) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+d.AccessToken)
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+	res, err := base.HttpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_ = res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return size, nil
+	case http.StatusPermanentRedirect:
+		// the range is missing if no bytes received
+		r := res.Header.Get("Range")
+		if r == "" {
+			return 0, nil
+		}
+		var end int64
+		if _, err := fmt.Sscanf(r, "bytes=0-%d", &end); err != nil {
+			return 0, fmt.Errorf("invalid range of upload session: %s", r)
+		}
+		return end + 1, nil
+	case http.StatusUnauthorized:
+		if err := d.refreshToken(); err != nil {
+			return 0, err
+		}
+		return d.uploadedBytes(ctx, url, size)
+	case http.StatusNotFound, http.StatusGone:
+		return 0, errs.UploadSessionExpired
+	}
+	return 0, fmt.Errorf("failed get upload session status: %s", res.Status)
+}
+
+func (d *GoogleDrive) chunkUpload(ctx context.Context, stream model.FileStreamer, url string, cp *model.UploadCheckpoint, up driver.UpdateProgress) error {
 	var defaultChunkSize = d.ChunkSize * 1024 * 1024
-	var finish int64 = 0
+	var finish = cp.Offset
 	for finish < stream.GetSize() {
 		if utils.IsCanceled(ctx) {
 			return ctx.Err()
@@ -229,12 +317,16 @@ func (d *GoogleDrive) chunkUpload(ctx context.Context, stream model.FileStreamer
 			req.SetHeaders(map[string]string{
 				"Content-Length": strconv.FormatInt(chunkSize, 10),
 				"Content-Range":  fmt.Sprintf("bytes %d-%d/%d", finish, finish+chunkSize-1, stream.GetSize()),
-			}).SetBody(io.LimitReader(stream.GetReadCloser(), chunkSize)).SetContext(ctx)
+			}).SetBody(io.LimitReader(stream, chunkSize)).SetContext(ctx)
 		}, nil)
 		if err != nil {
 			return err
 		}
 		finish += chunkSize
+		if err = cp.Commit(finish, uploadSession{Url: url}); err != nil {
+			return err
+		}
+		up(int(finish * 100 / stream.GetSize()))
 	}
 	return nil
 }
@@ -240,4 +240,94 @@ func (d *Local) Put(ctx context.Context, dstDir model.Obj, stream model.FileStre
 	return nil
 }
 
+// partSize is the bytes written to the partial file between the checkpoints
+const partSize = 8 * 1024 * 1024
+
+type partialSession struct {
+	Path string `json:"path"`
+}
+
+// PutResumable write to the partial file, which is renamed to the file when finished
+func (d *Local) PutResumable(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, cp *model.UploadCheckpoint, up driver.UpdateProgress) error {
+	fullPath := filepath.Join(dstDir.GetPath(), stream.GetName())
+	session := partialSession{Path: fullPath + ".alist_partial"}
+	var saved partialSession
+	ok, err := cp.GetSession(&saved)
+	if err != nil {
+		return err
+	}
+	var out *os.File
+	if ok && cp.Offset > 0 {
+		if saved.Path != session.Path {
+			return errs.UploadSessionExpired
+		}
+		out, err = os.OpenFile(session.Path, os.O_WRONLY, 0)
+		if errors.Is(err, os.ErrNotExist) {
+			return errs.UploadSessionExpired
+		}
+		if err != nil {
+			return err
+		}
+		// the bytes after the checkpoint may be incomplete
+		if stat, err := out.Stat(); err != nil || stat.Size() < cp.Offset {
+			_ = out.Close()
+			return errs.UploadSessionExpired
+		}
+		if err = out.Truncate(cp.Offset); err == nil {
+			_, err = out.Seek(cp.Offset, io.SeekStart)
+		}
+		if err != nil {
+			_ = out.Close()
+			return err
+		}
+	} else {
+		out, err = os.Create(session.Path)
+		if err != nil {
+			return err
+		}
+	}
+	written := cp.Offset
+	for written < stream.GetSize() {
+		if utils.IsCanceled(ctx) {
+			_ = out.Close()
+			return ctx.Err()
+		}
+		size := stream.GetSize() - written
+		if size > partSize {
+			size = partSize
+		}
+		n, err := io.CopyN(out, stream, size)
+		written += n
+		if err == nil {
+			err = out.Sync()
+		}
+		if err != nil {
+			_ = out.Close()
+			return err
+		}
+		if err = cp.Commit(written, session); err != nil {
+			_ = out.Close()
+			return err
+		}
+		up(int(written * 100 / stream.GetSize()))
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(session.Path, fullPath)
+}
+
+func (d *Local) AbortResumable(ctx context.Context, cp *model.UploadCheckpoint) error {
+	var session partialSession
+	if ok, err := cp.GetSession(&session); !ok || err != nil {
+		return err
+	}
+	err := os.Remove(session.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
 var _ driver.Driver = (*Local)(nil)
+var _ driver.ResumablePut = (*Local)(nil)
@@ -2,10 +2,7 @@ package mediatrack
 
 import (
 	"context"
-	"crypto/md5"
-	"encoding/hex"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"strconv"
@@ -200,16 +197,7 @@ func (d *MediaTrack) Put(ctx context.Context, dstDir model.Obj, stream model.Fil
 		return err
 	}
 	url := fmt.Sprintf("https://jayce.api.mediatrack.cn/v3/assets/%s/children", dstDir.GetID())
-	_, err = tempFile.Seek(0, io.SeekStart)
-	if err != nil {
-		return err
-	}
-	h := md5.New()
-	_, err = io.Copy(h, tempFile)
-	if err != nil {
-		return err
-	}
-	hash := hex.EncodeToString(h.Sum(nil))
+	hash := stream.GetHash(utils.MD5)
 	data := base.Json{
 		"category":    0,
 		"description": stream.GetName(),
@@ -3,6 +3,7 @@ package mediatrack
 import (
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
 )
 
 type Addition struct {
@@ -14,7 +15,8 @@ type Addition struct {
 }
 
 var config = driver.Config{
-	Name: "MediaTrack",
+	Name:    "MediaTrack",
+	PreHash: []string{utils.MD5},
 }
 
 func init() {
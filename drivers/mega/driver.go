@@ -9,6 +9,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/t3rm1n4l/go-mega"
@@ -76,39 +77,27 @@ func (d *Mega) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*
 		//u := down.GetResourceUrl()
 		//u = strings.Replace(u, "http", "https", 1)
 		//return &model.Link{URL: u}, nil
-		r, w := io.Pipe()
-		go func() {
-			defer func() {
-				_ = recover()
-			}()
-			log.Debugf("chunk size: %d", down.Chunks())
-			var (
-				chunk []byte
-				err   error
-			)
-			for id := 0; id < down.Chunks(); id++ {
-				chunk, err = down.DownloadChunk(id)
-				if err != nil {
-					log.Errorf("mega down: %+v", err)
-					break
+		return &model.Link{
+			RangeReader: model.RangeReaderFunc(func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+				end := file.GetSize()
+				if httpRange.Length >= 0 && httpRange.Start+httpRange.Length < end {
+					end = httpRange.Start + httpRange.Length
 				}
-				log.Debugf("id: %d,len: %d", id, len(chunk))
-				//_, _, err = down.ChunkLocation(id)
-				//if err != nil {
-				//	log.Errorf("mega down: %+v", err)
-				//	return
-				//}
-				//_, err = c.Write(chunk)
-				if _, err = w.Write(chunk); err != nil {
-					break
-				}
-			}
-			err = w.CloseWithError(err)
-			if err != nil {
-				log.Errorf("mega down: %+v", err)
-			}
-		}()
-		return &model.Link{Data: r}, nil
+				r, w := io.Pipe()
+				go func() {
+					defer func() {
+						_ = recover()
+					}()
+					log.Debugf("chunk size: %d", down.Chunks())
+					err := downRange(ctx, down, httpRange.Start, end, w)
+					if err != nil {
+						log.Errorf("mega down: %+v", err)
+					}
+					_ = w.CloseWithError(err)
+				}()
+				return r, nil
+			}),
+		}, nil
 	}
 	return nil, fmt.Errorf("unable to convert dir to mega node")
 }
@@ -190,3 +179,38 @@ func (d *Mega) Put(ctx context.Context, dstDir model.Obj, stream model.FileStrea
 //}
 
 var _ driver.Driver = (*Mega)(nil)
+
+// downRange write the bytes from start to end by the chunks that overlap the range
+func downRange(ctx context.Context, down *mega.Download, start, end int64, w io.Writer) error {
+	for id := 0; id < down.Chunks(); id++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		pos, size, err := down.ChunkLocation(id)
+		if err != nil {
+			return err
+		}
+		if pos+int64(size) <= start {
+			continue
+		}
+		if pos >= end {
+			break
+		}
+		chunk, err := down.DownloadChunk(id)
+		if err != nil {
+			return err
+		}
+		log.Debugf("id: %d,len: %d", id, len(chunk))
+		lo, hi := int64(0), int64(len(chunk))
+		if start > pos {
+			lo = start - pos
+		}
+		if end < pos+hi {
+			hi = end - pos
+		}
+		if _, err = w.Write(chunk[lo:hi]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -152,9 +152,33 @@ func (d *Onedrive) Put(ctx context.Context, dstDir model.Obj, stream model.FileS
 	if stream.GetSize() <= 4*1024*1024 {
 		err = d.upSmall(ctx, dstDir, stream)
 	} else {
-		err = d.upBig(ctx, dstDir, stream, up)
+		err = d.upBig(ctx, dstDir, stream, new(model.UploadCheckpoint), up)
 	}
 	return err
 }
 
+func (d *Onedrive) PutResumable(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, cp *model.UploadCheckpoint, up driver.UpdateProgress) error {
+	if stream.GetSize() <= 4*1024*1024 && cp.Offset == 0 {
+		return d.upSmall(ctx, dstDir, stream)
+	}
+	return d.upBig(ctx, dstDir, stream, cp, up)
+}
+
+func (d *Onedrive) AbortResumable(ctx context.Context, cp *model.UploadCheckpoint) error {
+	var session uploadSession
+	if ok, err := cp.GetSession(&session); !ok || err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, session.UploadUrl, nil)
+	if err != nil {
+		return err
+	}
+	res, err := base.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
 var _ driver.Driver = (*Onedrive)(nil)
+var _ driver.ResumablePut = (*Onedrive)(nil)
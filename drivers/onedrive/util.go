@@ -159,14 +159,30 @@ func (d *Onedrive) upSmall(ctx context.Context, dstDir model.Obj, stream model.F
 	return err
 }
 
-func (d *Onedrive) upBig(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
-	url := d.GetMetaUrl(false, stdpath.Join(dstDir.GetPath(), stream.GetName())) + "/createUploadSession"
-	res, err := d.Request(url, http.MethodPost, nil, nil)
+type uploadSession struct {
+	UploadUrl string `json:"upload_url"`
+}
+
+// upBig upload by the upload session, the session and the committed bytes are kept in cp
+func (d *Onedrive) upBig(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, cp *model.UploadCheckpoint, up driver.UpdateProgress) error {
+	var session uploadSession
+	ok, err := cp.GetSession(&session)
 	if err != nil {
 		return err
 	}
-	uploadUrl := jsoniter.Get(res, "uploadUrl").ToString()
-	var finish int64 = 0
+	if !ok {
+		url := d.GetMetaUrl(false, stdpath.Join(dstDir.GetPath(), stream.GetName())) + "/createUploadSession"
+		res, err := d.Request(url, http.MethodPost, nil, nil)
+		if err != nil {
+			return err
+		}
+		session.UploadUrl = jsoniter.Get(res, "uploadUrl").ToString()
+		if err = cp.Commit(0, session); err != nil {
+			return err
+		}
+	}
+	uploadUrl := session.UploadUrl
+	finish := cp.Offset
 	DEFAULT := d.ChunkSize * 1024 * 1024
 	for finish < stream.GetSize() {
 		if utils.IsCanceled(ctx) {
@@ -196,12 +212,19 @@ func (d *Onedrive) upBig(ctx context.Context, dstDir model.Obj, stream model.Fil
 		if err != nil {
 			return err
 		}
+		if res.StatusCode == http.StatusNotFound {
+			res.Body.Close()
+			return errs.UploadSessionExpired
+		}
 		if res.StatusCode != 201 && res.StatusCode != 202 {
 			data, _ := io.ReadAll(res.Body)
 			res.Body.Close()
 			return errors.New(string(data))
 		}
 		res.Body.Close()
+		if err = cp.Commit(finish, session); err != nil {
+			return err
+		}
 		up(int(finish * 100 / stream.GetSize()))
 	}
 	return nil
@@ -3,6 +3,7 @@ package pikpak
 import (
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
 )
 
 type Addition struct {
@@ -15,6 +16,7 @@ var config = driver.Config{
 	Name:        "PikPak",
 	LocalSort:   true,
 	DefaultRoot: "",
+	PreHash:     []string{utils.SHA1},
 }
 
 func init() {
@@ -2,9 +2,6 @@ package quark
 
 import (
 	"context"
-	"crypto/md5"
-	"crypto/sha1"
-	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -188,26 +185,8 @@ func (d *Quark) Put(ctx context.Context, dstDir model.Obj, stream model.FileStre
 		_ = tempFile.Close()
 		_ = os.Remove(tempFile.Name())
 	}()
-	m := md5.New()
-	_, err = io.Copy(m, tempFile)
-	if err != nil {
-		return err
-	}
-	_, err = tempFile.Seek(0, io.SeekStart)
-	if err != nil {
-		return err
-	}
-	md5Str := hex.EncodeToString(m.Sum(nil))
-	s := sha1.New()
-	_, err = io.Copy(s, tempFile)
-	if err != nil {
-		return err
-	}
-	_, err = tempFile.Seek(0, io.SeekStart)
-	if err != nil {
-		return err
-	}
-	sha1Str := hex.EncodeToString(s.Sum(nil))
+	md5Str := stream.GetHash(utils.MD5)
+	sha1Str := stream.GetHash(utils.SHA1)
 	// pre
 	pre, err := d.upPre(stream, dstDir.GetID())
 	if err != nil {
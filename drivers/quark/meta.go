@@ -3,6 +3,7 @@ package quark
 import (
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
 )
 
 type Addition struct {
@@ -17,6 +18,7 @@ var config = driver.Config{
 	OnlyLocal:         true,
 	DefaultRoot:       "0",
 	NoOverwriteUpload: true,
+	PreHash:           []string{utils.MD5, utils.SHA1},
 }
 
 func init() {
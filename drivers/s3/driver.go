@@ -11,7 +11,10 @@ import (
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
@@ -58,6 +61,26 @@ func (d *S3) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]mo
 	return d.listV1(dir.GetPath())
 }
 
+func (d *S3) ListPage(ctx context.Context, dir model.Obj, args model.ListPageArgs) (*model.ObjsPage, error) {
+	// the max keys of a page is 1000
+	maxKeys := int64(args.PerPage)
+	if maxKeys > 1000 {
+		maxKeys = 1000
+	}
+	var objs []model.Obj
+	var next string
+	var err error
+	if d.ListObjectVersion == "v2" {
+		objs, next, err = d.listPageV2(dir.GetPath(), args.Cursor, maxKeys)
+	} else {
+		objs, next, err = d.listPageV1(dir.GetPath(), args.Cursor, maxKeys)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model.ObjsPage{Objs: objs, NextCursor: next}, nil
+}
+
 func (d *S3) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
 	path := getKey(file.GetPath(), false)
 	filename := stdpath.Base(path)
@@ -146,4 +169,101 @@ func (d *S3) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreame
 	return err
 }
 
+type multipartSession struct {
+	Key      string              `json:"key"`
+	UploadId string              `json:"upload_id"`
+	PartSize int64               `json:"part_size"`
+	Parts    []*s3.CompletedPart `json:"parts"`
+}
+
+// PutResumable upload by the multipart upload, the uploaded parts are kept in the session
+func (d *S3) PutResumable(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, cp *model.UploadCheckpoint, up driver.UpdateProgress) error {
+	if stream.GetSize() <= s3manager.DefaultUploadPartSize && cp.Offset == 0 {
+		// a single part, nothing to resume
+		return d.Put(ctx, dstDir, stream, up)
+	}
+	key := getKey(stdpath.Join(dstDir.GetPath(), stream.GetName()), false)
+	var session multipartSession
+	ok, err := cp.GetSession(&session)
+	if err != nil {
+		return err
+	}
+	if ok && (session.Key != key || cp.Offset != int64(len(session.Parts))*session.PartSize) {
+		return errs.UploadSessionExpired
+	}
+	if !ok {
+		session = multipartSession{Key: key, PartSize: s3manager.DefaultUploadPartSize}
+		if stream.GetSize() > s3manager.MaxUploadParts*session.PartSize {
+			session.PartSize = stream.GetSize() / (s3manager.MaxUploadParts - 1)
+		}
+		contentType := stream.GetMimetype()
+		res, err := d.client.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
+			Bucket:      &d.Bucket,
+			Key:         &key,
+			ContentType: &contentType,
+		})
+		if err != nil {
+			return err
+		}
+		session.UploadId = *res.UploadId
+		if err = cp.Commit(0, session); err != nil {
+			return err
+		}
+	}
+	offset := cp.Offset
+	buf := make([]byte, session.PartSize)
+	for offset < stream.GetSize() {
+		if utils.IsCanceled(ctx) {
+			return ctx.Err()
+		}
+		size := stream.GetSize() - offset
+		if size > session.PartSize {
+			size = session.PartSize
+		}
+		n, err := io.ReadFull(stream, buf[:size])
+		if err != nil {
+			return err
+		}
+		partNumber := int64(len(session.Parts) + 1)
+		res, err := d.client.UploadPartWithContext(ctx, &s3.UploadPartInput{
+			Bucket:     &d.Bucket,
+			Key:        &key,
+			UploadId:   &session.UploadId,
+			PartNumber: &partNumber,
+			Body:       bytes.NewReader(buf[:n]),
+		})
+		if err != nil {
+			return multipartErr(err)
+		}
+		session.Parts = append(session.Parts, &s3.CompletedPart{ETag: res.ETag, PartNumber: aws.Int64(partNumber)})
+		offset += int64(n)
+		if err = cp.Commit(offset, session); err != nil {
+			return err
+		}
+		up(int(offset * 100 / stream.GetSize()))
+	}
+	_, err = d.client.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          &d.Bucket,
+		Key:             &key,
+		UploadId:        &session.UploadId,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: session.Parts},
+	})
+	return multipartErr(err)
+}
+
+func (d *S3) AbortResumable(ctx context.Context, cp *model.UploadCheckpoint) error {
+	var session multipartSession
+	if ok, err := cp.GetSession(&session); !ok || err != nil {
+		return err
+	}
+	_, err := d.client.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   &d.Bucket,
+		Key:      &session.Key,
+		UploadId: &session.UploadId,
+	})
+	return multipartErr(err)
+}
+
 var _ driver.Driver = (*S3)(nil)
+var _ driver.ResumablePut = (*S3)(nil)
+var _ driver.PagedLister = (*S3)(nil)
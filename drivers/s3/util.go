@@ -7,10 +7,12 @@ import (
 	"path"
 	"strings"
 
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -52,6 +54,15 @@ func (d *S3) getClient(link bool) *s3.S3 {
 	return client
 }
 
+// multipartErr returns errs.UploadSessionExpired if the multipart upload is not found
+func multipartErr(err error) error {
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchUpload {
+		return errs.UploadSessionExpired
+	}
+	return err
+}
+
 func getKey(path string, dir bool) string {
 	path = strings.TrimPrefix(path, "/")
 	if path != "" && dir {
@@ -70,112 +81,163 @@ func getPlaceholderName(placeholder string) string {
 }
 
 func (d *S3) listV1(prefix string) ([]model.Obj, error) {
-	prefix = getKey(prefix, true)
-	log.Debugf("list: %s", prefix)
 	files := make([]model.Obj, 0)
 	marker := ""
 	for {
-		input := &s3.ListObjectsInput{
-			Bucket:    &d.Bucket,
-			Marker:    &marker,
-			Prefix:    &prefix,
-			Delimiter: aws.String("/"),
-		}
-		listObjectsResult, err := d.client.ListObjects(input)
+		objs, next, err := d.listPageV1(prefix, marker, 0)
 		if err != nil {
 			return nil, err
 		}
-		for _, object := range listObjectsResult.CommonPrefixes {
-			name := path.Base(strings.Trim(*object.Prefix, "/"))
-			file := model.Object{
-				//Id:        *object.Key,
-				Name:     name,
-				Modified: d.Modified,
-				IsFolder: true,
-			}
-			files = append(files, &file)
+		files = append(files, objs...)
+		if next == "" {
+			break
 		}
-		for _, object := range listObjectsResult.Contents {
-			name := path.Base(*object.Key)
-			if name == getPlaceholderName(d.Placeholder) || name == d.Placeholder {
-				continue
-			}
-			file := model.Object{
-				//Id:        *object.Key,
-				Name:     name,
-				Size:     *object.Size,
-				Modified: *object.LastModified,
-			}
-			files = append(files, &file)
+		marker = next
+	}
+	return files, nil
+}
+
+// listPageV1 list a page from the marker, the next marker is empty if it's the last page
+func (d *S3) listPageV1(prefix, marker string, maxKeys int64) ([]model.Obj, string, error) {
+	prefix = getKey(prefix, true)
+	log.Debugf("list: %s", prefix)
+	files := make([]model.Obj, 0)
+	input := &s3.ListObjectsInput{
+		Bucket:    &d.Bucket,
+		Marker:    &marker,
+		Prefix:    &prefix,
+		Delimiter: aws.String("/"),
+	}
+	if maxKeys > 0 {
+		input.MaxKeys = &maxKeys
+	}
+	listObjectsResult, err := d.client.ListObjects(input)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, object := range listObjectsResult.CommonPrefixes {
+		name := path.Base(strings.Trim(*object.Prefix, "/"))
+		file := model.Object{
+			//Id:        *object.Key,
+			Name:     name,
+			Modified: d.Modified,
+			IsFolder: true,
 		}
-		if listObjectsResult.IsTruncated == nil {
-			return nil, errors.New("IsTruncated nil")
+		files = append(files, &file)
+	}
+	for _, object := range listObjectsResult.Contents {
+		name := path.Base(*object.Key)
+		if name == getPlaceholderName(d.Placeholder) || name == d.Placeholder {
+			continue
 		}
-		if *listObjectsResult.IsTruncated {
-			marker = *listObjectsResult.NextMarker
-		} else {
-			break
+		file := model.Object{
+			//Id:        *object.Key,
+			Name:     name,
+			Size:     *object.Size,
+			Modified: *object.LastModified,
 		}
+		files = append(files, &file)
 	}
-	return files, nil
+	if listObjectsResult.IsTruncated == nil {
+		return nil, "", errors.New("IsTruncated nil")
+	}
+	if !*listObjectsResult.IsTruncated {
+		return files, "", nil
+	}
+	if listObjectsResult.NextMarker != nil {
+		return files, *listObjectsResult.NextMarker, nil
+	}
+	// some compatible services don't return the next marker, then it's the last key
+	if n := len(listObjectsResult.Contents); n > 0 {
+		return files, *listObjectsResult.Contents[n-1].Key, nil
+	}
+	if n := len(listObjectsResult.CommonPrefixes); n > 0 {
+		return files, *listObjectsResult.CommonPrefixes[n-1].Prefix, nil
+	}
+	return files, "", nil
 }
 
 func (d *S3) listV2(prefix string) ([]model.Obj, error) {
-	prefix = getKey(prefix, true)
 	files := make([]model.Obj, 0)
-	var continuationToken, startAfter *string
+	cursor := ""
 	for {
-		input := &s3.ListObjectsV2Input{
-			Bucket:            &d.Bucket,
-			ContinuationToken: continuationToken,
-			Prefix:            &prefix,
-			Delimiter:         aws.String("/"),
-			StartAfter:        startAfter,
-		}
-		listObjectsResult, err := d.client.ListObjectsV2(input)
+		objs, next, err := d.listPageV2(prefix, cursor, 0)
 		if err != nil {
 			return nil, err
 		}
-		log.Debugf("resp: %+v", listObjectsResult)
-		for _, object := range listObjectsResult.CommonPrefixes {
-			name := path.Base(strings.Trim(*object.Prefix, "/"))
-			file := model.Object{
-				//Id:        *object.Key,
-				Name:     name,
-				Modified: d.Modified,
-				IsFolder: true,
-			}
-			files = append(files, &file)
+		files = append(files, objs...)
+		if next == "" {
+			break
 		}
-		for _, object := range listObjectsResult.Contents {
-			if strings.HasSuffix(*object.Key, "/") {
-				continue
-			}
-			name := path.Base(*object.Key)
-			if name == getPlaceholderName(d.Placeholder) || name == d.Placeholder {
-				continue
-			}
-			file := model.Object{
-				//Id:        *object.Key,
-				Name:     name,
-				Size:     *object.Size,
-				Modified: *object.LastModified,
-			}
-			files = append(files, &file)
+		cursor = next
+	}
+	return files, nil
+}
+
+const (
+	continuationCursor = "token:"
+	startAfterCursor   = "after:"
+)
+
+// listPageV2 list a page from the cursor, the cursor is either a continuation token or a start after key,
+// the next cursor is empty if it's the last page
+func (d *S3) listPageV2(prefix, cursor string, maxKeys int64) ([]model.Obj, string, error) {
+	prefix = getKey(prefix, true)
+	files := make([]model.Obj, 0)
+	input := &s3.ListObjectsV2Input{
+		Bucket:    &d.Bucket,
+		Prefix:    &prefix,
+		Delimiter: aws.String("/"),
+	}
+	if strings.HasPrefix(cursor, continuationCursor) {
+		input.ContinuationToken = aws.String(strings.TrimPrefix(cursor, continuationCursor))
+	} else if strings.HasPrefix(cursor, startAfterCursor) {
+		input.StartAfter = aws.String(strings.TrimPrefix(cursor, startAfterCursor))
+	}
+	if maxKeys > 0 {
+		input.MaxKeys = &maxKeys
+	}
+	listObjectsResult, err := d.client.ListObjectsV2(input)
+	if err != nil {
+		return nil, "", err
+	}
+	log.Debugf("resp: %+v", listObjectsResult)
+	for _, object := range listObjectsResult.CommonPrefixes {
+		name := path.Base(strings.Trim(*object.Prefix, "/"))
+		file := model.Object{
+			//Id:        *object.Key,
+			Name:     name,
+			Modified: d.Modified,
+			IsFolder: true,
 		}
-		if !aws.BoolValue(listObjectsResult.IsTruncated) {
-			break
+		files = append(files, &file)
+	}
+	for _, object := range listObjectsResult.Contents {
+		if strings.HasSuffix(*object.Key, "/") {
+			continue
 		}
-		if listObjectsResult.NextContinuationToken != nil {
-			continuationToken = listObjectsResult.NextContinuationToken
+		name := path.Base(*object.Key)
+		if name == getPlaceholderName(d.Placeholder) || name == d.Placeholder {
 			continue
 		}
-		if len(listObjectsResult.Contents) == 0 {
-			break
+		file := model.Object{
+			//Id:        *object.Key,
+			Name:     name,
+			Size:     *object.Size,
+			Modified: *object.LastModified,
 		}
-		startAfter = listObjectsResult.Contents[len(listObjectsResult.Contents)-1].Key
+		files = append(files, &file)
 	}
-	return files, nil
+	if !aws.BoolValue(listObjectsResult.IsTruncated) {
+		return files, "", nil
+	}
+	if listObjectsResult.NextContinuationToken != nil {
+		return files, continuationCursor + *listObjectsResult.NextContinuationToken, nil
+	}
+	if len(listObjectsResult.Contents) == 0 {
+		return files, "", nil
+	}
+	return files, startAfterCursor + *listObjectsResult.Contents[len(listObjectsResult.Contents)-1].Key, nil
 }
 
 func (d *S3) copy(ctx context.Context, src string, dst string, isDir bool) error {
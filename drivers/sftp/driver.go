@@ -2,6 +2,7 @@ package sftp
 
 import (
 	"context"
+	"io"
 	"os"
 	"path"
 
@@ -49,15 +50,11 @@ func (d *SFTP) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]
 }
 
 func (d *SFTP) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
-	remoteFile, err := d.client.Open(file.GetPath())
-	if err != nil {
-		return nil, err
-	}
-	link := &model.Link{
-		Data: remoteFile,
-	}
-	base.HandleRange(link, remoteFile, args.Header, file.GetSize())
-	return link, nil
+	return &model.Link{
+		RangeReader: base.NewSeekRangeReader(func(ctx context.Context) (io.ReadSeekCloser, error) {
+			return d.client.Open(file.GetPath())
+		}),
+	}, nil
 }
 
 func (d *SFTP) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
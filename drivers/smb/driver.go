@@ -3,6 +3,7 @@ package smb
 import (
 	"context"
 	"errors"
+	"io"
 	"path/filepath"
 	"strings"
 
@@ -74,17 +75,20 @@ func (d *SMB) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*m
 		return nil, err
 	}
 	fullPath := file.GetPath()
-	remoteFile, err := d.fs.Open(fullPath)
-	if err != nil {
-		d.cleanLastConnTime()
-		return nil, err
-	}
-	link := &model.Link{
-		Data: remoteFile,
-	}
-	base.HandleRange(link, remoteFile, args.Header, file.GetSize())
-	d.updateLastConnTime()
-	return link, nil
+	return &model.Link{
+		RangeReader: base.NewSeekRangeReader(func(ctx context.Context) (io.ReadSeekCloser, error) {
+			if err := d.checkConn(); err != nil {
+				return nil, err
+			}
+			remoteFile, err := d.fs.Open(fullPath)
+			if err != nil {
+				d.cleanLastConnTime()
+				return nil, err
+			}
+			d.updateLastConnTime()
+			return remoteFile, nil
+		}),
+	}, nil
 }
 
 func (d *SMB) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
@@ -14,8 +14,9 @@ type Addition struct {
 }
 
 var config = driver.Config{
-	Name:        "Terabox",
-	DefaultRoot: "/",
+	Name:               "Terabox",
+	DefaultRoot:        "/",
+	NeedSeekableStream: true,
 }
 
 func init() {
@@ -0,0 +1,180 @@
+package union
+
+import (
+	"context"
+	"errors"
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	mapset "github.com/deckarep/golang-set/v2"
+)
+
+type Union struct {
+	model.Storage
+	Addition
+	branches []string
+	rr       uint32
+}
+
+func (d *Union) Config() driver.Config {
+	return config
+}
+
+func (d *Union) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Union) Init(ctx context.Context) error {
+	d.branches = nil
+	for _, branch := range strings.Split(d.Branches, "\n") {
+		branch = strings.TrimSpace(branch)
+		if branch == "" {
+			continue
+		}
+		branch = utils.FixAndCleanPath(branch)
+		// the union would list itself through the branch containing it, e.g. /
+		if utils.IsSubPath(d.MountPath, branch) || utils.IsSubPath(branch, d.MountPath) {
+			return errors.New("branch can't be in or contain the union itself")
+		}
+		d.branches = append(d.branches, branch)
+	}
+	if len(d.branches) == 0 {
+		return errors.New("branches is required")
+	}
+	return nil
+}
+
+func (d *Union) Drop(ctx context.Context) error {
+	d.branches = nil
+	return nil
+}
+
+func (d *Union) Get(ctx context.Context, path string) (model.Obj, error) {
+	if utils.PathEqual(path, "/") {
+		return &model.Object{
+			Name:     "Root",
+			IsFolder: true,
+			Path:     "/",
+		}, nil
+	}
+	for _, branch := range d.branches {
+		obj, err := fs.Get(ctx, stdpath.Join(branch, path), &fs.GetArgs{NoLog: true})
+		if err == nil {
+			return &model.Object{
+				Path:     path,
+				Name:     obj.GetName(),
+				Size:     obj.GetSize(),
+				Modified: obj.ModTime(),
+				IsFolder: obj.IsDir(),
+			}, nil
+		}
+	}
+	return nil, errs.ObjectNotFound
+}
+
+func (d *Union) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	var objs []model.Obj
+	found := false
+	names := mapset.NewSet[string]()
+	for _, branch := range d.branches {
+		tmp, err := d.list(ctx, stdpath.Join(branch, dir.GetPath()))
+		if err != nil {
+			continue
+		}
+		found = true
+		// the former branch has the higher priority
+		for _, obj := range tmp {
+			if names.Add(obj.GetName()) {
+				objs = append(objs, obj)
+			}
+		}
+	}
+	if !found {
+		return nil, errs.ObjectNotFound
+	}
+	return objs, nil
+}
+
+func (d *Union) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	for _, branch := range d.holding(ctx, file.GetPath(), true) {
+		link, err := d.link(ctx, stdpath.Join(branch, file.GetPath()), args)
+		if err == nil {
+			return link, nil
+		}
+	}
+	return nil, errs.ObjectNotFound
+}
+
+func (d *Union) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	branch, err := d.createBranch(ctx, parentDir.GetPath())
+	if err != nil {
+		return err
+	}
+	return fs.MakeDir(ctx, stdpath.Join(branch, parentDir.GetPath(), dirName))
+}
+
+func (d *Union) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	return d.forExisting(ctx, srcObj.GetPath(), func(branch string) error {
+		dstDirPath := stdpath.Join(branch, dstDir.GetPath())
+		if err := fs.MakeDir(ctx, dstDirPath); err != nil {
+			return err
+		}
+		return fs.Move(ctx, stdpath.Join(branch, srcObj.GetPath()), dstDirPath)
+	})
+}
+
+func (d *Union) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	return d.forExisting(ctx, srcObj.GetPath(), func(branch string) error {
+		return fs.Rename(ctx, stdpath.Join(branch, srcObj.GetPath()), newName)
+	})
+}
+
+func (d *Union) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	branches := d.holding(ctx, srcObj.GetPath(), true)
+	if len(branches) == 0 {
+		return errs.ObjectNotFound
+	}
+	dstDirPath := stdpath.Join(branches[0], dstDir.GetPath())
+	if err := fs.MakeDir(ctx, dstDirPath); err != nil {
+		return err
+	}
+	_, err := fs.Copy(ctx, stdpath.Join(branches[0], srcObj.GetPath()), dstDirPath)
+	return err
+}
+
+func (d *Union) Remove(ctx context.Context, obj model.Obj) error {
+	return d.forExisting(ctx, obj.GetPath(), func(branch string) error {
+		return fs.Remove(ctx, stdpath.Join(branch, obj.GetPath()))
+	})
+}
+
+func (d *Union) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	// overwrite the existing file in place
+	var branch string
+	if branches := d.holding(ctx, stdpath.Join(dstDir.GetPath(), stream.GetName()), true); len(branches) > 0 {
+		branch = branches[0]
+	} else {
+		var err error
+		branch, err = d.createBranch(ctx, dstDir.GetPath())
+		if err != nil {
+			return err
+		}
+	}
+	storage, actualPath, err := op.GetStorageAndActualPath(stdpath.Join(branch, dstDir.GetPath()))
+	if err != nil {
+		return err
+	}
+	file, err := branchStream(stream)
+	if err != nil {
+		return err
+	}
+	return op.Put(ctx, storage, actualPath, file, up)
+}
+
+var _ driver.Driver = (*Union)(nil)
@@ -0,0 +1,167 @@
+package union
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+func createStorage(t *testing.T, storage model.Storage) error {
+	id, err := op.CreateStorage(context.Background(), storage)
+	if id != 0 {
+		t.Cleanup(func() {
+			_ = op.DeleteStorageById(context.Background(), id)
+		})
+	}
+	return err
+}
+
+// setup create the union of two local branches, the files are created in the branches by the paths
+func setup(t *testing.T, files map[string]string) (dirs []string) {
+	for i, mountPath := range []string{"/branch1", "/branch2"} {
+		dir := t.TempDir()
+		err := createStorage(t, model.Storage{
+			Driver:    "Local",
+			MountPath: mountPath,
+			Addition:  `{"root_folder_path":"` + filepath.ToSlash(dir) + `"}`,
+		})
+		if err != nil {
+			t.Fatalf("failed create branch: %+v", err)
+		}
+		for path, content := range files {
+			if path[0] != byte('1'+i) {
+				continue
+			}
+			path = filepath.Join(dir, path[1:])
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		dirs = append(dirs, dir)
+	}
+	err := createStorage(t, model.Storage{
+		Driver:    "Union",
+		MountPath: "/union",
+		Addition:  `{"branches":"/branch1\n/branch2","create_policy":"first_found","existing_path_policy":"all"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed create union: %+v", err)
+	}
+	return dirs
+}
+
+func TestInit(t *testing.T) {
+	for _, branches := range []string{"/", "/union", "/union/a", "\n"} {
+		d := &Union{Addition: Addition{Branches: branches}}
+		d.MountPath = "/union"
+		if err := d.Init(context.Background()); err == nil {
+			t.Errorf("expect the branches %q rejected", branches)
+		}
+	}
+	d := &Union{Addition: Addition{Branches: " /a \n\n/b/c/"}}
+	d.MountPath = "/union"
+	if err := d.Init(context.Background()); err != nil || !utils.SliceEqual(d.branches, []string{"/a", "/b/c"}) {
+		t.Errorf("expect the branches parsed, got %v %+v", d.branches, err)
+	}
+}
+
+func TestList(t *testing.T) {
+	setup(t, map[string]string{"1a.txt": "1", "1dir/b.txt": "1", "2a.txt": "22", "2c.txt": "2"})
+	objs, err := fs.List(context.Background(), "/union", &fs.ListArgs{NoLog: true})
+	if err != nil {
+		t.Fatalf("failed list: %+v", err)
+	}
+	var names []string
+	for _, obj := range objs {
+		names = append(names, obj.GetName())
+		// the former branch has the higher priority
+		if obj.GetName() == "a.txt" && obj.GetSize() != 1 {
+			t.Errorf("expect a.txt of the first branch, got size %d", obj.GetSize())
+		}
+	}
+	sort.Strings(names)
+	if !utils.SliceEqual(names, []string{"a.txt", "c.txt", "dir"}) {
+		t.Errorf("expect the objs merged, got %v", names)
+	}
+	if obj, err := fs.Get(context.Background(), "/union/c.txt", &fs.GetArgs{NoLog: true}); err != nil || obj.GetSize() != 1 {
+		t.Errorf("expect c.txt of the second branch, got %+v %v", obj, err)
+	}
+}
+
+func put(t *testing.T, path string, rc io.ReadCloser, size int64) {
+	t.Helper()
+	err := fs.PutDirectly(context.Background(), filepath.ToSlash(filepath.Dir(path)), &model.FileStream{
+		Obj:        &model.Object{Name: filepath.Base(path), Size: size, Modified: time.Now()},
+		ReadCloser: rc,
+	})
+	if err != nil {
+		t.Fatalf("failed put %s: %+v", path, err)
+	}
+}
+
+func TestPut(t *testing.T) {
+	dirs := setup(t, map[string]string{"2dir/a.txt": "old"})
+
+	// the existing file is overwritten in place
+	put(t, "/union/dir/a.txt", io.NopCloser(bytes.NewReader([]byte("new"))), 3)
+	if data, err := os.ReadFile(filepath.Join(dirs[1], "dir", "a.txt")); err != nil || string(data) != "new" {
+		t.Errorf("expect the file overwritten in the second branch, got %q %v", data, err)
+	}
+	if utils.Exists(filepath.Join(dirs[0], "dir", "a.txt")) {
+		t.Errorf("expect the file not created in the first branch")
+	}
+
+	// the new file is created in the first branch, from the position of the file stream
+	f, err := os.CreateTemp(t.TempDir(), "file-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("skip-content")
+	_, _ = f.Seek(5, io.SeekStart)
+	put(t, "/union/dir/b.txt", f, 7)
+	if data, err := os.ReadFile(filepath.Join(dirs[0], "dir", "b.txt")); err != nil || string(data) != "content" {
+		t.Errorf("expect the file uploaded from the position, got %q %v", data, err)
+	}
+	if utils.Exists(f.Name()) {
+		t.Errorf("expect the temp file removed after the upload")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	dirs := setup(t, map[string]string{"1a.txt": "1", "2a.txt": "2"})
+	if err := fs.Remove(context.Background(), "/union/a.txt"); err != nil {
+		t.Fatalf("failed remove: %+v", err)
+	}
+	for _, dir := range dirs {
+		if utils.Exists(filepath.Join(dir, "a.txt")) {
+			t.Errorf("expect the file removed from all the branches")
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package union
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+type Addition struct {
+	// the first branch has the highest priority when reading
+	Branches           string `json:"branches" required:"true" type:"text" help:"mount paths of the branches, one path per line"`
+	CreatePolicy       string `json:"create_policy" type:"select" options:"first_found,most_free_space,least_used,round_robin" default:"first_found" help:"which branch new files and folders are created on"`
+	PathPreserving     bool   `json:"path_preserving" default:"true" help:"only create on the branches where the parent folder already exists"`
+	ExistingPathPolicy string `json:"existing_path_policy" type:"select" options:"all,first_found" default:"all" help:"which branches rename, move and remove act on"`
+}
+
+var config = driver.Config{
+	Name:        "Union",
+	LocalSort:   true,
+	NoCache:     true,
+	DefaultRoot: "/",
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Union{}
+	})
+}
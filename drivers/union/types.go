@@ -0,0 +1,10 @@
+package union
+
+const (
+	FirstFound    = "first_found"
+	MostFreeSpace = "most_free_space"
+	LeastUsed     = "least_used"
+	RoundRobin    = "round_robin"
+
+	AllBranches = "all"
+)
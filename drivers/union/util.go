@@ -0,0 +1,183 @@
+package union
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	stdpath "path"
+	"sync/atomic"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// do others that not defined in Driver interface
+
+func (d *Union) list(ctx context.Context, reqPath string) ([]model.Obj, error) {
+	objs, err := fs.List(ctx, reqPath, &fs.ListArgs{NoLog: true})
+	if err != nil {
+		return nil, err
+	}
+	return utils.SliceConvert(objs, func(obj model.Obj) (model.Obj, error) {
+		thumb, ok := model.GetThumb(obj)
+		objRes := model.Object{
+			Name:     obj.GetName(),
+			Size:     obj.GetSize(),
+			Modified: obj.ModTime(),
+			IsFolder: obj.IsDir(),
+		}
+		if !ok {
+			return &objRes, nil
+		}
+		return &model.ObjThumb{
+			Object: objRes,
+			Thumbnail: model.Thumbnail{
+				Thumbnail: thumb,
+			},
+		}, nil
+	})
+}
+
+func (d *Union) link(ctx context.Context, reqPath string, args model.LinkArgs) (*model.Link, error) {
+	storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
+	if err != nil {
+		return nil, err
+	}
+	if common.ShouldProxy(storage, stdpath.Base(reqPath)) {
+		return &model.Link{
+			URL: fmt.Sprintf("%s/p%s?sign=%s",
+				common.GetApiUrl(args.HttpReq),
+				utils.EncodePath(reqPath, true),
+				sign.Sign(reqPath)),
+		}, nil
+	}
+	link, _, err := fs.Link(ctx, reqPath, args)
+	return link, err
+}
+
+// holding get the branches where the path exists
+func (d *Union) holding(ctx context.Context, path string, first bool) []string {
+	var branches []string
+	for _, branch := range d.branches {
+		if _, err := fs.Get(ctx, stdpath.Join(branch, path), &fs.GetArgs{NoLog: true}); err == nil {
+			branches = append(branches, branch)
+			if first {
+				break
+			}
+		}
+	}
+	return branches
+}
+
+// forExisting call f on the branches chosen by the existing path policy
+func (d *Union) forExisting(ctx context.Context, path string, f func(branch string) error) error {
+	branches := d.holding(ctx, path, d.ExistingPathPolicy != AllBranches)
+	if len(branches) == 0 {
+		return errs.ObjectNotFound
+	}
+	var errors []error
+	for _, branch := range branches {
+		if err := f(branch); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	return utils.MergeErrors(errors...)
+}
+
+// createBranch choose the branch to create new obj in dirPath by the create policy
+func (d *Union) createBranch(ctx context.Context, dirPath string) (string, error) {
+	candidates := d.branches
+	if d.PathPreserving {
+		candidates = d.holding(ctx, dirPath, false)
+		if len(candidates) == 0 {
+			return "", errs.ObjectNotFound
+		}
+	}
+	switch d.CreatePolicy {
+	case RoundRobin:
+		i := atomic.AddUint32(&d.rr, 1)
+		return candidates[int(i)%len(candidates)], nil
+	case MostFreeSpace, LeastUsed:
+		best, bestVal := "", int64(0)
+		for _, branch := range candidates {
+			used, total, ok := d.space(ctx, branch)
+			if !ok {
+				continue
+			}
+			val := total - used
+			if d.CreatePolicy == LeastUsed {
+				val = -used
+			}
+			if best == "" || val > bestVal {
+				best, bestVal = branch, val
+			}
+		}
+		if best != "" {
+			return best, nil
+		}
+		// no branch report the space, fallback to first found
+		return candidates[0], nil
+	default:
+		return candidates[0], nil
+	}
+}
+
+func (d *Union) space(ctx context.Context, branch string) (used, total int64, ok bool) {
+	storage, err := fs.GetStorage(branch, &fs.GetStoragesArgs{})
+	if err != nil {
+		return 0, 0, false
+	}
+	s, ok := storage.(driver.SpaceGetter)
+	if !ok {
+		return 0, 0, false
+	}
+	used, total, err = s.GetSpace(ctx)
+	if err != nil {
+		log.Warnf("failed get space of [%s]: %+v", branch, err)
+		return 0, 0, false
+	}
+	return used, total, true
+}
+
+// branchStream returns a fresh stream of the content for the branch, since the branch closes its stream
+// after the upload, and removes it if it's a file. The stream of the union is still left to its caller
+func branchStream(stream model.FileStreamer) (*model.FileStream, error) {
+	file := &model.FileStream{
+		Obj:      stream,
+		Mimetype: stream.GetMimetype(),
+		Old:      stream.GetOld(),
+		KeepFile: true,
+	}
+	rc := stream.GetReadCloser()
+	if s, ok := stream.(*model.FileStream); ok {
+		// the bytes are counted by the stream of the branch
+		file.CountRead, file.Hashes, rc = s.CountRead, s.Hashes, s.ReadCloser
+	}
+	f, ok := rc.(*os.File)
+	if !ok {
+		file.ReadCloser = io.NopCloser(rc)
+		return file, nil
+	}
+	// open the file again, so it's still seekable and closed by the branch on its own
+	pos, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	branchFile, err := os.Open(f.Name())
+	if err == nil {
+		_, err = branchFile.Seek(pos, io.SeekStart)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	file.ReadCloser = branchFile
+	return file, nil
+}
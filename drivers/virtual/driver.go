@@ -7,6 +7,7 @@ import (
 
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
 	"github.com/alist-org/alist/v3/pkg/utils/random"
 )
 
@@ -54,7 +55,13 @@ func (d *Virtual) List(ctx context.Context, dir model.Obj, args model.ListArgs)
 
 func (d *Virtual) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
 	return &model.Link{
-		Data: io.NopCloser(io.LimitReader(random.Rand, file.GetSize())),
+		RangeReader: model.RangeReaderFunc(func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+			length := httpRange.Length
+			if length < 0 {
+				length = file.GetSize() - httpRange.Start
+			}
+			return io.NopCloser(io.LimitReader(random.Rand, length)), nil
+		}),
 	}, nil
 }
 
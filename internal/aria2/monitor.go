@@ -98,6 +98,8 @@ func (m *Monitor) Update() (bool, error) {
 	if err != nil {
 		downloaded = 0
 	}
+	m.tsk.SetTotalBytes(int64(total))
+	m.tsk.SetDoneBytes(int64(downloaded))
 	progress := float64(downloaded) / float64(total) * 100
 	m.tsk.SetProgress(int(progress))
 	switch info.Status {
@@ -171,7 +173,9 @@ func (m *Monitor) Complete() error {
 					},
 					ReadCloser: f,
 					Mimetype:   mimetype,
+					CountRead:  tsk.AddDoneBytes,
 				}
+				tsk.SetTotalBytes(size)
 				relDir, err := filepath.Rel(m.tempDir, filepath.Dir(file.Path))
 				if err != nil {
 					log.Errorf("find relation directory error: %v", err)
@@ -0,0 +1,366 @@
+// Package blockcache cache the content of the files proxied by the server
+// on local disk by fixed size blocks, evicting the least recently used
+// blocks when the size limit is exceeded
+package blockcache
+
+import (
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	BlockSize = 4 * 1024 * 1024
+	metaName  = "meta.json"
+	// tmpName is the dir of the blocks being written and the evicted files being deleted
+	tmpName = ".tmp"
+)
+
+// Meta identify the cached file, the blocks of the old version
+// are never hit after the file is modified and will be evicted finally
+type Meta struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Modified int64  `json:"modified"`
+}
+
+func (m Meta) key() string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s\n%d\n%d", m.Path, m.Size, m.Modified)))
+	return hex.EncodeToString(sum[:])
+}
+
+// blockCount returns the count of blocks of the file
+func (m Meta) blockCount() int64 {
+	return (m.Size + BlockSize - 1) / BlockSize
+}
+
+// blockSize returns the size of the i-th block, the last one may be smaller
+func (m Meta) blockSize(i int64) int64 {
+	if i == m.blockCount()-1 {
+		return m.Size - i*BlockSize
+	}
+	return BlockSize
+}
+
+type file struct {
+	Meta
+	dir    string
+	blocks map[int64]*list.Element
+}
+
+type block struct {
+	file  *file
+	index int64
+	size  int64
+}
+
+func (b *block) path() string {
+	return filepath.Join(b.file.dir, strconv.FormatInt(b.index, 10))
+}
+
+type Stats struct {
+	Files     int   `json:"files"`
+	Blocks    int   `json:"blocks"`
+	Size      int64 `json:"size"`
+	Limit     int64 `json:"limit"`
+	HitBytes  int64 `json:"hit_bytes"`
+	MissBytes int64 `json:"miss_bytes"`
+}
+
+var (
+	mu    sync.Mutex
+	root  string
+	files = make(map[string]*file)
+	// the front is the most recently used
+	lru  = list.New()
+	size int64
+
+	limit     int64
+	hitBytes  int64
+	missBytes int64
+	// trashSeq names the evicted files in the temp dir, guarded by mu
+	trashSeq int64
+)
+
+func init() {
+	op.RegisterSettingItemHook(conf.BlockCacheSize, func(item *model.SettingItem) error {
+		mb, err := strconv.ParseInt(item.Value, 10, 64)
+		if err != nil {
+			return errors.Wrapf(err, "invalid %s", conf.BlockCacheSize)
+		}
+		SetLimit(mb * 1024 * 1024)
+		return nil
+	})
+}
+
+// Init load the blocks already cached in dir
+func Init(dir string) error {
+	mu.Lock()
+	var trash []string
+	defer func() {
+		mu.Unlock()
+		deleteTrash(trash)
+	}()
+	root = dir
+	files = make(map[string]*file)
+	lru.Init()
+	size = 0
+	if err := os.MkdirAll(root, 0777); err != nil {
+		return errors.WithStack(err)
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	type loaded struct {
+		b       *block
+		modTime time.Time
+	}
+	var all []loaded
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, entry.Name())
+		if entry.Name() == tmpName {
+			// left by the last run
+			_ = os.RemoveAll(dir)
+			continue
+		}
+		f := &file{dir: dir, blocks: make(map[int64]*list.Element)}
+		data, err := os.ReadFile(filepath.Join(dir, metaName))
+		if err == nil {
+			err = utils.Json.Unmarshal(data, &f.Meta)
+		}
+		if err != nil || f.key() != entry.Name() {
+			log.Warnf("remove invalid block cache dir [%s]", dir)
+			_ = os.RemoveAll(dir)
+			continue
+		}
+		blocks, _ := os.ReadDir(dir)
+		for _, b := range blocks {
+			index, err := strconv.ParseInt(b.Name(), 10, 64)
+			if err != nil {
+				continue
+			}
+			info, err := b.Info()
+			if err != nil || index >= f.blockCount() || info.Size() != f.blockSize(index) {
+				_ = os.Remove(filepath.Join(dir, b.Name()))
+				continue
+			}
+			all = append(all, loaded{b: &block{file: f, index: index, size: info.Size()}, modTime: info.ModTime()})
+		}
+		files[entry.Name()] = f
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].modTime.Before(all[j].modTime)
+	})
+	for _, l := range all {
+		l.b.file.blocks[l.b.index] = lru.PushFront(l.b)
+		size += l.b.size
+	}
+	for key, f := range files {
+		if len(f.blocks) == 0 {
+			_ = os.RemoveAll(f.dir)
+			delete(files, key)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, tmpName), 0777); err != nil {
+		return errors.WithStack(err)
+	}
+	trash = evict()
+	return nil
+}
+
+// SetLimit set the max size in bytes of the cache, 0 means disabled
+func SetLimit(bytes int64) {
+	atomic.StoreInt64(&limit, bytes)
+	mu.Lock()
+	trash := evict()
+	mu.Unlock()
+	deleteTrash(trash)
+}
+
+func Enabled() bool {
+	return atomic.LoadInt64(&limit) > 0 && root != ""
+}
+
+// getBlock returns the local path of the block if it's cached
+func getBlock(m Meta, index int64) (string, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	f, ok := files[m.key()]
+	if !ok {
+		return "", false
+	}
+	e, ok := f.blocks[index]
+	if !ok {
+		return "", false
+	}
+	lru.MoveToFront(e)
+	return e.Value.(*block).path(), true
+}
+
+// putBlock save the block to the cache
+func putBlock(m Meta, index int64, data []byte) error {
+	mu.Lock()
+	dir := root
+	mu.Unlock()
+	if atomic.LoadInt64(&limit) <= 0 || dir == "" {
+		return nil
+	}
+	// write to a temp file out of the lock first, so that a partial block is never read
+	tmp, err := os.CreateTemp(filepath.Join(dir, tmpName), "*.block")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// the temp file is left only if it's not moved into the cache
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	trash, err := addBlock(m, index, tmp.Name(), int64(len(data)))
+	deleteTrash(trash)
+	return err
+}
+
+// addBlock move the written temp file into the cache and insert it to the lru,
+// it returns the evicted files to delete after releasing the lock
+func addBlock(m Meta, index int64, tmp string, n int64) ([]string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if atomic.LoadInt64(&limit) <= 0 || root == "" {
+		return nil, nil
+	}
+	key := m.key()
+	f, ok := files[key]
+	if !ok {
+		f = &file{Meta: m, dir: filepath.Join(root, key), blocks: make(map[int64]*list.Element)}
+		if err := os.MkdirAll(f.dir, 0777); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		metaData, err := utils.Json.Marshal(m)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if err := os.WriteFile(filepath.Join(f.dir, metaName), metaData, 0666); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		files[key] = f
+	}
+	if e, ok := f.blocks[index]; ok {
+		// cached by another reader meanwhile
+		lru.MoveToFront(e)
+		return nil, nil
+	}
+	b := &block{file: f, index: index, size: n}
+	if err := os.Rename(tmp, b.path()); err != nil {
+		if len(f.blocks) == 0 {
+			delete(files, key)
+			return moveToTrash(f.dir, nil), errors.WithStack(err)
+		}
+		return nil, errors.WithStack(err)
+	}
+	f.blocks[index] = lru.PushFront(b)
+	size += b.size
+	return evict(), nil
+}
+
+// evict remove the least recently used blocks until the size is under the limit,
+// the caller must hold the lock and delete the returned files after releasing it
+func evict() []string {
+	var trash []string
+	l := atomic.LoadInt64(&limit)
+	for size > l && lru.Len() > 0 {
+		trash = removeBlock(lru.Back(), trash)
+	}
+	return trash
+}
+
+// removeBlock remove the block from the lru and append its files to trash,
+// the caller must hold the lock
+func removeBlock(e *list.Element, trash []string) []string {
+	b := lru.Remove(e).(*block)
+	size -= b.size
+	delete(b.file.blocks, b.index)
+	trash = moveToTrash(b.path(), trash)
+	if len(b.file.blocks) == 0 {
+		trash = moveToTrash(b.file.dir, trash)
+		delete(files, b.file.key())
+	}
+	return trash
+}
+
+// moveToTrash rename the path into the temp dir under the lock, so that deleting it
+// later never removes a block cached again at the same path meanwhile
+func moveToTrash(path string, trash []string) []string {
+	trashSeq++
+	dst := filepath.Join(root, tmpName, strconv.FormatInt(trashSeq, 10)+".trash")
+	if err := os.Rename(path, dst); err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("failed remove cached block: %+v", err)
+		}
+		return trash
+	}
+	return append(trash, dst)
+}
+
+func deleteTrash(trash []string) {
+	// readers that opened the block still can read it on unix
+	for _, path := range trash {
+		if err := os.RemoveAll(path); err != nil {
+			log.Warnf("failed remove cached block: %+v", err)
+		}
+	}
+}
+
+// Purge remove the cached files in the path, empty path means all
+func Purge(path string) int {
+	mu.Lock()
+	var trash []string
+	count := 0
+	for _, f := range files {
+		if path != "" && !utils.IsSubPath(path, f.Path) {
+			continue
+		}
+		count++
+		for _, e := range f.blocks {
+			trash = removeBlock(e, trash)
+		}
+	}
+	mu.Unlock()
+	deleteTrash(trash)
+	return count
+}
+
+func GetStats() Stats {
+	mu.Lock()
+	defer mu.Unlock()
+	return Stats{
+		Files:     len(files),
+		Blocks:    lru.Len(),
+		Size:      size,
+		Limit:     atomic.LoadInt64(&limit),
+		HitBytes:  atomic.LoadInt64(&hitBytes),
+		MissBytes: atomic.LoadInt64(&missBytes),
+	}
+}
@@ -0,0 +1,137 @@
+package blockcache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func newUpstream(t *testing.T, content []byte) (*model.Link, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(server.Close)
+	return &model.Link{URL: server.URL}, &requests
+}
+
+func readRange(t *testing.T, meta Meta, link *model.Link, offset, length int64) []byte {
+	r := NewReader(context.Background(), meta, func(ctx context.Context, offset int64) (io.ReadCloser, error) {
+		return fetch(ctx, link, offset)
+	})
+	defer r.Close()
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, length))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestReader(t *testing.T) {
+	if err := Init(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	SetLimit(100 * BlockSize)
+	content := make([]byte, 3*BlockSize+100)
+	rand.Read(content)
+	link, requests := newUpstream(t, content)
+	meta := Meta{Path: "/test/file", Size: int64(len(content)), Modified: 1}
+
+	if !bytes.Equal(readRange(t, meta, link, 0, meta.Size), content) {
+		t.Fatal("content mismatch on the first read")
+	}
+	if *requests != 1 || GetStats().Blocks != 4 {
+		t.Fatalf("expect 1 request and 4 blocks, got %d and %d", *requests, GetStats().Blocks)
+	}
+	if !bytes.Equal(readRange(t, meta, link, BlockSize-10, BlockSize+20), content[BlockSize-10:2*BlockSize+10]) {
+		t.Fatal("content mismatch on the cached read")
+	}
+	if *requests != 1 {
+		t.Fatalf("the cached read should not request the upstream, got %d requests", *requests)
+	}
+
+	// a partial hit only fetches the missing blocks
+	mu.Lock()
+	f := files[meta.key()]
+	trash := removeBlock(f.blocks[2], nil)
+	mu.Unlock()
+	deleteTrash(trash)
+	if !bytes.Equal(readRange(t, meta, link, 0, meta.Size), content) {
+		t.Fatal("content mismatch on the partial hit")
+	}
+	if *requests != 2 || GetStats().Blocks != 4 {
+		t.Fatalf("expect 2 requests and 4 blocks, got %d and %d", *requests, GetStats().Blocks)
+	}
+
+	// the blocks survive the restart
+	if err := Init(root); err != nil {
+		t.Fatal(err)
+	}
+	if GetStats().Blocks != 4 {
+		t.Fatalf("expect 4 blocks after reload, got %d", GetStats().Blocks)
+	}
+	if n := Purge("/test"); n != 1 || GetStats().Size != 0 {
+		t.Fatalf("expect 1 file purged and nothing left, got %d and %d", n, GetStats().Size)
+	}
+}
+
+func TestEvict(t *testing.T) {
+	if err := Init(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	SetLimit(2 * BlockSize)
+	content := make([]byte, BlockSize)
+	link, _ := newUpstream(t, content)
+	for i := 0; i < 3; i++ {
+		meta := Meta{Path: fmt.Sprintf("/file%d", i), Size: BlockSize}
+		readRange(t, meta, link, 0, BlockSize)
+		if i == 1 {
+			// touch the first file, so the second one is the least recently used
+			readRange(t, Meta{Path: "/file0", Size: BlockSize}, link, 0, BlockSize)
+		}
+	}
+	stats := GetStats()
+	if stats.Files != 2 || stats.Size != 2*BlockSize {
+		t.Fatalf("expect 2 files cached, got %d files of %d bytes", stats.Files, stats.Size)
+	}
+	if _, ok := getBlock(Meta{Path: "/file1", Size: BlockSize}, 0); ok {
+		t.Fatal("the least recently used file should be evicted")
+	}
+	if entries, _ := os.ReadDir(filepath.Join(root, tmpName)); len(entries) != 0 {
+		t.Fatalf("the evicted files should be deleted, got %d left", len(entries))
+	}
+}
+
+func TestFetch_ContentRange(t *testing.T) {
+	content := make([]byte, 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// ignore the range start
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-99/%d", len(content)))
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+	if _, err := fetch(context.Background(), &model.Link{URL: server.URL}, 10); err == nil {
+		t.Fatal("expect error for the content range not starting at the offset")
+	}
+	rc, err := fetch(context.Background(), &model.Link{URL: server.URL}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rc.Close()
+}
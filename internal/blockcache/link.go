@@ -0,0 +1,81 @@
+package blockcache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/pkg/errors"
+)
+
+var (
+	once       sync.Once
+	httpClient *http.Client
+)
+
+func client() *http.Client {
+	once.Do(func() {
+		httpClient = base.NewHttpClient()
+	})
+	return httpClient
+}
+
+// Wrap return a link that serve the content through the cache if the storage enable it,
+// only the links of url are cached, the local files and streams are returned as is
+func Wrap(storage driver.Driver, path string, link *model.Link, file model.Obj) *model.Link {
+	if !storage.GetStorage().BlockCache || !Enabled() || file.GetSize() <= 0 {
+		return link
+	}
+	if link.URL == "" || link.Data != nil || link.FilePath != nil || link.Handle != nil {
+		return link
+	}
+	meta := Meta{Path: path, Size: file.GetSize(), Modified: file.ModTime().Unix()}
+	return &model.Link{
+		Handle: func(w http.ResponseWriter, r *http.Request) error {
+			reader := NewReader(r.Context(), meta, func(ctx context.Context, offset int64) (io.ReadCloser, error) {
+				return fetch(ctx, link, offset)
+			})
+			defer reader.Close()
+			filename := file.GetName()
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, filename, url.PathEscape(filename)))
+			http.ServeContent(w, r, filename, file.ModTime(), reader)
+			return nil
+		},
+	}
+}
+
+func fetch(ctx context.Context, link *model.Link, offset int64) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.URL, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for h, val := range link.Header {
+		req.Header[h] = val
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	res, err := client().Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	switch {
+	case res.StatusCode == http.StatusPartialContent:
+		// the blocks are cached by the offset, so the content must start at it
+		ra, _, err := http_range.ParseContentRange(res.Header.Get("Content-Range"))
+		if err != nil || ra.Start != offset {
+			_ = res.Body.Close()
+			return nil, errors.Errorf("upstream respond content range [%s] for range from %d", res.Header.Get("Content-Range"), offset)
+		}
+	case res.StatusCode == http.StatusOK && offset == 0:
+	default:
+		_ = res.Body.Close()
+		return nil, errors.Errorf("upstream respond status %d for range from %d", res.StatusCode, offset)
+	}
+	return res.Body, nil
+}
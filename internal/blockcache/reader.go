@@ -0,0 +1,130 @@
+package blockcache
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// FetchFunc open the upstream content from offset to the end
+type FetchFunc func(ctx context.Context, offset int64) (io.ReadCloser, error)
+
+// Reader read the file through the cache, the cached blocks are read from local disk
+// and the missing ones are fetched from the upstream and saved while reading
+type Reader struct {
+	ctx    context.Context
+	meta   Meta
+	fetch  FetchFunc
+	offset int64
+
+	// the block in memory
+	buf      []byte
+	bufIndex int64
+
+	// the upstream is kept open while reading the continuous missing blocks
+	upstream       io.ReadCloser
+	upstreamOffset int64
+}
+
+func NewReader(ctx context.Context, meta Meta, fetch FetchFunc) *Reader {
+	return &Reader{
+		ctx:      ctx,
+		meta:     meta,
+		fetch:    fetch,
+		bufIndex: -1,
+	}
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.offset >= r.meta.Size {
+		return 0, io.EOF
+	}
+	index := r.offset / BlockSize
+	if index != r.bufIndex {
+		if err := r.load(index); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf[r.offset-index*BlockSize:])
+	r.offset += int64(n)
+	return n, nil
+}
+
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.meta.Size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+func (r *Reader) Close() error {
+	if r.upstream != nil {
+		err := r.upstream.Close()
+		r.upstream = nil
+		return err
+	}
+	return nil
+}
+
+// load read the block into the buffer from the cache or the upstream
+func (r *Reader) load(index int64) error {
+	size := r.meta.blockSize(index)
+	if cap(r.buf) < BlockSize {
+		r.buf = make([]byte, BlockSize)
+	}
+	r.buf = r.buf[:size]
+	r.bufIndex = -1
+	if path, ok := getBlock(r.meta, index); ok {
+		if err := readFull(path, r.buf); err == nil {
+			atomic.AddInt64(&hitBytes, size)
+			r.bufIndex = index
+			return nil
+		}
+		// evicted meanwhile, fetch it again
+	}
+	start := index * BlockSize
+	if r.upstream == nil || r.upstreamOffset != start {
+		_ = r.Close()
+		upstream, err := r.fetch(r.ctx, start)
+		if err != nil {
+			return err
+		}
+		r.upstream, r.upstreamOffset = upstream, start
+	}
+	n, err := io.ReadFull(r.upstream, r.buf)
+	r.upstreamOffset += int64(n)
+	if err != nil {
+		_ = r.Close()
+		return errors.WithStack(err)
+	}
+	atomic.AddInt64(&missBytes, size)
+	r.bufIndex = index
+	if err := putBlock(r.meta, index, r.buf); err != nil {
+		log.Warnf("failed cache block %d of [%s]: %+v", index, r.meta.Path, err)
+	}
+	return nil
+}
+
+func readFull(path string, buf []byte) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.ReadFull(f, buf)
+	return err
+}
@@ -0,0 +1,13 @@
+package bootstrap
+
+import (
+	"github.com/alist-org/alist/v3/internal/blockcache"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+func InitBlockCache() {
+	if err := blockcache.Init(conf.Conf.BlockCacheDir); err != nil {
+		utils.Log.Errorf("failed init block cache: %+v", err)
+	}
+}
@@ -3,6 +3,7 @@ package data
 import "github.com/alist-org/alist/v3/cmd/flags"
 
 func InitData() {
+	hashPlainPasswords()
 	initUser()
 	initSettings()
 	if flags.Dev {
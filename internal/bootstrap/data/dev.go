@@ -4,7 +4,6 @@ import (
 	"context"
 
 	"github.com/alist-org/alist/v3/cmd/flags"
-	"github.com/alist-org/alist/v3/internal/db"
 	"github.com/alist-org/alist/v3/internal/message"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
@@ -22,7 +21,7 @@ func initDevData() {
 	if err != nil {
 		log.Fatalf("failed to create storage: %+v", err)
 	}
-	err = db.CreateUser(&model.User{
+	err = op.CreateUser(&model.User{
 		Username:   "Noah",
 		Password:   "hsu",
 		BasePath:   "/data",
@@ -0,0 +1,47 @@
+package data
+
+import (
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// hashPlainPasswords hash the plaintext passwords of users and metas stored by the old versions
+func hashPlainPasswords() {
+	passwords, err := db.GetPlainPasswords()
+	if err != nil {
+		utils.Log.Fatalf("failed get plain passwords: %+v", err)
+	}
+	for id, password := range passwords {
+		user, err := op.GetUserById(id)
+		if err != nil {
+			utils.Log.Fatalf("failed get user: %+v", err)
+		}
+		user.Password = password
+		if err := op.UpdateUser(user); err != nil {
+			utils.Log.Fatalf("failed hash password of user [%s]: %+v", user.Username, err)
+		}
+		if err := db.ClearPlainPassword(id); err != nil {
+			utils.Log.Fatalf("failed clear plain password of user [%s]: %+v", user.Username, err)
+		}
+		utils.Log.Infof("hashed the password of user [%s]", user.Username)
+	}
+	for page := 1; ; page++ {
+		metas, _, err := db.GetMetas(page, 100)
+		if err != nil {
+			utils.Log.Fatalf("failed get metas: %+v", err)
+		}
+		for i := range metas {
+			if metas[i].Password == "" || utils.IsPasswordHashed(metas[i].Password) {
+				continue
+			}
+			if err := op.UpdateMeta(&metas[i]); err != nil {
+				utils.Log.Fatalf("failed hash password of meta [%s]: %+v", metas[i].Path, err)
+			}
+			utils.Log.Infof("hashed the password of meta [%s]", metas[i].Path)
+		}
+		if len(metas) < 100 {
+			break
+		}
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"os"
 
 	"github.com/alist-org/alist/v3/cmd/flags"
-	"github.com/alist-org/alist/v3/internal/db"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -33,7 +32,7 @@ func initUser() {
 			if err := op.CreateUser(admin); err != nil {
 				panic(err)
 			} else {
-				utils.Log.Infof("Successfully created the admin user and the initial password is: %s", admin.Password)
+				utils.Log.Infof("Successfully created the admin user and the initial password is: %s", adminPassword)
 			}
 		} else {
 			panic(err)
@@ -50,7 +49,7 @@ func initUser() {
 				Permission: 0,
 				Disabled:   true,
 			}
-			if err := db.CreateUser(guest); err != nil {
+			if err := op.CreateUser(guest); err != nil {
 				panic(err)
 			}
 		} else {
@@ -0,0 +1,17 @@
+package bootstrap
+
+import (
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/listcache"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+func InitListCache() {
+	backend, err := listcache.New(conf.Conf.ListCache)
+	if err != nil {
+		utils.Log.Errorf("failed init list cache, the listings are cached in memory: %+v", err)
+		return
+	}
+	op.SetListCache(backend)
+}
@@ -0,0 +1,45 @@
+package bootstrap
+
+import (
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/aria2"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/qbittorrent"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/pkg/errors"
+)
+
+type workerSetter interface {
+	SetWorkers(workers int)
+}
+
+// the task managers resized by the settings
+var taskWorkers = map[string][]workerSetter{
+	conf.CopyTaskWorkers:            {fs.CopyTaskManager},
+	conf.UploadTaskWorkers:          {fs.UploadTaskManager},
+	conf.OfflineDownloadTaskWorkers: {aria2.DownTaskManager, qbittorrent.DownTaskManager},
+	conf.TransferTaskWorkers:        {aria2.TransferTaskManager, qbittorrent.TransferTaskManager},
+}
+
+func InitTaskManagers() {
+	for key, managers := range taskWorkers {
+		key, managers := key, managers
+		op.RegisterSettingItemHook(key, func(item *model.SettingItem) error {
+			workers, err := strconv.Atoi(item.Value)
+			if err != nil || workers < 1 {
+				return errors.Errorf("invalid %s: %s, should be a positive number", key, item.Value)
+			}
+			for _, m := range managers {
+				m.SetWorkers(workers)
+			}
+			return nil
+		})
+		for _, m := range managers {
+			m.SetWorkers(setting.GetInt(key, 3))
+		}
+	}
+}
@@ -0,0 +1,223 @@
+// Package bundle export the storages, metas, users and setting items of the instance
+// to a versioned json or yaml bundle, and import the bundle by merging or replacing
+package bundle
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Version of the bundle format, the bundles of newer versions can't be imported
+const Version = 1
+
+const (
+	KindStorage = "storage"
+	KindMeta    = "meta"
+	KindUser    = "user"
+	KindSetting = "setting"
+)
+
+var Kinds = []string{KindStorage, KindMeta, KindUser, KindSetting}
+
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// User is the user with the password hash and the otp secret, which are hidden in model.User
+type User struct {
+	model.User
+	PwdHash   string `json:"pwd_hash"`
+	OtpSecret string `json:"otp_secret"`
+}
+
+func (u User) toModel() model.User {
+	user := u.User
+	user.Password = ""
+	user.PwdHash = u.PwdHash
+	user.OtpSecret = u.OtpSecret
+	return user
+}
+
+func fromModel(u model.User) User {
+	return User{User: u, PwdHash: u.PwdHash, OtpSecret: u.OtpSecret}
+}
+
+type Bundle struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// the kinds in the bundle, other kinds are untouched on import
+	Include    []string            `json:"include"`
+	Secrets    string              `json:"secrets"`
+	Encryption *Encryption         `json:"encryption,omitempty"`
+	Storages   []model.Storage     `json:"storages,omitempty"`
+	Metas      []model.Meta        `json:"metas,omitempty"`
+	Users      []User              `json:"users,omitempty"`
+	Settings   []model.SettingItem `json:"settings,omitempty"`
+}
+
+func (b *Bundle) includes(kind string) bool {
+	return utils.SliceContains(b.Include, kind)
+}
+
+type ExportOptions struct {
+	// the kinds to export, all kinds if empty
+	Include    []string
+	Secrets    string
+	Passphrase string
+}
+
+func checkKinds(kinds []string) ([]string, error) {
+	if len(kinds) == 0 {
+		return Kinds, nil
+	}
+	for _, kind := range kinds {
+		if !utils.SliceContains(Kinds, kind) {
+			return nil, errors.Errorf("invalid kind: %s, should be one of %v", kind, Kinds)
+		}
+	}
+	return kinds, nil
+}
+
+// exportedSetting returns if the setting item is a part of the configuration,
+// the readonly, deprecated and the internal single items are not
+func exportedSetting(item model.SettingItem) bool {
+	return item.Group != model.SINGLE && item.Flag != model.READONLY && item.Flag != model.DEPRECATED
+}
+
+// Export the items of the included kinds in the database to the bundle
+func Export(opts ExportOptions) (*Bundle, error) {
+	include, err := checkKinds(opts.Include)
+	if err != nil {
+		return nil, err
+	}
+	s, encryption, err := newSealer(opts.Secrets, opts.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{Version: Version, CreatedAt: time.Now(), Include: include, Secrets: s.mode, Encryption: encryption}
+	if b.includes(KindStorage) {
+		storages, _, err := db.GetStorages(1, model.MaxInt)
+		if err != nil {
+			return nil, err
+		}
+		for _, storage := range storages {
+			if storage.Addition, err = s.sealJSON(storage.Addition); err != nil {
+				return nil, errors.WithMessagef(err, "failed seal addition of storage [%s]", storage.MountPath)
+			}
+			b.Storages = append(b.Storages, storage)
+		}
+	}
+	if b.includes(KindMeta) {
+		metas, _, err := op.GetMetas(1, model.MaxInt)
+		if err != nil {
+			return nil, err
+		}
+		for _, meta := range metas {
+			if meta.Password, err = s.seal(meta.Password); err != nil {
+				return nil, err
+			}
+			b.Metas = append(b.Metas, meta)
+		}
+	}
+	if b.includes(KindUser) {
+		users, _, err := op.GetUsers(1, model.MaxInt)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			u := fromModel(user)
+			if u.PwdHash, err = s.seal(u.PwdHash); err != nil {
+				return nil, err
+			}
+			if u.OtpSecret, err = s.seal(u.OtpSecret); err != nil {
+				return nil, err
+			}
+			b.Users = append(b.Users, u)
+		}
+	}
+	if b.includes(KindSetting) {
+		items, err := op.GetSettingItems()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if !exportedSetting(item) {
+				continue
+			}
+			if isSecretSetting(item.Key) {
+				item.Value, err = s.seal(item.Value)
+			} else if utils.SliceContains(jsonSettings, item.Key) {
+				item.Value, err = s.sealJSON(item.Value)
+			}
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed seal setting [%s]", item.Key)
+			}
+			b.Settings = append(b.Settings, item)
+		}
+	}
+	return b, nil
+}
+
+// Marshal the bundle in the format, json or yaml
+func Marshal(b *Bundle, format string) ([]byte, error) {
+	data, err := utils.Json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	switch format {
+	case "", FormatJSON:
+		return data, nil
+	case FormatYAML:
+		// json is yaml, convert it by the node to keep the order of the fields
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		resetStyle(&node)
+		data, err = yaml.Marshal(&node)
+		return data, errors.WithStack(err)
+	}
+	return nil, errors.Errorf("invalid format: %s, should be json or yaml", format)
+}
+
+// resetStyle drop the flow style and the quotes of json
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, n := range node.Content {
+		resetStyle(n)
+	}
+}
+
+// Unmarshal the bundle in json or yaml
+func Unmarshal(data []byte) (*Bundle, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] != '{' {
+		var v any
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, errors.Wrap(err, "failed parse bundle")
+		}
+		var err error
+		if data, err = utils.Json.Marshal(v); err != nil {
+			return nil, errors.Wrap(err, "failed parse bundle")
+		}
+	}
+	var b Bundle
+	if err := utils.Json.Unmarshal(data, &b); err != nil {
+		return nil, errors.Wrap(err, "failed parse bundle")
+	}
+	if b.Version < 1 || b.Version > Version {
+		return nil, errors.Errorf("unsupported bundle version: %d", b.Version)
+	}
+	var err error
+	if b.Include, err = checkKinds(b.Include); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
@@ -0,0 +1,211 @@
+package bundle
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+func setup(t *testing.T) {
+	// the disabled storage is not loaded, so that the addition is kept as it is
+	storage := model.Storage{Driver: "Local", MountPath: "/local", Disabled: true,
+		Addition: `{"root_folder_path":"/data","refresh_token":"abc"}`}
+	if err := db.CreateStorage(&storage); err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	if err := op.CreateMeta(&model.Meta{Path: "/a", Password: "pwd"}); err != nil {
+		t.Fatalf("failed create meta: %+v", err)
+	}
+	if err := op.CreateUser(&model.User{Username: "alice", Password: "pass", BasePath: "/", OtpSecret: "otp"}); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	if err := db.SaveSettingItems([]model.SettingItem{
+		{Key: conf.SiteTitle, Value: "AList", Group: model.SITE},
+		{Key: conf.LdapManagerPassword, Value: "manager", Group: model.LDAP, Flag: model.PRIVATE},
+		{Key: conf.Token, Value: "jwt", Group: model.SINGLE, Flag: model.PRIVATE},
+	}); err != nil {
+		t.Fatalf("failed save settings: %+v", err)
+	}
+}
+
+func findSetting(b *Bundle, key string) *model.SettingItem {
+	for i := range b.Settings {
+		if b.Settings[i].Key == key {
+			return &b.Settings[i]
+		}
+	}
+	return nil
+}
+
+func TestBundle(t *testing.T) {
+	setup(t)
+	ctx := context.Background()
+
+	// the yaml bundle is the same as the json one
+	plain, err := Export(ExportOptions{})
+	if err != nil {
+		t.Fatalf("failed export: %+v", err)
+	}
+	if findSetting(plain, conf.Token) != nil {
+		t.Errorf("the internal token should not be exported")
+	}
+	jsonData, _ := Marshal(plain, FormatJSON)
+	yamlData, err := Marshal(plain, FormatYAML)
+	if err != nil {
+		t.Fatalf("failed marshal yaml: %+v", err)
+	}
+	fromJSON, err := Unmarshal(jsonData)
+	if err != nil {
+		t.Fatalf("failed unmarshal json: %+v", err)
+	}
+	fromYAML, err := Unmarshal(yamlData)
+	if err != nil {
+		t.Fatalf("failed unmarshal yaml: %+v", err)
+	}
+	if !fromJSON.CreatedAt.Equal(fromYAML.CreatedAt) {
+		t.Errorf("the created time is changed by yaml")
+	}
+	fromYAML.CreatedAt = fromJSON.CreatedAt
+	for i := range fromYAML.Storages {
+		fromYAML.Storages[i].Modified = fromJSON.Storages[i].Modified
+	}
+	if !reflect.DeepEqual(fromJSON, fromYAML) {
+		t.Errorf("the yaml bundle is different:\n%s", yamlData)
+	}
+	if changes, err := Import(ctx, fromYAML, ImportOptions{DryRun: true}); err != nil || len(changes) != 0 {
+		t.Errorf("expect no change, got %+v %+v", changes, err)
+	}
+
+	// the redacted secrets are kept on import
+	redacted, err := Export(ExportOptions{Secrets: SecretsRedact})
+	if err != nil {
+		t.Fatalf("failed export: %+v", err)
+	}
+	if addition := redacted.Storages[0].Addition; utils.Json.Get([]byte(addition), "refresh_token").ToString() != Redacted ||
+		utils.Json.Get([]byte(addition), "root_folder_path").ToString() == Redacted {
+		t.Errorf("unexpected redacted addition: %s", addition)
+	}
+	if redacted.Users[0].PwdHash != Redacted || redacted.Users[0].OtpSecret != Redacted || redacted.Metas[0].Password != Redacted {
+		t.Errorf("the password and otp secret should be redacted")
+	}
+	if findSetting(redacted, conf.LdapManagerPassword).Value != Redacted || findSetting(redacted, conf.SiteTitle).Value != "AList" {
+		t.Errorf("unexpected redacted settings: %+v", redacted.Settings)
+	}
+	redacted.Storages[0].Remark = "imported"
+	redacted.Users = append(redacted.Users, User{User: model.User{Username: "bob", BasePath: "/bob"}})
+	findSetting(redacted, conf.SiteTitle).Value = "Imported"
+	changes, err := Import(ctx, redacted, ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("failed dry run: %+v", err)
+	}
+	expected := []Change{
+		{Kind: KindStorage, Key: "/local", Action: ActionUpdate, Fields: []string{"remark"}},
+		{Kind: KindUser, Key: "bob", Action: ActionCreate},
+		{Kind: KindSetting, Key: conf.SiteTitle, Action: ActionUpdate, Fields: []string{"value"}},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expect changes %+v, got %+v", expected, changes)
+	}
+	if _, err := Import(ctx, redacted, ImportOptions{}); err != nil {
+		t.Fatalf("failed import: %+v", err)
+	}
+	storage, _ := db.GetStorageByMountPath("/local")
+	if storage.Remark != "imported" || utils.Json.Get([]byte(storage.Addition), "refresh_token").ToString() != "abc" {
+		t.Errorf("unexpected imported storage: %+v", storage)
+	}
+	alice, _ := op.GetUserByName("alice")
+	if alice.ValidatePassword("pass") != nil || alice.OtpSecret != "otp" {
+		t.Errorf("the redacted secrets of alice should be kept")
+	}
+	if _, err := op.GetUserByName("bob"); err != nil {
+		t.Errorf("failed get imported user: %+v", err)
+	}
+	if item, _ := op.GetSettingItemByKey(conf.LdapManagerPassword); item.Value != "manager" {
+		t.Errorf("the redacted setting should be kept, got %s", item.Value)
+	}
+
+	// the encrypted secrets are restored with the passphrase
+	encrypted, err := Export(ExportOptions{Include: []string{KindUser, KindSetting}, Secrets: SecretsEncrypt, Passphrase: "pass phrase"})
+	if err != nil {
+		t.Fatalf("failed export: %+v", err)
+	}
+	if encrypted.Storages != nil || encrypted.Users[0].OtpSecret == "otp" {
+		t.Errorf("unexpected encrypted bundle: %+v", encrypted)
+	}
+	if _, err := Import(ctx, encrypted, ImportOptions{Passphrase: "wrong", DryRun: true}); err == nil {
+		t.Errorf("the wrong passphrase should be rejected")
+	}
+	alice.OtpSecret = "changed"
+	_ = op.UpdateUser(alice)
+	session, _, err := op.CreateSession(alice, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("failed create session: %+v", err)
+	}
+	_ = op.SaveSettingItem(&model.SettingItem{Key: conf.LdapManagerPassword, Value: "changed", Group: model.LDAP, Flag: model.PRIVATE})
+	changes, err = Import(ctx, encrypted, ImportOptions{Passphrase: "pass phrase"})
+	if err != nil {
+		t.Fatalf("failed import: %+v", err)
+	}
+	expected = []Change{
+		{Kind: KindUser, Key: "alice", Action: ActionUpdate, Fields: []string{"otp_secret"}},
+		{Kind: KindSetting, Key: conf.LdapManagerPassword, Action: ActionUpdate, Fields: []string{"value"}},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expect changes %+v, got %+v", expected, changes)
+	}
+	if alice, _ = op.GetUserByName("alice"); alice.OtpSecret != "otp" {
+		t.Errorf("the otp secret should be decrypted, got %s", alice.OtpSecret)
+	}
+	if _, err := op.GetSession(session.ID); err == nil {
+		t.Errorf("the session should be revoked when the otp secret is changed")
+	}
+
+	// the tokens are invalidated when the password is changed by import
+	alice.Password = "other"
+	_ = op.UpdateUser(alice)
+	gen := alice.TokenGen
+	if session, _, err = op.CreateSession(alice, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("failed create session: %+v", err)
+	}
+	if _, err = Import(ctx, encrypted, ImportOptions{Passphrase: "pass phrase"}); err != nil {
+		t.Fatalf("failed import: %+v", err)
+	}
+	if alice, _ = op.GetUserByName("alice"); alice.ValidatePassword("pass") != nil || alice.TokenGen != gen+1 {
+		t.Errorf("expect the password restored and the token generation bumped, got %d", alice.TokenGen)
+	}
+	if _, err := op.GetSession(session.ID); err == nil {
+		t.Errorf("the session should be revoked when the password is changed")
+	}
+
+	// the items not in the bundle are deleted by replace
+	changes, err = Import(ctx, &Bundle{Version: Version, Include: []string{KindMeta, KindUser},
+		Users: []User{{User: model.User{Username: "alice", BasePath: "/"}}}}, ImportOptions{Mode: ModeReplace, DryRun: true})
+	if err != nil {
+		t.Fatalf("failed dry run: %+v", err)
+	}
+	expected = []Change{
+		{Kind: KindMeta, Key: "/a", Action: ActionDelete},
+		{Kind: KindUser, Key: "alice", Action: ActionUpdate, Fields: []string{"otp_secret", "pwd_hash"}},
+		{Kind: KindUser, Key: "bob", Action: ActionDelete},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expect changes %+v, got %+v", expected, changes)
+	}
+}
@@ -0,0 +1,444 @@
+package bundle
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ModeMerge create the new items and update the existing ones
+	ModeMerge = "merge"
+	// ModeReplace also delete the items not in the bundle, except the admin and guest users,
+	// the setting items are never deleted
+	ModeReplace = "replace"
+)
+
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+type ImportOptions struct {
+	Mode string
+	// only returns the changes without applying them
+	DryRun     bool
+	Passphrase string
+}
+
+// Change is an item changed by the import, Key is the mount path, path, username or setting key
+type Change struct {
+	Kind   string   `json:"kind"`
+	Key    string   `json:"key"`
+	Action string   `json:"action"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+type step struct {
+	Change
+	apply func(ctx context.Context) error
+}
+
+// fields ignored by the diff, they are maintained by the instance
+var ignoredFields = []string{"id", "modified", "status"}
+
+func toMap(v any) (map[string]any, error) {
+	data, err := utils.Json.MarshalToString(v)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	m, err := parseJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return m.(map[string]any), nil
+}
+
+// diffFields returns the sorted names of the different fields of the items
+func diffFields(newItem, oldItem any) ([]string, error) {
+	newMap, err := toMap(newItem)
+	if err != nil {
+		return nil, err
+	}
+	oldMap, err := toMap(oldItem)
+	if err != nil {
+		return nil, err
+	}
+	var fields []string
+	for key, value := range newMap {
+		if !utils.SliceContains(ignoredFields, key) && !reflect.DeepEqual(value, oldMap[key]) {
+			fields = append(fields, key)
+		}
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// diffAddition returns the different fields of the additions as addition.<field>
+func diffAddition(newAddition, oldAddition string) []string {
+	if newAddition == oldAddition {
+		return nil
+	}
+	newV, err1 := parseJSON(newAddition)
+	oldV, err2 := parseJSON(oldAddition)
+	newMap, ok1 := newV.(map[string]any)
+	oldMap, ok2 := oldV.(map[string]any)
+	if err1 != nil || err2 != nil || !ok1 || !ok2 {
+		return []string{"addition"}
+	}
+	var fields []string
+	for key, value := range newMap {
+		if !reflect.DeepEqual(value, oldMap[key]) {
+			fields = append(fields, "addition."+key)
+		}
+	}
+	for key := range oldMap {
+		if _, ok := newMap[key]; !ok {
+			fields = append(fields, "addition."+key)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// sameJSON returns if the json are the same regardless of the format and the order of the fields
+func sameJSON(a, b string) bool {
+	av, err1 := parseJSON(a)
+	bv, err2 := parseJSON(b)
+	return err1 == nil && err2 == nil && reflect.DeepEqual(av, bv)
+}
+
+// Import the bundle to the instance, the changes are returned even if some of them failed
+func Import(ctx context.Context, b *Bundle, opts ImportOptions) ([]Change, error) {
+	switch opts.Mode {
+	case "":
+		opts.Mode = ModeMerge
+	case ModeMerge, ModeReplace:
+	default:
+		return nil, errors.Errorf("invalid import mode: %s, should be merge or replace", opts.Mode)
+	}
+	s, err := openSealer(b, opts.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	var steps []step
+	for _, plan := range []struct {
+		kind string
+		fn   func(*sealer, *Bundle, bool) ([]step, error)
+	}{
+		{KindStorage, planStorages},
+		{KindMeta, planMetas},
+		{KindUser, planUsers},
+		{KindSetting, planSettings},
+	} {
+		if !b.includes(plan.kind) {
+			continue
+		}
+		kindSteps, err := plan.fn(s, b, opts.Mode == ModeReplace)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed plan %s", plan.kind)
+		}
+		steps = append(steps, kindSteps...)
+	}
+	changes := make([]Change, len(steps))
+	for i := range steps {
+		changes[i] = steps[i].Change
+	}
+	if opts.DryRun {
+		return changes, nil
+	}
+	var errs []error
+	for _, st := range steps {
+		if err := st.apply(ctx); err != nil {
+			errs = append(errs, errors.WithMessagef(err, "failed %s %s [%s]", st.Action, st.Kind, st.Key))
+		}
+	}
+	return changes, utils.MergeErrors(errs...)
+}
+
+func planStorages(s *sealer, b *Bundle, replace bool) ([]step, error) {
+	existing, _, err := db.GetStorages(1, model.MaxInt)
+	if err != nil {
+		return nil, err
+	}
+	olds := make(map[string]model.Storage, len(existing))
+	for _, storage := range existing {
+		olds[storage.MountPath] = storage
+	}
+	var steps []step
+	seen := make(map[string]struct{})
+	for _, storage := range b.Storages {
+		storage := storage
+		storage.MountPath = utils.FixAndCleanPath(storage.MountPath)
+		seen[storage.MountPath] = struct{}{}
+		old, ok := olds[storage.MountPath]
+		if storage.Addition, err = s.openJSON(storage.Addition, old.Addition); err != nil {
+			return nil, errors.WithMessagef(err, "failed open addition of storage [%s]", storage.MountPath)
+		}
+		storage.ID, storage.Status = old.ID, ""
+		change := Change{Kind: KindStorage, Key: storage.MountPath, Action: ActionCreate}
+		if !ok {
+			steps = append(steps, step{Change: change, apply: func(ctx context.Context) error {
+				return createStorage(ctx, storage)
+			}})
+			continue
+		}
+		// the additions are compared by the fields, the order of them doesn't matter
+		compared := old
+		compared.Addition = storage.Addition
+		if change.Fields, err = diffFields(storage, compared); err != nil {
+			return nil, err
+		}
+		change.Fields = append(change.Fields, diffAddition(storage.Addition, old.Addition)...)
+		if len(change.Fields) == 0 {
+			continue
+		}
+		change.Action = ActionUpdate
+		steps = append(steps, step{Change: change, apply: func(ctx context.Context) error {
+			return updateStorage(ctx, old, storage)
+		}})
+	}
+	if replace {
+		for _, old := range existing {
+			if _, ok := seen[old.MountPath]; ok {
+				continue
+			}
+			old := old
+			steps = append(steps, step{Change: Change{Kind: KindStorage, Key: old.MountPath, Action: ActionDelete},
+				apply: func(ctx context.Context) error {
+					return deleteStorage(ctx, old)
+				}})
+		}
+	}
+	return steps, nil
+}
+
+// createStorage create the storage, which is loaded only if it's enabled
+func createStorage(ctx context.Context, storage model.Storage) error {
+	storage.ID = 0
+	if storage.Disabled {
+		storage.Modified = time.Now()
+		return db.CreateStorage(&storage)
+	}
+	_, err := op.CreateStorage(ctx, storage)
+	return err
+}
+
+func updateStorage(ctx context.Context, old, storage model.Storage) error {
+	if !op.HasStorage(old.MountPath) {
+		// the storage is disabled or not loaded by the cli, save it and load it if enabled now
+		storage.Modified = time.Now()
+		if err := db.UpdateStorage(&storage); err != nil {
+			return err
+		}
+		if old.Disabled && !storage.Disabled {
+			return op.LoadStorage(ctx, storage)
+		}
+		return nil
+	}
+	if old.Driver != storage.Driver {
+		// the driver of the storage can't be changed, recreate it
+		if err := deleteStorage(ctx, old); err != nil {
+			return err
+		}
+		return createStorage(ctx, storage)
+	}
+	if storage.Disabled {
+		if err := op.DisableStorage(ctx, storage.ID); err != nil {
+			return err
+		}
+	}
+	return op.UpdateStorage(ctx, storage)
+}
+
+func deleteStorage(ctx context.Context, old model.Storage) error {
+	if !op.HasStorage(old.MountPath) {
+		return db.DeleteStorageById(old.ID)
+	}
+	return op.DeleteStorageById(ctx, old.ID)
+}
+
+func planMetas(s *sealer, b *Bundle, replace bool) ([]step, error) {
+	existing, _, err := op.GetMetas(1, model.MaxInt)
+	if err != nil {
+		return nil, err
+	}
+	olds := make(map[string]model.Meta, len(existing))
+	for _, meta := range existing {
+		olds[meta.Path] = meta
+	}
+	var steps []step
+	seen := make(map[string]struct{})
+	for _, meta := range b.Metas {
+		meta := meta
+		meta.Path = utils.FixAndCleanPath(meta.Path)
+		seen[meta.Path] = struct{}{}
+		old, ok := olds[meta.Path]
+		if meta.Password, err = s.openValue(meta.Password, old.Password); err != nil {
+			return nil, errors.WithMessagef(err, "failed open password of meta [%s]", meta.Path)
+		}
+		meta.ID = old.ID
+		change := Change{Kind: KindMeta, Key: meta.Path, Action: ActionCreate}
+		if !ok {
+			steps = append(steps, step{Change: change, apply: func(ctx context.Context) error {
+				meta.ID = 0
+				return op.CreateMeta(&meta)
+			}})
+			continue
+		}
+		if change.Fields, err = diffFields(meta, old); err != nil {
+			return nil, err
+		}
+		if len(change.Fields) == 0 {
+			continue
+		}
+		change.Action = ActionUpdate
+		steps = append(steps, step{Change: change, apply: func(ctx context.Context) error {
+			return op.UpdateMeta(&meta)
+		}})
+	}
+	if replace {
+		for _, old := range existing {
+			if _, ok := seen[old.Path]; ok {
+				continue
+			}
+			id := old.ID
+			steps = append(steps, step{Change: Change{Kind: KindMeta, Key: old.Path, Action: ActionDelete},
+				apply: func(ctx context.Context) error {
+					return op.DeleteMetaById(id)
+				}})
+		}
+	}
+	return steps, nil
+}
+
+func planUsers(s *sealer, b *Bundle, replace bool) ([]step, error) {
+	existing, _, err := op.GetUsers(1, model.MaxInt)
+	if err != nil {
+		return nil, err
+	}
+	olds := make(map[string]model.User, len(existing))
+	for _, user := range existing {
+		olds[user.Username] = user
+	}
+	// the admin and guest are matched by the role if the name is changed, since the admin may be renamed
+	findOld := func(u User) (model.User, bool) {
+		if old, ok := olds[u.Username]; ok && old.Role == u.Role {
+			return old, true
+		}
+		for _, old := range existing {
+			if (u.IsAdmin() && old.IsAdmin()) || (u.IsGuest() && old.IsGuest()) {
+				return old, true
+			}
+		}
+		old, ok := olds[u.Username]
+		return old, ok
+	}
+	var steps []step
+	seen := make(map[uint]struct{})
+	for _, bu := range b.Users {
+		old, ok := findOld(bu)
+		if ok {
+			seen[old.ID] = struct{}{}
+		}
+		if bu.PwdHash, err = s.openValue(bu.PwdHash, old.PwdHash); err != nil {
+			return nil, errors.WithMessagef(err, "failed open password of user [%s]", bu.Username)
+		}
+		if bu.OtpSecret, err = s.openValue(bu.OtpSecret, old.OtpSecret); err != nil {
+			return nil, errors.WithMessagef(err, "failed open otp secret of user [%s]", bu.Username)
+		}
+		user := bu.toModel()
+		user.BasePath = utils.FixAndCleanPath(user.BasePath)
+		user.ID = old.ID
+		change := Change{Kind: KindUser, Key: user.Username, Action: ActionCreate}
+		if !ok {
+			steps = append(steps, step{Change: change, apply: func(ctx context.Context) error {
+				user.ID = 0
+				return op.CreateUser(&user)
+			}})
+			continue
+		}
+		// the source of the user is kept by op.UpdateUser
+		user.Source = old.Source
+		if change.Fields, err = diffFields(fromModel(user), fromModel(old)); err != nil {
+			return nil, err
+		}
+		if len(change.Fields) == 0 {
+			continue
+		}
+		change.Action = ActionUpdate
+		// the tokens are invalidated by op.UpdateUser if the password is changed
+		otpChanged := user.OtpSecret != old.OtpSecret
+		steps = append(steps, step{Change: change, apply: func(ctx context.Context) error {
+			if err := op.UpdateUser(&user); err != nil {
+				return err
+			}
+			if otpChanged {
+				return op.DeleteSessionsByUser(user.ID)
+			}
+			return nil
+		}})
+	}
+	if replace {
+		for _, old := range existing {
+			if _, ok := seen[old.ID]; ok || old.IsAdmin() || old.IsGuest() {
+				continue
+			}
+			id := old.ID
+			steps = append(steps, step{Change: Change{Kind: KindUser, Key: old.Username, Action: ActionDelete},
+				apply: func(ctx context.Context) error {
+					return op.DeleteUserById(id)
+				}})
+		}
+	}
+	return steps, nil
+}
+
+// planSettings update the values of the existing setting items, the items unknown
+// to this version or not exported are skipped, so that they are never deleted
+func planSettings(s *sealer, b *Bundle, _ bool) ([]step, error) {
+	existing, err := op.GetSettingItems()
+	if err != nil {
+		return nil, err
+	}
+	olds := make(map[string]model.SettingItem, len(existing))
+	for _, item := range existing {
+		olds[item.Key] = item
+	}
+	var steps []step
+	for _, item := range b.Settings {
+		old, ok := olds[item.Key]
+		if !ok || !exportedSetting(old) {
+			continue
+		}
+		value := item.Value
+		if isSecretSetting(item.Key) {
+			value, err = s.openValue(value, old.Value)
+		} else if utils.SliceContains(jsonSettings, item.Key) {
+			if value, err = s.openJSON(value, old.Value); err == nil && sameJSON(value, old.Value) {
+				value = old.Value
+			}
+		}
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed open setting [%s]", item.Key)
+		}
+		if value == old.Value {
+			continue
+		}
+		old.Value = value
+		steps = append(steps, step{Change: Change{Kind: KindSetting, Key: item.Key, Action: ActionUpdate, Fields: []string{"value"}},
+			apply: func(ctx context.Context) error {
+				// save with the hook of the item
+				return op.SaveSettingItem(&old)
+			}})
+	}
+	return steps, nil
+}
@@ -0,0 +1,264 @@
+package bundle
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	SecretsPlain   = "plain"
+	SecretsRedact  = "redact"
+	SecretsEncrypt = "encrypt"
+)
+
+// Redacted replace the secrets in the redacted bundle, the existing values are kept on import
+const Redacted = "<redacted>"
+
+const (
+	encryptedPrefix = "enc:"
+	// encrypted with the key to check the passphrase before decrypting the secrets
+	checkPlaintext = "alist-bundle"
+)
+
+var secretKeyParts = []string{"password", "passwd", "secret", "token", "cookie", "credential",
+	"private_key", "access_key", "seckey", "apikey", "api_key", "auth_key"}
+
+// isSecretKey guess if the field of the key holds a secret by its name,
+// the urls, types and ids are not secrets even if they are named with a token
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, suffix := range []string{"_url", "_uri", "_type", "_id"} {
+		if strings.HasSuffix(key, suffix) {
+			return false
+		}
+	}
+	for _, part := range secretKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSecretSetting returns if the whole value of the setting item is a secret
+func isSecretSetting(key string) bool {
+	// the url of qbittorrent contains the username and password
+	return key == conf.QbittorrentUrl || isSecretKey(key)
+}
+
+// jsonSettings are the setting items whose value is json with the secret fields
+var jsonSettings = []string{conf.OIDCProviders}
+
+// Encryption is the parameters to derive the key from the passphrase
+type Encryption struct {
+	Salt  string `json:"salt"`
+	Check string `json:"check"`
+}
+
+// sealer seal the secrets on export and open them on import by the mode of the bundle
+type sealer struct {
+	mode string
+	aead cipher.AEAD
+}
+
+func newAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase is required to encrypt or decrypt the secrets")
+	}
+	block, err := aes.NewCipher(argon2.IDKey([]byte(passphrase), salt, 1, 64*1024, 4, 32))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	return aead, errors.WithStack(err)
+}
+
+// newSealer create the sealer to export the secrets, the encryption is returned in the encrypt mode
+func newSealer(mode, passphrase string) (*sealer, *Encryption, error) {
+	switch mode {
+	case "":
+		mode = SecretsPlain
+	case SecretsPlain, SecretsRedact, SecretsEncrypt:
+	default:
+		return nil, nil, errors.Errorf("invalid secrets mode: %s, should be plain, redact or encrypt", mode)
+	}
+	s := &sealer{mode: mode}
+	if mode != SecretsEncrypt {
+		return s, nil, nil
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.aead = aead
+	check, err := s.seal(checkPlaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, &Encryption{Salt: base64.StdEncoding.EncodeToString(salt), Check: check}, nil
+}
+
+// openSealer create the sealer to open the secrets of the bundle, the passphrase is checked if encrypted
+func openSealer(b *Bundle, passphrase string) (*sealer, error) {
+	if b.Secrets != SecretsEncrypt {
+		return &sealer{mode: b.Secrets}, nil
+	}
+	if b.Encryption == nil {
+		return nil, errors.New("the encryption of the bundle is missing")
+	}
+	salt, err := base64.StdEncoding.DecodeString(b.Encryption.Salt)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid salt of the bundle")
+	}
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	s := &sealer{mode: SecretsEncrypt, aead: aead}
+	if check, _, err := s.open(b.Encryption.Check); err != nil || check != checkPlaintext {
+		return nil, errors.New("wrong passphrase")
+	}
+	return s, nil
+}
+
+func (s *sealer) seal(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	switch s.mode {
+	case SecretsRedact:
+		return Redacted, nil
+	case SecretsEncrypt:
+		nonce := make([]byte, s.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", errors.WithStack(err)
+		}
+		return encryptedPrefix + base64.StdEncoding.EncodeToString(s.aead.Seal(nonce, nonce, []byte(value), nil)), nil
+	}
+	return value, nil
+}
+
+// open returns the plain secret, redacted is true if the secret is not in the bundle
+func (s *sealer) open(value string) (plain string, redacted bool, err error) {
+	if value == Redacted {
+		return "", true, nil
+	}
+	if s.mode != SecretsEncrypt || !strings.HasPrefix(value, encryptedPrefix) {
+		return value, false, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil || len(data) < s.aead.NonceSize() {
+		return "", false, errors.New("invalid encrypted secret")
+	}
+	nonce, data := data[:s.aead.NonceSize()], data[s.aead.NonceSize():]
+	plainBytes, err := s.aead.Open(nil, nonce, data, nil)
+	if err != nil {
+		return "", false, errors.New("failed decrypt secret")
+	}
+	return string(plainBytes), false, nil
+}
+
+// openValue open the secret, the old value is kept if it's redacted
+func (s *sealer) openValue(value, old string) (string, error) {
+	plain, redacted, err := s.open(value)
+	if redacted {
+		return old, nil
+	}
+	return plain, err
+}
+
+func parseJSON(str string) (any, error) {
+	var v any
+	decoder := utils.Json.NewDecoder(strings.NewReader(str))
+	// keep the numbers as they are
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return v, nil
+}
+
+// walkSecrets call fn with the secret fields in the json objects and arrays,
+// fn returns the new value of the field
+func walkSecrets(v any, old any, fn func(value string, old any) (string, error)) error {
+	switch v := v.(type) {
+	case map[string]any:
+		oldMap, _ := old.(map[string]any)
+		for key, value := range v {
+			if str, ok := value.(string); ok && isSecretKey(key) {
+				sealed, err := fn(str, oldMap[key])
+				if err != nil {
+					return errors.WithMessagef(err, "field %s", key)
+				}
+				v[key] = sealed
+			} else if err := walkSecrets(value, oldMap[key], fn); err != nil {
+				return err
+			}
+		}
+	case []any:
+		oldSlice, _ := old.([]any)
+		for i := range v {
+			var oldItem any
+			if i < len(oldSlice) {
+				oldItem = oldSlice[i]
+			}
+			if err := walkSecrets(v[i], oldItem, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sealJSON seal the secret fields in the json
+func (s *sealer) sealJSON(str string) (string, error) {
+	if str == "" || s.mode == SecretsPlain {
+		return str, nil
+	}
+	v, err := parseJSON(str)
+	if err != nil {
+		return "", err
+	}
+	if err := walkSecrets(v, nil, func(value string, _ any) (string, error) {
+		return s.seal(value)
+	}); err != nil {
+		return "", err
+	}
+	return utils.Json.MarshalToString(v)
+}
+
+// openJSON open the secret fields in the json, the redacted fields take the values in the old json,
+// and they are empty if not found in the old one
+func (s *sealer) openJSON(str, old string) (string, error) {
+	if str == "" || s.mode == SecretsPlain {
+		return str, nil
+	}
+	v, err := parseJSON(str)
+	if err != nil {
+		return "", err
+	}
+	var oldV any
+	if old != "" {
+		// the broken old json is the same as missing
+		oldV, _ = parseJSON(old)
+	}
+	if err := walkSecrets(v, oldV, func(value string, old any) (string, error) {
+		oldStr, _ := old.(string)
+		return s.openValue(value, oldStr)
+	}); err != nil {
+		return "", err
+	}
+	return utils.Json.MarshalToString(v)
+}
@@ -23,6 +23,12 @@ type Scheme struct {
 	Https    bool   `json:"https" env:"HTTPS"`
 	CertFile string `json:"cert_file" env:"CERT_FILE"`
 	KeyFile  string `json:"key_file" env:"KEY_FILE"`
+	// off, optional or required, the client certificates are verified by the ca
+	ClientAuth    string `json:"client_auth" env:"CLIENT_AUTH"`
+	ClientCAFile  string `json:"client_ca_file" env:"CLIENT_CA_FILE"`
+	ClientCRLFile string `json:"client_crl_file" env:"CLIENT_CRL_FILE"`
+	// cn, email, dns or uri of the client certificate used as the username
+	ClientCertUser string `json:"client_cert_user" env:"CLIENT_CERT_USER"`
 }
 
 type LogConfig struct {
@@ -34,6 +40,16 @@ type LogConfig struct {
 	Compress   bool   `json:"compress" env:"COMPRESS"`
 }
 
+type ListCache struct {
+	// memory, bolt or redis
+	Type          string `json:"type" env:"LIST_CACHE_TYPE"`
+	BoltFile      string `json:"bolt_file" env:"LIST_CACHE_BOLT_FILE"`
+	RedisAddress  string `json:"redis_address" env:"LIST_CACHE_REDIS_ADDRESS"`
+	RedisPassword string `json:"redis_password" env:"LIST_CACHE_REDIS_PASSWORD"`
+	RedisDB       int    `json:"redis_db" env:"LIST_CACHE_REDIS_DB"`
+	RedisPrefix   string `json:"redis_prefix" env:"LIST_CACHE_REDIS_PREFIX"`
+}
+
 type Config struct {
 	Force                 bool      `json:"force" env:"FORCE"`
 	Address               string    `json:"address" env:"ADDR"`
@@ -42,10 +58,13 @@ type Config struct {
 	Cdn                   string    `json:"cdn" env:"CDN"`
 	JwtSecret             string    `json:"jwt_secret" env:"JWT_SECRET"`
 	TokenExpiresIn        int       `json:"token_expires_in" env:"TOKEN_EXPIRES_IN"`
+	AccessTokenExpiresIn  int       `json:"access_token_expires_in" env:"ACCESS_TOKEN_EXPIRES_IN"`
 	Database              Database  `json:"database"`
 	Scheme                Scheme    `json:"scheme"`
 	TempDir               string    `json:"temp_dir" env:"TEMP_DIR"`
 	BleveDir              string    `json:"bleve_dir" env:"BLEVE_DIR"`
+	BlockCacheDir         string    `json:"block_cache_dir" env:"BLOCK_CACHE_DIR"`
+	ListCache             ListCache `json:"list_cache"`
 	Log                   LogConfig `json:"log"`
 	MaxConnections        int       `json:"max_connections" env:"MAX_CONNECTIONS"`
 	TlsInsecureSkipVerify bool      `json:"tls_insecure_skip_verify" env:"TLS_INSECURE_SKIP_VERIFY"`
@@ -54,21 +73,33 @@ type Config struct {
 func DefaultConfig() *Config {
 	tempDir := filepath.Join(flags.DataDir, "temp")
 	indexDir := filepath.Join(flags.DataDir, "bleve")
+	blockCacheDir := filepath.Join(flags.DataDir, "block_cache")
 	logPath := filepath.Join(flags.DataDir, "log/log.log")
 	dbPath := filepath.Join(flags.DataDir, "data.db")
+	listCachePath := filepath.Join(flags.DataDir, "list_cache.db")
 	return &Config{
 		Address:        "0.0.0.0",
 		Port:           5244,
 		JwtSecret:      random.String(16),
 		TokenExpiresIn: 48,
 		TempDir:        tempDir,
+		Scheme: Scheme{
+			ClientAuth:     "off",
+			ClientCertUser: "cn",
+		},
 		Database: Database{
 			Type:        "sqlite3",
 			Port:        0,
 			TablePrefix: "x_",
 			DBFile:      dbPath,
 		},
-		BleveDir: indexDir,
+		BleveDir:      indexDir,
+		BlockCacheDir: blockCacheDir,
+		ListCache: ListCache{
+			Type:        "memory",
+			BoltFile:    listCachePath,
+			RedisPrefix: "alist:list:",
+		},
 		Log: LogConfig{
 			Enable:     true,
 			Name:       logPath,
@@ -41,6 +41,9 @@ const (
 	OcrApi                  = "ocr_api"
 	FilenameCharMapping     = "filename_char_mapping"
 	ForwardDirectLinkParams = "forward_direct_link_params"
+	StorageHealthInterval   = "storage_health_interval"
+	StorageStatusWebhook    = "storage_status_webhook"
+	BlockCacheSize          = "block_cache_size"
 
 	// index
 	SearchIndex     = "search_index"
@@ -66,6 +69,39 @@ const (
 	SSOEndpointName     = "sso_endpoint_name"
 	SSOJwtPublicKey     = "sso_jwt_public_key"
 
+	// ldap
+	LdapLoginEnabled      = "ldap_login_enabled"
+	LdapServer            = "ldap_server"
+	LdapManagerDN         = "ldap_manager_dn"
+	LdapManagerPassword   = "ldap_manager_password"
+	LdapUserSearchBase    = "ldap_user_search_base"
+	LdapUserSearchFilter  = "ldap_user_search_filter"
+	LdapGroupAttribute    = "ldap_group_attribute"
+	LdapGroupMapping      = "ldap_group_mapping"
+	LdapDefaultPermission = "ldap_default_permission"
+	LdapDefaultBasePath   = "ldap_default_base_path"
+
+	// oidc
+	OIDCLoginEnabled = "oidc_login_enabled"
+	OIDCProviders    = "oidc_providers"
+
+	// proxy auth
+	ProxyAuthEnabled           = "proxy_auth_enabled"
+	ProxyAuthTrustedCIDRs      = "proxy_auth_trusted_cidrs"
+	ProxyAuthUserHeader        = "proxy_auth_user_header"
+	ProxyAuthGroupsHeader      = "proxy_auth_groups_header"
+	ProxyAuthAutoRegister      = "proxy_auth_auto_register"
+	ProxyAuthLocalUsers        = "proxy_auth_local_users"
+	ProxyAuthGroupMapping      = "proxy_auth_group_mapping"
+	ProxyAuthDefaultPermission = "proxy_auth_default_permission"
+	ProxyAuthDefaultBasePath   = "proxy_auth_default_base_path"
+
+	// task
+	CopyTaskWorkers            = "copy_task_workers"
+	UploadTaskWorkers          = "upload_task_workers"
+	OfflineDownloadTaskWorkers = "offline_download_task_workers"
+	TransferTaskWorkers        = "transfer_task_workers"
+
 	// qbittorrent
 	QbittorrentUrl      = "qbittorrent_url"
 	QbittorrentSeedtime = "qbittorrent_seedtime"
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateApiToken(t *model.ApiToken) error {
+	return errors.WithStack(db.Create(t).Error)
+}
+
+func GetApiTokenByHash(hash string) (*model.ApiToken, error) {
+	t := model.ApiToken{TokenHash: hash}
+	if err := db.Where(t).First(&t).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find api token")
+	}
+	return &t, nil
+}
+
+func GetApiTokenById(id uint) (*model.ApiToken, error) {
+	var t model.ApiToken
+	if err := db.First(&t, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get api token")
+	}
+	return &t, nil
+}
+
+func GetApiTokensByUser(userID uint) ([]model.ApiToken, error) {
+	var tokens []model.ApiToken
+	if err := db.Where(model.ApiToken{UserID: userID}).Order(columnName("id")).Find(&tokens).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get api tokens")
+	}
+	return tokens, nil
+}
+
+func UpdateApiTokenLastUsed(id uint, t time.Time) error {
+	return errors.WithStack(db.Model(&model.ApiToken{ID: id}).Update("last_used", t).Error)
+}
+
+func DeleteApiTokenById(id uint) error {
+	return errors.WithStack(db.Delete(&model.ApiToken{}, id).Error)
+}
+
+func DeleteApiTokensByUser(userID uint) error {
+	return errors.WithStack(db.Where(model.ApiToken{UserID: userID}).Delete(&model.ApiToken{}).Error)
+}
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+// GetUploadCheckpoint get the checkpoint by the key of the copy
+func GetUploadCheckpoint(key string) (*model.UploadCheckpoint, error) {
+	var c model.UploadCheckpoint
+	if err := db.Where(model.UploadCheckpoint{Key: key}).First(&c).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get upload checkpoint")
+	}
+	return &c, nil
+}
+
+func SaveUploadCheckpoint(c *model.UploadCheckpoint) error {
+	return errors.WithStack(db.Save(c).Error)
+}
+
+func DeleteUploadCheckpointById(id uint) error {
+	return errors.WithStack(db.Delete(&model.UploadCheckpoint{}, id).Error)
+}
+
+// DeleteUploadCheckpointsBefore delete the checkpoints not updated since t, their sessions are likely expired
+func DeleteUploadCheckpointsBefore(t time.Time) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s < ?", columnName("updated_at")), t).Delete(&model.UploadCheckpoint{}).Error)
+}
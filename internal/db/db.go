@@ -12,7 +12,7 @@ var db *gorm.DB
 
 func Init(d *gorm.DB) {
 	db = d
-	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode))
+	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.StorageStatus), new(model.ApiToken), new(model.Session), new(model.WebAuthnCredential), new(model.RecoveryCode), new(model.UploadCheckpoint))
 	if err != nil {
 		log.Fatalf("failed migrate database: %s", err.Error())
 	}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateSession(s *model.Session) error {
+	return errors.WithStack(db.Create(s).Error)
+}
+
+func UpdateSession(s *model.Session) error {
+	return errors.WithStack(db.Save(s).Error)
+}
+
+func GetSessionById(id string) (*model.Session, error) {
+	var s model.Session
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("id")), id).First(&s).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get session")
+	}
+	return &s, nil
+}
+
+func GetSessionByRefreshHash(hash string) (*model.Session, error) {
+	var s model.Session
+	if err := db.Where(model.Session{RefreshHash: hash}).First(&s).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get session")
+	}
+	return &s, nil
+}
+
+func GetSessionsByUser(userID uint) ([]model.Session, error) {
+	var sessions []model.Session
+	if err := db.Where(model.Session{UserID: userID}).Order(fmt.Sprintf("%s desc", columnName("last_seen"))).Find(&sessions).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get sessions")
+	}
+	return sessions, nil
+}
+
+func UpdateSessionLastSeen(id string, t time.Time) error {
+	return errors.WithStack(db.Model(&model.Session{ID: id}).Update("last_seen", t).Error)
+}
+
+func DeleteSessionById(id string) error {
+	return errors.WithStack(db.Delete(&model.Session{ID: id}).Error)
+}
+
+func DeleteSessionsByUser(userID uint) error {
+	return errors.WithStack(db.Where(model.Session{UserID: userID}).Delete(&model.Session{}).Error)
+}
+
+func DeleteSessionsExpiredBefore(t time.Time) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s < ?", columnName("expires_at")), t).Delete(&model.Session{}).Error)
+}
@@ -0,0 +1,29 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateStorageStatus(s *model.StorageStatus) error {
+	return errors.WithStack(db.Create(s).Error)
+}
+
+// GetStorageStatuses get status history of a storage, the latest first
+func GetStorageStatuses(storageID uint, pageIndex, pageSize int) (statuses []model.StorageStatus, count int64, err error) {
+	statusDB := db.Model(&model.StorageStatus{}).Where(fmt.Sprintf("%s = ?", columnName("storage_id")), storageID)
+	if err = statusDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get storage statuses count")
+	}
+	if err = statusDB.Order(fmt.Sprintf("%s desc", columnName("id"))).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&statuses).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find storage statuses")
+	}
+	return statuses, count, nil
+}
+
+func DeleteStorageStatusesBefore(t time.Time) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s < ?", columnName("time")), t).Delete(&model.StorageStatus{}).Error)
+}
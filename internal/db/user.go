@@ -1,6 +1,8 @@
 package db
 
 import (
+	"fmt"
+
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/pkg/errors"
 )
@@ -59,3 +61,30 @@ func GetUsers(pageIndex, pageSize int) (users []model.User, count int64, err err
 func DeleteUserById(id uint) error {
 	return errors.WithStack(db.Delete(&model.User{}, id).Error)
 }
+
+type plainPassword struct {
+	ID       uint
+	Password string
+}
+
+// GetPlainPasswords returns the plaintext passwords stored by the old versions,
+// the column is not in the model anymore but still exists in the old databases
+func GetPlainPasswords() (map[uint]string, error) {
+	if !db.Migrator().HasColumn(&model.User{}, "password") {
+		return nil, nil
+	}
+	var rows []plainPassword
+	if err := db.Model(&model.User{}).Select("id", "password").
+		Where(fmt.Sprintf("%s <> ''", columnName("password"))).Scan(&rows).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get plain passwords")
+	}
+	passwords := make(map[uint]string, len(rows))
+	for _, row := range rows {
+		passwords[row.ID] = row.Password
+	}
+	return passwords, nil
+}
+
+func ClearPlainPassword(id uint) error {
+	return errors.WithStack(db.Model(&model.User{}).Where("id = ?", id).Update("password", "").Error)
+}
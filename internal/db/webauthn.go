@@ -0,0 +1,76 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func CreateWebAuthnCredential(c *model.WebAuthnCredential) error {
+	return errors.WithStack(db.Create(c).Error)
+}
+
+func GetWebAuthnCredentialById(id uint) (*model.WebAuthnCredential, error) {
+	var c model.WebAuthnCredential
+	if err := db.First(&c, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webauthn credential")
+	}
+	return &c, nil
+}
+
+func GetWebAuthnCredentialsByUser(userID uint) ([]model.WebAuthnCredential, error) {
+	var credentials []model.WebAuthnCredential
+	if err := db.Where(model.WebAuthnCredential{UserID: userID}).Order(columnName("id")).Find(&credentials).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webauthn credentials")
+	}
+	return credentials, nil
+}
+
+func UpdateWebAuthnCredentialUsed(id uint, credential string, t time.Time) error {
+	return errors.WithStack(db.Model(&model.WebAuthnCredential{ID: id}).
+		Updates(map[string]any{"credential": credential, "last_used": t}).Error)
+}
+
+func DeleteWebAuthnCredentialById(id uint) error {
+	return errors.WithStack(db.Delete(&model.WebAuthnCredential{}, id).Error)
+}
+
+func DeleteWebAuthnCredentialsByUser(userID uint) error {
+	return errors.WithStack(db.Where(model.WebAuthnCredential{UserID: userID}).Delete(&model.WebAuthnCredential{}).Error)
+}
+
+// ReplaceRecoveryCodes replace all recovery codes of the user with the new ones
+func ReplaceRecoveryCodes(userID uint, hashes []string) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(model.RecoveryCode{UserID: userID}).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]model.RecoveryCode, len(hashes))
+		for i, hash := range hashes {
+			codes[i] = model.RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	}))
+}
+
+func CountRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	if err := db.Model(&model.RecoveryCode{}).Where(model.RecoveryCode{UserID: userID}).Count(&count).Error; err != nil {
+		return 0, errors.Wrapf(err, "failed count recovery codes")
+	}
+	return count, nil
+}
+
+// DeleteRecoveryCode delete the code of the user, returns false if there's no such code
+func DeleteRecoveryCode(userID uint, hash string) (bool, error) {
+	res := db.Where(model.RecoveryCode{UserID: userID, CodeHash: hash}).Delete(&model.RecoveryCode{})
+	if res.Error != nil {
+		return false, errors.WithStack(res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
@@ -0,0 +1,132 @@
+package dedupe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// Apply add a task that delete the duplicates in the report of args.Tid,
+// or replace them with .url link stubs
+func Apply(args ApplyArgs) (uint64, error) {
+	if args.Action != ActionDelete && args.Action != ActionLink {
+		return 0, errors.Errorf("unknown action: %s", args.Action)
+	}
+	report, ok := GetReport(args.Tid)
+	if !ok {
+		return 0, errors.Errorf("report of task [%d] not found", args.Tid)
+	}
+	sets := report.Sets
+	if len(args.Sets) > 0 {
+		sets = make([]Set, 0, len(args.Sets))
+		for _, i := range args.Sets {
+			if i < 0 || i >= len(report.Sets) {
+				return 0, errors.Errorf("set index out of range: %d", i)
+			}
+			sets = append(sets, report.Sets[i])
+		}
+	}
+	tid := TaskManager.Submit(task.WithCancelCtx(&task.Task[uint64]{
+		Name: fmt.Sprintf("dedupe %s duplicates of scan [%d]", args.Action, args.Tid),
+		Func: func(t *task.Task[uint64]) error {
+			return apply(t, args, sets)
+		},
+	}))
+	return tid, nil
+}
+
+func apply(t *task.Task[uint64], args ApplyArgs, sets []Set) error {
+	var errs []error
+	for i, set := range sets {
+		if utils.IsCanceled(t.Ctx) {
+			return t.Ctx.Err()
+		}
+		kept, dups := splitSet(set, args.Keep)
+		// the report may be stale, the duplicates are removed only if a kept file is still there
+		if err := checkUnchanged(t.Ctx, kept[0]); err != nil {
+			errs = append(errs, errors.WithMessagef(err, "skip the set of [%s]", kept[0].Path))
+			t.SetProgress((i + 1) * 100 / len(sets))
+			continue
+		}
+		for _, f := range dups {
+			t.SetStatus(fmt.Sprintf("%s %s", args.Action, f.Path))
+			err := checkUnchanged(t.Ctx, f)
+			if err == nil && args.Action == ActionLink {
+				err = replaceWithLink(t.Ctx, f.Path, kept[0].Path, args.ApiUrl)
+			} else if err == nil {
+				err = fs.Remove(t.Ctx, f.Path)
+			}
+			if err != nil {
+				errs = append(errs, errors.WithMessagef(err, "failed %s [%s]", args.Action, f.Path))
+			}
+		}
+		t.SetProgress((i + 1) * 100 / len(sets))
+	}
+	return utils.MergeErrors(errs...)
+}
+
+// checkUnchanged returns an error if the file is gone or its size changed since the scan
+func checkUnchanged(ctx context.Context, f File) error {
+	obj, err := fs.Get(ctx, f.Path, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return errors.WithMessage(err, "failed get file")
+	}
+	if obj.IsDir() || obj.GetSize() != f.Size {
+		return errors.Errorf("the file changed since the scan, size %d -> %d", f.Size, obj.GetSize())
+	}
+	return nil
+}
+
+// splitSet returns all the files of the set in keep and the duplicates to remove,
+// the oldest file is kept if none of them is in keep.
+// A duplicate with the same path as a kept file is the kept file itself, so it's never removed
+func splitSet(set Set, keep []string) (kept []File, dups []File) {
+	for _, f := range set.Files {
+		if utils.SliceContains(keep, f.Path) {
+			kept = append(kept, f)
+		}
+	}
+	if len(kept) == 0 {
+		// files are sorted by modified time
+		kept = set.Files[:1]
+	}
+	keptPaths := make(map[string]struct{}, len(kept))
+	for _, f := range kept {
+		keptPaths[f.Path] = struct{}{}
+	}
+	for _, f := range set.Files {
+		if _, ok := keptPaths[f.Path]; !ok {
+			dups = append(dups, f)
+			keptPaths[f.Path] = struct{}{}
+		}
+	}
+	return kept, dups
+}
+
+// replaceWithLink put a <name>.url stub beside the duplicate that point to the kept file,
+// then remove the duplicate. The link has no sign, since the stub may be in a public folder
+// and a sign can't be revoked, so the file is downloaded with the permission of the visitor
+func replaceWithLink(ctx context.Context, dupPath, keepPath, apiUrl string) error {
+	content := fmt.Sprintf("[InternetShortcut]\r\nURL=%s/d%s\r\n", apiUrl, utils.EncodePath(keepPath, true))
+	dir, name := stdpath.Split(dupPath)
+	stub := &model.FileStream{
+		Obj: &model.Object{
+			Name: name + ".url",
+			Size: int64(len(content)),
+		},
+		ReadCloser: io.NopCloser(strings.NewReader(content)),
+		Mimetype:   "application/internet-shortcut",
+	}
+	if err := fs.PutDirectly(ctx, dir, stub); err != nil {
+		return errors.WithMessage(err, "failed put link stub")
+	}
+	return fs.Remove(ctx, dupPath)
+}
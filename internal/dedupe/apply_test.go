@@ -0,0 +1,64 @@
+package dedupe
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+func TestApply(t *testing.T) {
+	dir := t.TempDir()
+	id, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/dedupe",
+		Addition:  `{"root_folder_path":"` + filepath.ToSlash(dir) + `"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	defer func() {
+		_ = op.DeleteStorageById(context.Background(), id)
+	}()
+	for name, content := range map[string]string{"a": "same", "b": "same", "c": "same", "d": "changed"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sets := []Set{
+		// the same file listed twice is kept
+		{Size: 4, Files: []File{{Path: "/dedupe/a", Size: 4}, {Path: "/dedupe/a", Size: 4}, {Path: "/dedupe/b", Size: 4}}},
+		// the file changed since the scan is not removed
+		{Size: 4, Files: []File{{Path: "/dedupe/c", Size: 4}, {Path: "/dedupe/d", Size: 4}}},
+		// the kept file is gone, so the duplicate is the only copy
+		{Size: 4, Files: []File{{Path: "/dedupe/e", Size: 4}, {Path: "/dedupe/c", Size: 4}}},
+	}
+	tsk := task.WithCancelCtx(&task.Task[uint64]{})
+	if err := apply(tsk, ApplyArgs{Action: ActionDelete}, sets); err == nil {
+		t.Errorf("expect the changed files reported")
+	}
+	for name, exist := range map[string]bool{"a": true, "b": false, "c": true, "d": true} {
+		if utils.Exists(filepath.Join(dir, name)) != exist {
+			t.Errorf("expect %s exist: %v", name, exist)
+		}
+	}
+}
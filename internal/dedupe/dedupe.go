@@ -0,0 +1,74 @@
+// Package dedupe find duplicate files across storages and clean them up
+package dedupe
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/pkg/generic_sync"
+	"github.com/alist-org/alist/v3/pkg/task"
+)
+
+var TaskManager = task.NewTaskManager(1, func(tid *uint64) {
+	atomic.AddUint64(tid, 1)
+})
+
+// reports store the result of scan tasks, keyed by task id
+var reports generic_sync.MapOf[uint64, *Report]
+
+const (
+	ActionDelete = "delete"
+	// ActionLink replace the duplicate with a .url link stub to the kept file
+	ActionLink = "link"
+)
+
+type File struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	// HashType is "provider:<type>" if the hash is reported by the driver,
+	// or the algorithm used when computed by alist
+	HashType string `json:"hash_type"`
+	Hash     string `json:"hash"`
+}
+
+type Set struct {
+	Size  int64  `json:"size"`
+	Hash  string `json:"hash"`
+	Files []File `json:"files"`
+}
+
+type Report struct {
+	Paths   []string `json:"paths"`
+	Scanned int      `json:"scanned"`
+	// Unhashed is the count of same-size candidates that could not be hashed
+	Unhashed int   `json:"unhashed"`
+	Wasted   int64 `json:"wasted"`
+	Sets     []Set `json:"sets"`
+}
+
+type ScanArgs struct {
+	Paths       []string `json:"paths"`
+	MinSize     int64    `json:"min_size"`
+	MaxDepth    int      `json:"max_depth"`
+	ComputeHash bool     `json:"compute_hash"`
+}
+
+type ApplyArgs struct {
+	Tid    uint64 `json:"-"`
+	Action string `json:"action"`
+	// Keep is the paths to keep, all of them in a set are kept,
+	// for the set without a kept path, the oldest file is kept
+	Keep []string `json:"keep"`
+	// Sets is the index of sets to apply, empty means all
+	Sets   []int  `json:"sets"`
+	ApiUrl string `json:"-"`
+}
+
+func GetReport(tid uint64) (*Report, bool) {
+	return reports.Load(tid)
+}
+
+func DelReport(tid uint64) {
+	reports.Delete(tid)
+}
@@ -0,0 +1,248 @@
+package dedupe
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Scan add a scan task and return its id, the report can be got by GetReport after the task succeeded
+func Scan(args ScanArgs) (uint64, error) {
+	if len(args.Paths) == 0 {
+		return 0, errors.New("paths is empty")
+	}
+	for i := range args.Paths {
+		args.Paths[i] = utils.FixAndCleanPath(args.Paths[i])
+	}
+	// the files under the overlapping paths would be duplicates of themselves
+	for i := range args.Paths {
+		for j := i + 1; j < len(args.Paths); j++ {
+			if utils.IsSubPath(args.Paths[i], args.Paths[j]) || utils.IsSubPath(args.Paths[j], args.Paths[i]) {
+				return 0, errors.Errorf("paths [%s] and [%s] overlap", args.Paths[i], args.Paths[j])
+			}
+		}
+	}
+	if args.MaxDepth == 0 {
+		args.MaxDepth = -1
+	}
+	tid := TaskManager.Submit(task.WithCancelCtx(&task.Task[uint64]{
+		Name: fmt.Sprintf("dedupe scan %v", args.Paths),
+		Func: func(t *task.Task[uint64]) error {
+			report, err := scan(t, args)
+			if err != nil {
+				return err
+			}
+			reports.Store(t.ID, report)
+			return nil
+		},
+	}))
+	return tid, nil
+}
+
+type candidate struct {
+	File
+	obj model.Obj
+}
+
+func scan(t *task.Task[uint64], args ScanArgs) (*Report, error) {
+	report := &Report{Paths: args.Paths}
+	// group by size first, it's free
+	bySize := make(map[int64][]candidate)
+	// the paths reached more than once, such as by the nested mount paths, are only counted once
+	seen := make(map[string]struct{})
+	for _, root := range args.Paths {
+		t.SetStatus("walking " + root)
+		rootObj, err := fs.Get(t.Ctx, root, &fs.GetArgs{})
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed get [%s]", root)
+		}
+		err = fs.WalkFS(t.Ctx, args.MaxDepth, root, rootObj, func(reqPath string, obj model.Obj) error {
+			if utils.IsCanceled(t.Ctx) {
+				return t.Ctx.Err()
+			}
+			if obj.IsDir() {
+				return nil
+			}
+			if _, ok := seen[reqPath]; ok {
+				return nil
+			}
+			seen[reqPath] = struct{}{}
+			report.Scanned++
+			if obj.GetSize() <= 0 || obj.GetSize() < args.MinSize {
+				return nil
+			}
+			bySize[obj.GetSize()] = append(bySize[obj.GetSize()], candidate{
+				File: File{
+					Path:     reqPath,
+					Size:     obj.GetSize(),
+					Modified: obj.ModTime(),
+				},
+				obj: obj,
+			})
+			return nil
+		})
+		if err != nil && err != filepath.SkipDir {
+			return nil, errors.WithMessagef(err, "failed walk [%s]", root)
+		}
+	}
+	var total, done int
+	for _, cs := range bySize {
+		if len(cs) > 1 {
+			total += len(cs)
+		}
+	}
+	// then group by hash
+	byHash := make(map[string][]File)
+	var compute hashFunc
+	if args.ComputeHash {
+		compute = func(ctx context.Context, path string) (string, error) {
+			t.SetStatus("hashing " + path)
+			return computeHash(ctx, path)
+		}
+	}
+	for _, cs := range bySize {
+		if len(cs) < 2 {
+			continue
+		}
+		groups, unhashed, err := groupByHash(t.Ctx, cs, compute)
+		if err != nil {
+			return nil, err
+		}
+		for key, files := range groups {
+			byHash[key] = append(byHash[key], files...)
+		}
+		report.Unhashed += unhashed
+		done += len(cs)
+		t.SetProgress(done * 100 / total)
+	}
+	for _, files := range byHash {
+		if len(files) < 2 {
+			continue
+		}
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].Modified.Before(files[j].Modified)
+		})
+		report.Sets = append(report.Sets, Set{
+			Size:  files[0].Size,
+			Hash:  files[0].Hash,
+			Files: files,
+		})
+		report.Wasted += files[0].Size * int64(len(files)-1)
+	}
+	sort.Slice(report.Sets, func(i, j int) bool {
+		return report.Sets[i].Size*int64(len(report.Sets[i].Files)) > report.Sets[j].Size*int64(len(report.Sets[j].Files))
+	})
+	t.SetStatus(fmt.Sprintf("found %d duplicate sets", len(report.Sets)))
+	return report, nil
+}
+
+type hashFunc func(ctx context.Context, path string) (string, error)
+
+// computedHashType is the algorithm of the hash computed by alist
+const computedHashType = "sha1"
+
+// groupByHash group the candidates of the same size by their content hash.
+// The hashes reported by drivers are compared by the algorithm, so a provider sha1 match a computed one.
+// If the candidates don't share an algorithm, the sha1 of the ones without it is computed when compute is not nil,
+// the candidates that still can't be compared are counted as unhashed
+func groupByHash(ctx context.Context, cs []candidate, compute hashFunc) (map[string][]File, int, error) {
+	types := make(map[string]int)
+	for i := range cs {
+		if hashType, hash, ok := model.GetHash(cs[i].obj); ok {
+			cs[i].HashType, cs[i].Hash = "provider:"+strings.ToLower(hashType), strings.ToLower(hash)
+			types[algorithm(cs[i].HashType)]++
+		}
+	}
+	groups := make(map[string][]File)
+	unhashed := 0
+	for _, c := range cs {
+		if utils.IsCanceled(ctx) {
+			return nil, 0, ctx.Err()
+		}
+		algo := algorithm(c.HashType)
+		// compute the sha1 if some other candidate can't be compared by the provider hash
+		if algo != computedHashType && (algo == "" || types[algo] < len(cs)) && compute != nil {
+			hash, err := compute(ctx, c.Path)
+			if err != nil {
+				log.Warnf("failed compute hash of [%s]: %+v", c.Path, err)
+			} else {
+				c.HashType, c.Hash = computedHashType, hash
+				algo = computedHashType
+			}
+		}
+		if c.Hash == "" {
+			unhashed++
+			continue
+		}
+		key := fmt.Sprintf("%d:%s:%s", c.Size, algo, c.Hash)
+		groups[key] = append(groups[key], c.File)
+	}
+	return groups, unhashed, nil
+}
+
+// algorithm returns the algorithm of the hash type without the provider prefix
+func algorithm(hashType string) string {
+	return strings.TrimPrefix(hashType, "provider:")
+}
+
+// computeHash read the whole file and compute its sha1
+func computeHash(ctx context.Context, path string) (string, error) {
+	link, _, err := fs.Link(ctx, path, model.LinkArgs{})
+	if err != nil {
+		return "", err
+	}
+	rc, err := openLink(ctx, link)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	h := sha1.New()
+	if err := utils.CopyWithCtx(ctx, h, rc, 0, nil); err != nil {
+		return "", errors.Wrapf(err, "failed read [%s]", path)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func openLink(ctx context.Context, link *model.Link) (io.ReadCloser, error) {
+	if link.RangeReader != nil {
+		return link.RangeReader.RangeRead(ctx, http_range.Range{Start: 0, Length: -1})
+	}
+	if link.Data != nil {
+		return link.Data, nil
+	}
+	if link.FilePath != nil {
+		return os.Open(*link.FilePath)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.URL, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create request for %s", link.URL)
+	}
+	for h, val := range link.Header {
+		req.Header[h] = val
+	}
+	res, err := common.HttpClient().Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get response for %s", link.URL)
+	}
+	if res.StatusCode >= 400 {
+		_ = res.Body.Close()
+		return nil, errors.Errorf("unexpected status %d for %s", res.StatusCode, link.URL)
+	}
+	return res.Body, nil
+}
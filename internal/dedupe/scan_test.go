@@ -0,0 +1,169 @@
+package dedupe
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+type hashObj struct {
+	model.Object
+	hashType, hash string
+}
+
+func (o *hashObj) Hash() (string, string) {
+	return o.hashType, o.hash
+}
+
+func newCandidate(path, hashType, hash string) candidate {
+	var obj model.Obj = &model.Object{Name: path, Size: 10}
+	if hashType != "" {
+		obj = &hashObj{Object: model.Object{Name: path, Size: 10}, hashType: hashType, hash: hash}
+	}
+	return candidate{File: File{Path: path, Size: 10}, obj: obj}
+}
+
+// computed returns a hashFunc with the sha1 of the paths and records the paths hashed
+func computed(hashes map[string]string, hashed *[]string) hashFunc {
+	return func(ctx context.Context, path string) (string, error) {
+		*hashed = append(*hashed, path)
+		if hash, ok := hashes[path]; ok {
+			return hash, nil
+		}
+		return "", errors.New("failed read")
+	}
+}
+
+func TestGroupByHash(t *testing.T) {
+	tests := []struct {
+		name     string
+		cs       []candidate
+		hashes   map[string]string
+		compute  bool
+		sets     [][]string
+		unhashed int
+		hashed   []string
+	}{
+		{
+			name: "provider sha1 match computed sha1",
+			cs: []candidate{
+				newCandidate("/aliyun/a", "sha1", "ABC"),
+				newCandidate("/115/a", "sha1", "abc"),
+				newCandidate("/local/a", "", ""),
+			},
+			hashes:  map[string]string{"/local/a": "abc"},
+			compute: true,
+			sets:    [][]string{{"/aliyun/a", "/115/a", "/local/a"}},
+			hashed:  []string{"/local/a"},
+		},
+		{
+			name: "other algorithm is computed",
+			cs: []candidate{
+				newCandidate("/aliyun/a", "sha1", "abc"),
+				newCandidate("/baidu/a", "md5", "def"),
+			},
+			hashes:  map[string]string{"/baidu/a": "abc"},
+			compute: true,
+			sets:    [][]string{{"/aliyun/a", "/baidu/a"}},
+			hashed:  []string{"/baidu/a"},
+		},
+		{
+			name: "same algorithm is not computed",
+			cs: []candidate{
+				newCandidate("/baidu/a", "md5", "def"),
+				newCandidate("/baidu/b", "md5", "def"),
+				newCandidate("/baidu/c", "md5", "123"),
+			},
+			compute: true,
+			sets:    [][]string{{"/baidu/a", "/baidu/b"}},
+		},
+		{
+			name: "without computing",
+			cs: []candidate{
+				newCandidate("/aliyun/a", "sha1", "abc"),
+				newCandidate("/115/a", "sha1", "abc"),
+				newCandidate("/local/a", "", ""),
+			},
+			sets:     [][]string{{"/aliyun/a", "/115/a"}},
+			unhashed: 1,
+		},
+		{
+			name: "failed computing",
+			cs: []candidate{
+				newCandidate("/baidu/a", "md5", "def"),
+				newCandidate("/local/a", "", ""),
+				newCandidate("/local/b", "", ""),
+			},
+			hashes:   map[string]string{"/local/a": "abc"},
+			compute:  true,
+			unhashed: 1,
+			hashed:   []string{"/baidu/a", "/local/a", "/local/b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hashed []string
+			var compute hashFunc
+			if tt.compute {
+				compute = computed(tt.hashes, &hashed)
+			}
+			groups, unhashed, err := groupByHash(context.Background(), tt.cs, compute)
+			if err != nil {
+				t.Fatalf("failed group: %+v", err)
+			}
+			if unhashed != tt.unhashed {
+				t.Errorf("expect %d unhashed, got %d", tt.unhashed, unhashed)
+			}
+			if len(hashed) != len(tt.hashed) {
+				t.Errorf("expect %v hashed, got %v", tt.hashed, hashed)
+			}
+			var sets [][]File
+			for _, files := range groups {
+				if len(files) > 1 {
+					sets = append(sets, files)
+				}
+			}
+			if len(sets) != len(tt.sets) {
+				t.Fatalf("expect %d sets, got %+v", len(tt.sets), sets)
+			}
+			for i, files := range sets {
+				if len(files) != len(tt.sets[i]) {
+					t.Fatalf("expect set %v, got %+v", tt.sets[i], files)
+				}
+				for j, f := range files {
+					if f.Path != tt.sets[i][j] {
+						t.Errorf("expect set %v, got %+v", tt.sets[i], files)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSplitSet(t *testing.T) {
+	set := Set{Files: []File{{Path: "/a"}, {Path: "/b"}, {Path: "/c"}}}
+	kept, dups := splitSet(set, []string{"/b", "/c"})
+	if len(kept) != 2 || kept[0].Path != "/b" || kept[1].Path != "/c" || len(dups) != 1 || dups[0].Path != "/a" {
+		t.Errorf("expect all the paths in keep kept, got %+v %+v", kept, dups)
+	}
+	kept, dups = splitSet(set, nil)
+	if len(kept) != 1 || kept[0].Path != "/a" || len(dups) != 2 {
+		t.Errorf("expect the oldest kept, got %+v %+v", kept, dups)
+	}
+	// the same file reached twice is not a duplicate of itself
+	set = Set{Files: []File{{Path: "/a"}, {Path: "/a"}, {Path: "/b"}, {Path: "/b"}}}
+	kept, dups = splitSet(set, nil)
+	if len(kept) != 1 || kept[0].Path != "/a" || len(dups) != 1 || dups[0].Path != "/b" {
+		t.Errorf("expect the kept path never removed, got %+v %+v", kept, dups)
+	}
+}
+
+func TestScan_Overlap(t *testing.T) {
+	for _, paths := range [][]string{{"/a", "/a/b"}, {"/a/b/", "/a"}, {"/a", "a"}, {"/", "/c"}} {
+		if _, err := Scan(ScanArgs{Paths: paths}); err == nil {
+			t.Errorf("expect the overlapping paths %v rejected", paths)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package downloader
+
+import (
+	"context"
+	"sync"
+)
+
+// MaxBufferSize is the max bytes of the chunks buffered by all the downloads at the same time
+const MaxBufferSize = 256 * 1024 * 1024
+
+// buffers is shared by all the downloads, so they can't buffer more than MaxBufferSize together
+var buffers = newBudget(MaxBufferSize)
+
+// budget is the bytes that can be taken until they are released
+type budget struct {
+	mu    sync.Mutex
+	avail int64
+	// released is closed and replaced once some bytes are released
+	released chan struct{}
+}
+
+func newBudget(size int64) *budget {
+	return &budget{avail: size, released: make(chan struct{})}
+}
+
+// acquire wait until n bytes are available and take them
+func (b *budget) acquire(ctx context.Context, n int64) error {
+	for {
+		b.mu.Lock()
+		if b.avail >= n {
+			b.avail -= n
+			b.mu.Unlock()
+			return nil
+		}
+		released := b.released
+		b.mu.Unlock()
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (b *budget) release(n int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.avail += n
+	close(b.released)
+	b.released = make(chan struct{})
+}
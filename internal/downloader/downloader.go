@@ -0,0 +1,326 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrRangeNotSupported is returned if the upstream ignores the range requests
+	ErrRangeNotSupported = errors.New("range not supported by the upstream")
+	// errLinkRejected is returned if the upstream rejects the link, it's likely expired
+	errLinkRejected = errors.New("link rejected by the upstream")
+)
+
+// maxRetries is the max attempts to fetch a chunk
+const maxRetries = 3
+
+var (
+	once       sync.Once
+	httpClient *http.Client
+)
+
+func client() *http.Client {
+	once.Do(func() {
+		httpClient = base.NewHttpClient()
+	})
+	return httpClient
+}
+
+// RefreshFunc returns a new link when the link is expired or rejected
+type RefreshFunc func(ctx context.Context) (*model.Link, error)
+
+// Req is a download of the range of a link by the concurrent range requests
+type Req struct {
+	Link *model.Link
+	// Refresh is called to get a new link, the link is not refreshed if nil
+	Refresh RefreshFunc
+	Offset  int64
+	Length  int64
+	Options
+}
+
+type chunk struct {
+	start  int64
+	length int64
+	data   []byte
+	err    error
+	done   chan struct{}
+	// release the buffer of the chunk once it's read or dropped
+	release sync.Once
+}
+
+// free release the buffer of the chunk once its fetching is done
+func (c *chunk) free() {
+	<-c.done
+	c.release.Do(func() {
+		c.data = nil
+		buffers.release(c.length)
+	})
+}
+
+// reader reassemble the chunks in order, at most Concurrency chunks are fetched or buffered at the same time,
+// and the chunks of all the readers are limited by MaxBufferSize
+type reader struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	req    Req
+
+	mu        sync.Mutex
+	link      *model.Link
+	expiresAt time.Time
+
+	chunks chan *chunk
+	// sem is taken by a chunk until it's read
+	sem chan struct{}
+	cur *chunk
+	pos int
+
+	closeOnce sync.Once
+}
+
+// Download fetch the range of the link url by the concurrent range requests, the chunks are read in order.
+// ErrRangeNotSupported is returned if the upstream doesn't support range, then it should be fetched as a whole
+func Download(ctx context.Context, req Req) (io.ReadCloser, error) {
+	if req.Link == nil || req.Link.URL == "" {
+		return nil, errors.New("only the links of url can be downloaded")
+	}
+	if req.Concurrency < 1 {
+		req.Concurrency = 1
+	}
+	if req.ChunkSize <= 0 {
+		req.ChunkSize = DefaultChunkSize
+	}
+	if req.ChunkSize > MaxBufferSize {
+		req.ChunkSize = MaxBufferSize
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r := &reader{
+		ctx:    ctx,
+		cancel: cancel,
+		req:    req,
+		chunks: make(chan *chunk, req.Concurrency),
+		sem:    make(chan struct{}, req.Concurrency),
+	}
+	r.setLink(req.Link)
+	go r.dispatch()
+	// the first chunk tells whether the range is supported
+	first, ok := <-r.chunks
+	if !ok {
+		return r, nil
+	}
+	r.cur = first
+	select {
+	case <-first.done:
+	case <-ctx.Done():
+	}
+	if first.err != nil || ctx.Err() != nil {
+		err := first.err
+		if err == nil {
+			err = ctx.Err()
+		}
+		_ = r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// dispatch create the chunks in order and fetch them concurrently
+func (r *reader) dispatch() {
+	defer close(r.chunks)
+	for start := r.req.Offset; start < r.req.Offset+r.req.Length; start += r.req.ChunkSize {
+		select {
+		case r.sem <- struct{}{}:
+		case <-r.ctx.Done():
+			return
+		}
+		c := &chunk{start: start, length: r.req.ChunkSize, done: make(chan struct{})}
+		if end := r.req.Offset + r.req.Length; c.start+c.length > end {
+			c.length = end - c.start
+		}
+		if err := buffers.acquire(r.ctx, c.length); err != nil {
+			return
+		}
+		go r.fetch(c)
+		r.chunks <- c
+	}
+}
+
+func (r *reader) setLink(link *model.Link) {
+	r.link = link
+	r.expiresAt = time.Time{}
+	if link.Expiration != nil && *link.Expiration > 0 {
+		r.expiresAt = time.Now().Add(*link.Expiration)
+	}
+}
+
+// getLink returns the link, it's refreshed if expired
+func (r *reader) getLink() (*model.Link, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.req.Refresh != nil && !r.expiresAt.IsZero() && time.Now().After(r.expiresAt) {
+		if err := r.refreshLocked(); err != nil {
+			return nil, err
+		}
+	}
+	return r.link, nil
+}
+
+// refresh the link rejected by the upstream, unless it's refreshed by another chunk
+func (r *reader) refresh(rejected *model.Link) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.link != rejected {
+		return nil
+	}
+	if r.req.Refresh == nil {
+		return errLinkRejected
+	}
+	return r.refreshLocked()
+}
+
+func (r *reader) refreshLocked() error {
+	link, err := r.req.Refresh(r.ctx)
+	if err != nil {
+		return errors.WithMessage(err, "failed refresh link")
+	}
+	if link.URL == "" {
+		return errors.New("the refreshed link is not url")
+	}
+	r.setLink(link)
+	return nil
+}
+
+func (r *reader) fetch(c *chunk) {
+	defer close(c.done)
+	for i := 0; i < maxRetries; i++ {
+		link, err := r.getLink()
+		if err != nil {
+			c.err = err
+			return
+		}
+		c.data, c.err = fetchRange(r.ctx, link, c.start, c.length)
+		if c.err == nil || r.ctx.Err() != nil || errors.Is(c.err, ErrRangeNotSupported) {
+			return
+		}
+		log.Debugf("failed fetch range %d-%d, attempt %d: %v", c.start, c.start+c.length-1, i+1, c.err)
+		if errors.Is(c.err, errLinkRejected) {
+			if err := r.refresh(link); err != nil {
+				c.err = err
+				return
+			}
+		}
+	}
+}
+
+// get request the link with the link headers, the range header is set if not empty
+func get(ctx context.Context, link *model.Link, rangeHeader string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.URL, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for h, val := range link.Header {
+		req.Header[h] = val
+	}
+	if rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+	res, err := client().Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return res, nil
+}
+
+func fetchRange(ctx context.Context, link *model.Link, start, length int64) ([]byte, error) {
+	res, err := get(ctx, link, fmt.Sprintf("bytes=%d-%d", start, start+length-1))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		// the content is used only if it's exactly the range requested
+		ra, _, err := http_range.ParseContentRange(res.Header.Get("Content-Range"))
+		if err != nil || ra.Start != start || ra.Length != length {
+			return nil, errors.WithMessagef(ErrRangeNotSupported, "content range [%s] for range %d-%d", res.Header.Get("Content-Range"), start, start+length-1)
+		}
+	case http.StatusOK:
+		return nil, ErrRangeNotSupported
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusGone:
+		return nil, errors.WithMessagef(errLinkRejected, "status %d", res.StatusCode)
+	default:
+		return nil, errors.Errorf("upstream respond status %d for range %d-%d", res.StatusCode, start, start+length-1)
+	}
+	data := make([]byte, length)
+	if _, err = io.ReadFull(res.Body, data); err != nil {
+		return nil, errors.Wrapf(err, "failed read range %d-%d", start, start+length-1)
+	}
+	return data, nil
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	for r.cur == nil || r.pos >= len(r.cur.data) {
+		if r.cur != nil {
+			if r.cur.err != nil {
+				return 0, r.cur.err
+			}
+			// the chunk is read, the next one can be fetched
+			r.cur.free()
+			r.cur = nil
+			<-r.sem
+		}
+		select {
+		case c, ok := <-r.chunks:
+			if !ok {
+				if err := r.ctx.Err(); err != nil {
+					return 0, err
+				}
+				return 0, io.EOF
+			}
+			// the chunk is kept as the current one even if it fails, so it's released by Close
+			r.cur, r.pos = c, 0
+		case <-r.ctx.Done():
+			return 0, r.ctx.Err()
+		}
+		select {
+		case <-r.cur.done:
+		case <-r.ctx.Done():
+			return 0, r.ctx.Err()
+		}
+	}
+	n := copy(p, r.cur.data[r.pos:])
+	r.pos += n
+	return n, nil
+}
+
+// Close cancel the fetching, the buffers of the chunks not read are released once they are done
+func (r *reader) Close() error {
+	r.closeOnce.Do(func() {
+		r.cancel()
+		cur := r.cur
+		r.cur = nil
+		go func() {
+			if cur != nil {
+				cur.free()
+			}
+			for c := range r.chunks {
+				c.free()
+			}
+		}()
+	})
+	return nil
+}
@@ -0,0 +1,201 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func newServer(data []byte, handle func(w http.ResponseWriter, r *http.Request) bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handle != nil && !handle(w, r) {
+			return
+		}
+		http.ServeContent(w, r, "a.bin", time.Time{}, bytes.NewReader(data))
+	}))
+}
+
+func TestDownload(t *testing.T) {
+	data := make([]byte, 1024*1024+7)
+	_, _ = rand.Read(data)
+	var cur, peak int32
+	srv := newServer(data, func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Header.Get("X-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return false
+		}
+		n := atomic.AddInt32(&cur, 1)
+		defer atomic.AddInt32(&cur, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return true
+	})
+	defer srv.Close()
+	link := &model.Link{URL: srv.URL, Header: http.Header{"X-Token": []string{"secret"}}}
+
+	for _, r := range [][2]int64{{0, int64(len(data))}, {1000, 300 * 1024}, {int64(len(data)) - 1, 1}} {
+		rc, err := Download(context.Background(), Req{
+			Link:    link,
+			Offset:  r[0],
+			Length:  r[1],
+			Options: Options{Concurrency: 4, ChunkSize: 64 * 1024},
+		})
+		if err != nil {
+			t.Fatalf("failed download: %+v", err)
+		}
+		got, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatalf("failed read: %+v", err)
+		}
+		if !bytes.Equal(got, data[r[0]:r[0]+r[1]]) {
+			t.Errorf("expect the range %d-%d reassembled in order", r[0], r[0]+r[1]-1)
+		}
+	}
+	if peak < 2 || peak > 4 {
+		t.Errorf("expect 2 to 4 concurrent requests, got %d", peak)
+	}
+}
+
+func TestDownload_RangeNotSupported(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello world"))
+	}))
+	defer srv.Close()
+	_, err := Download(context.Background(), Req{
+		Link:    &model.Link{URL: srv.URL},
+		Length:  11,
+		Options: Options{Concurrency: 2, ChunkSize: 4},
+	})
+	if !errors.Is(err, ErrRangeNotSupported) {
+		t.Errorf("expect %v, got %v", ErrRangeNotSupported, err)
+	}
+}
+
+func TestDownload_Refresh(t *testing.T) {
+	data := []byte("hello world, the link is refreshed")
+	srv := newServer(data, func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Query().Get("v") != "2" {
+			w.WriteHeader(http.StatusForbidden)
+			return false
+		}
+		return true
+	})
+	defer srv.Close()
+	var refreshed int32
+	expiration := time.Hour
+	rc, err := Download(context.Background(), Req{
+		Link: &model.Link{URL: srv.URL + "?v=1", Expiration: &expiration},
+		Refresh: func(ctx context.Context) (*model.Link, error) {
+			atomic.AddInt32(&refreshed, 1)
+			return &model.Link{URL: srv.URL + "?v=2"}, nil
+		},
+		Length:  int64(len(data)),
+		Options: Options{Concurrency: 3, ChunkSize: 4},
+	})
+	if err != nil {
+		t.Fatalf("failed download: %+v", err)
+	}
+	defer rc.Close()
+	got, err := io.ReadAll(rc)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("expect %q, got %q, %v", data, got, err)
+	}
+	if refreshed != 1 {
+		t.Errorf("expect the rejected link refreshed once, got %d", refreshed)
+	}
+}
+
+func TestDownload_ContentRange(t *testing.T) {
+	data := []byte("hello world")
+	// the upstream answers 206 with the content from the beginning
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Range", "bytes 0-3/11")
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write(data[:4])
+	}))
+	defer srv.Close()
+	_, err := Download(context.Background(), Req{
+		Link:    &model.Link{URL: srv.URL},
+		Offset:  4,
+		Length:  7,
+		Options: Options{Concurrency: 2, ChunkSize: 4},
+	})
+	if !errors.Is(err, ErrRangeNotSupported) {
+		t.Errorf("expect the misaligned range rejected, got %v", err)
+	}
+}
+
+func TestDownload_Buffers(t *testing.T) {
+	data := make([]byte, 64*1024)
+	_, _ = rand.Read(data)
+	srv := newServer(data, nil)
+	defer srv.Close()
+	// the buffers are released after the chunks are read, or the reader is closed before
+	for _, read := range []bool{true, false} {
+		rc, err := Download(context.Background(), Req{
+			Link:    &model.Link{URL: srv.URL},
+			Length:  int64(len(data)),
+			Options: Options{Concurrency: 4, ChunkSize: 4 * 1024},
+		})
+		if err != nil {
+			t.Fatalf("failed download: %+v", err)
+		}
+		if read {
+			_, _ = io.ReadAll(rc)
+		}
+		_ = rc.Close()
+	}
+	for i := 0; ; i++ {
+		buffers.mu.Lock()
+		avail := buffers.avail
+		buffers.mu.Unlock()
+		if avail == MaxBufferSize {
+			break
+		}
+		if i > 100 {
+			t.Fatalf("expect the buffers released, %d bytes left", MaxBufferSize-avail)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	b := newBudget(10)
+	if err := b.acquire(context.Background(), 8); err != nil {
+		t.Fatal(err)
+	}
+	// wait until the bytes are released
+	done := make(chan error)
+	go func() {
+		done <- b.acquire(context.Background(), 5)
+	}()
+	select {
+	case <-done:
+		t.Fatalf("expect the acquire wait for the release")
+	case <-time.After(50 * time.Millisecond):
+	}
+	b.release(8)
+	if err := <-done; err != nil {
+		t.Errorf("expect the bytes acquired after the release, got %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := b.acquire(ctx, 6); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expect the acquire canceled, got %v", err)
+	}
+}
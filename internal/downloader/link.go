@@ -0,0 +1,57 @@
+package downloader
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Wrap return a link that read the ranges by the concurrent range requests if the storage enable it,
+// only the links of url are wrapped, the local files, streams and handles are returned as is
+func Wrap(storage driver.Driver, link *model.Link, file model.Obj, refresh RefreshFunc) *model.Link {
+	opts, ok := StorageOptions(storage.GetStorage())
+	if !ok || file.GetSize() <= 0 {
+		return link
+	}
+	if link.URL == "" || link.Data != nil || link.FilePath != nil || link.Handle != nil || link.RangeReader != nil {
+		return link
+	}
+	return &model.Link{
+		RangeReader: model.RangeReaderFunc(func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+			req := Req{Link: link, Refresh: refresh, Offset: httpRange.Start, Length: httpRange.Length, Options: opts}
+			if req.Length < 0 {
+				req.Length = file.GetSize() - req.Offset
+			}
+			rc, err := Download(ctx, req)
+			if errors.Is(err, ErrRangeNotSupported) {
+				log.Warnf("the upstream of %s doesn't support range, download it with a single request", file.GetName())
+				return downloadWhole(ctx, req)
+			}
+			return rc, err
+		}),
+	}
+}
+
+// downloadWhole request the whole file and discard the bytes before the offset
+func downloadWhole(ctx context.Context, req Req) (io.ReadCloser, error) {
+	res, err := get(ctx, req.Link, "")
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		_ = res.Body.Close()
+		return nil, errors.Errorf("upstream respond status %d", res.StatusCode)
+	}
+	if _, err = io.CopyN(io.Discard, res.Body, req.Offset); err != nil {
+		_ = res.Body.Close()
+		return nil, errors.Wrapf(err, "failed skip to %d", req.Offset)
+	}
+	return utils.ReadCloser{Reader: io.LimitReader(res.Body, req.Length), Closer: res.Body}, nil
+}
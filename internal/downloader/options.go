@@ -0,0 +1,41 @@
+package downloader
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+// DefaultChunkSize is the size of each range request if the storage doesn't set it
+const DefaultChunkSize = 4 * 1024 * 1024
+
+type Options struct {
+	Concurrency int
+	ChunkSize   int64
+}
+
+// StorageOptions returns the options set by the storage, false if the concurrent download is disabled
+func StorageOptions(storage *model.Storage) (Options, bool) {
+	if storage.DownConcurrency < 2 {
+		return Options{}, false
+	}
+	opts := Options{Concurrency: storage.DownConcurrency, ChunkSize: DefaultChunkSize}
+	if storage.DownChunkSize > 0 {
+		opts.ChunkSize = int64(storage.DownChunkSize) * 1024 * 1024
+	}
+	return opts, true
+}
+
+// LinkRefresher returns a RefreshFunc that drop the cached link of the file and get a new one from the driver
+func LinkRefresher(path string, args model.LinkArgs) RefreshFunc {
+	return func(ctx context.Context) (*model.Link, error) {
+		storage, actualPath, err := op.GetStorageAndActualPath(path)
+		if err != nil {
+			return nil, err
+		}
+		op.ClearLinkCache(storage, actualPath, args.IP)
+		link, _, err := op.Link(ctx, storage, actualPath, args)
+		return link, err
+	}
+}
@@ -12,6 +12,15 @@ type Config struct {
 	CheckStatus       bool   `json:"-"`
 	Alert             string `json:"alert"` //info,success,warning,danger
 	NoOverwriteUpload bool   `json:"-"`
+	// NeedSeekableStream is set if the driver reads the stream more than once,
+	// then the stream is stored in a temp file before the upload if it's not a file
+	NeedSeekableStream bool `json:"-"`
+	// PreHash are the types of the hashes needed before the upload,
+	// they are computed while the stream is stored, see model.FileStreamer.GetHash
+	PreHash []string `json:"-"`
+	// RequestPolicy is set if the driver request the upstream api by the client of base.NewPolicyClient,
+	// then the max_retries and qps of the storage are shown
+	RequestPolicy bool `json:"-"`
 }
 
 func (c Config) MustProxy() bool {
@@ -38,10 +38,27 @@ type Reader interface {
 	Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error)
 }
 
+// PagedLister list a dir page by page, so a huge dir is not loaded into memory at once.
+// The page should contain at most PerPage objs, the objs are in the order of the upstream
+type PagedLister interface {
+	ListPage(ctx context.Context, dir model.Obj, args model.ListPageArgs) (*model.ObjsPage, error)
+}
+
 type GetRooter interface {
 	GetRoot(ctx context.Context) (model.Obj, error)
 }
 
+// Pinger is a cheap probe used by the storage health checker,
+// if not implemented, the root folder will be listed instead
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// SpaceGetter report the space usage of the storage in bytes
+type SpaceGetter interface {
+	GetSpace(ctx context.Context) (used, total int64, err error)
+}
+
 type Getter interface {
 	// Get file by path, the path haven't been joined with root path
 	Get(ctx context.Context, path string) (model.Obj, error)
@@ -109,6 +126,15 @@ type PutResult interface {
 	Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up UpdateProgress) (model.Obj, error)
 }
 
+// ResumablePut upload in parts by a session which can be resumed, it's used by the copy between storages.
+// The stream starts at cp.Offset, and cp.Commit should be called after each part is committed.
+// errs.UploadSessionExpired is returned if the session of cp can't be resumed, then the upload is restarted.
+type ResumablePut interface {
+	PutResumable(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, cp *model.UploadCheckpoint, up UpdateProgress) error
+	// AbortResumable drop the session of cp, such as the uploaded parts
+	AbortResumable(ctx context.Context, cp *model.UploadCheckpoint) error
+}
+
 type UpdateProgress func(percentage int)
 
 type Progress struct {
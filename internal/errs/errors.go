@@ -11,6 +11,7 @@ var (
 
 	MoveBetweenTwoStorages = errors.New("can't move files between two storages, try to copy")
 	UploadNotSupported     = errors.New("upload not supported")
+	UploadSessionExpired   = errors.New("upload session expired")
 
 	MetaNotFound = errors.New("meta not found")
 )
@@ -7,4 +7,7 @@ var (
 	EmptyPassword      = errors.New("password is empty")
 	WrongPassword      = errors.New("password is incorrect")
 	DeleteAdminOrGuest = errors.New("cannot delete admin or guest")
+	InvalidApiToken    = errors.New("api token is invalid")
+	ExpiredApiToken    = errors.New("api token is expired")
+	InvalidSession     = errors.New("session is revoked or expired")
 )
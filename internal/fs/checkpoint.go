@@ -0,0 +1,69 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// checkpointExpiration is the duration after which the checkpoints not updated are dropped,
+// the upload sessions of the drivers are likely expired
+const checkpointExpiration = 7 * 24 * time.Hour
+
+// getCheckpoint get the checkpoint of copying the src file to the dst dir,
+// it's nil if the dst storage can't resume the upload
+func getCheckpoint(srcStorage, dstStorage driver.Driver, srcFile model.Obj, srcFilePath, dstDirPath string) *model.UploadCheckpoint {
+	if _, ok := dstStorage.(driver.ResumablePut); !ok {
+		return nil
+	}
+	srcPath := utils.GetFullPath(srcStorage.GetStorage().MountPath, srcFilePath)
+	dstPath := utils.GetFullPath(dstStorage.GetStorage().MountPath, stdpath.Join(dstDirPath, srcFile.GetName()))
+	key := utils.GetSHA1Encode(fmt.Sprintf("%s\n%s\n%d\n%d", srcPath, dstPath, srcFile.GetSize(), srcFile.ModTime().UnixNano()))
+	if err := db.DeleteUploadCheckpointsBefore(time.Now().Add(-checkpointExpiration)); err != nil {
+		log.Warnf("failed delete expired upload checkpoints: %+v", err)
+	}
+	cp, err := db.GetUploadCheckpoint(key)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Warnf("failed get upload checkpoint of [%s]: %+v", dstPath, err)
+		}
+		cp = &model.UploadCheckpoint{
+			Key:     key,
+			SrcPath: srcPath,
+			DstPath: dstPath,
+			Size:    srcFile.GetSize(),
+		}
+	}
+	cp.SetSaver(func(cp *model.UploadCheckpoint) error {
+		// the upload goes on even if the checkpoint can't be saved, it just can't be resumed
+		if err := db.SaveUploadCheckpoint(cp); err != nil {
+			log.Warnf("failed save upload checkpoint of [%s]: %+v", cp.DstPath, err)
+		}
+		return nil
+	})
+	return cp
+}
+
+// dropCheckpoint delete the checkpoint, and abort the upload session if the upload is given up
+func dropCheckpoint(dstStorage driver.Driver, cp *model.UploadCheckpoint, abort bool) {
+	if abort && cp.Session != "" {
+		if err := dstStorage.(driver.ResumablePut).AbortResumable(context.Background(), cp); err != nil {
+			log.Warnf("failed abort upload session of [%s]: %+v", cp.DstPath, err)
+		}
+	}
+	if cp.ID == 0 {
+		return
+	}
+	if err := db.DeleteUploadCheckpointById(cp.ID); err != nil {
+		log.Warnf("failed delete upload checkpoint of [%s]: %+v", cp.DstPath, err)
+	}
+}
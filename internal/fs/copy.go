@@ -7,6 +7,7 @@ import (
 	"sync/atomic"
 
 	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/task"
@@ -21,27 +22,28 @@ var CopyTaskManager = task.NewTaskManager(3, func(tid *uint64) {
 
 // Copy if in the same storage, call move method
 // if not, add copy task
-func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool) (bool, error) {
+func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool) (*task.Task[uint64], error) {
 	srcStorage, srcObjActualPath, err := op.GetStorageAndActualPath(srcObjPath)
 	if err != nil {
-		return false, errors.WithMessage(err, "failed get src storage")
+		return nil, errors.WithMessage(err, "failed get src storage")
 	}
 	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
 	if err != nil {
-		return false, errors.WithMessage(err, "failed get dst storage")
+		return nil, errors.WithMessage(err, "failed get dst storage")
 	}
 	// copy if in the same storage, just call driver.Copy
 	if srcStorage.GetStorage() == dstStorage.GetStorage() {
-		return false, op.Copy(ctx, srcStorage, srcObjActualPath, dstDirActualPath, lazyCache...)
+		return nil, op.Copy(ctx, srcStorage, srcObjActualPath, dstDirActualPath, lazyCache...)
 	}
 	// not in the same storage
-	CopyTaskManager.Submit(task.WithCancelCtx(&task.Task[uint64]{
+	t := task.WithCancelCtx(&task.Task[uint64]{
 		Name: fmt.Sprintf("copy [%s](%s) to [%s](%s)", srcStorage.GetStorage().MountPath, srcObjActualPath, dstStorage.GetStorage().MountPath, dstDirActualPath),
 		Func: func(task *task.Task[uint64]) error {
 			return copyBetween2Storages(task, srcStorage, dstStorage, srcObjActualPath, dstDirActualPath)
 		},
-	}))
-	return true, nil
+	})
+	CopyTaskManager.Submit(t)
+	return t, nil
 }
 
 func copyBetween2Storages(t *task.Task[uint64], srcStorage, dstStorage driver.Driver, srcObjPath, dstDirPath string) error {
@@ -62,15 +64,16 @@ func copyBetween2Storages(t *task.Task[uint64], srcStorage, dstStorage driver.Dr
 			}
 			srcObjPath := stdpath.Join(srcObjPath, obj.GetName())
 			dstObjPath := stdpath.Join(dstDirPath, srcObj.GetName())
-			CopyTaskManager.Submit(task.WithCancelCtx(&task.Task[uint64]{
+			CopyTaskManager.SubmitChild(t, task.WithCancelCtx(&task.Task[uint64]{
 				Name: fmt.Sprintf("copy [%s](%s) to [%s](%s)", srcStorage.GetStorage().MountPath, srcObjPath, dstStorage.GetStorage().MountPath, dstObjPath),
 				Func: func(t *task.Task[uint64]) error {
 					return copyBetween2Storages(t, srcStorage, dstStorage, srcObjPath, dstObjPath)
 				},
 			}))
 		}
+		t.SetStatus(fmt.Sprintf("%d sub tasks submitted", len(objs)))
 	} else {
-		CopyTaskManager.Submit(task.WithCancelCtx(&task.Task[uint64]{
+		CopyTaskManager.SubmitChild(t, task.WithCancelCtx(&task.Task[uint64]{
 			Name: fmt.Sprintf("copy [%s](%s) to [%s](%s)", srcStorage.GetStorage().MountPath, srcObjPath, dstStorage.GetStorage().MountPath, dstDirPath),
 			Func: func(t *task.Task[uint64]) error {
 				err := copyFileBetween2Storages(t, srcStorage, dstStorage, srcObjPath, dstDirPath)
@@ -87,13 +90,53 @@ func copyFileBetween2Storages(tsk *task.Task[uint64], srcStorage, dstStorage dri
 	if err != nil {
 		return errors.WithMessagef(err, "failed get src [%s] file", srcFilePath)
 	}
+	tsk.SetTotalBytes(srcFile.GetSize())
+	cp := getCheckpoint(srcStorage, dstStorage, srcFile, srcFilePath, dstDirPath)
+	err = putFileFromCheckpoint(tsk, srcStorage, dstStorage, srcFile, srcFilePath, dstDirPath, cp)
+	if cp == nil {
+		return err
+	}
+	if errors.Is(err, errs.UploadSessionExpired) {
+		log.Warnf("the upload session of [%s] expired, restart from the beginning", cp.DstPath)
+		cp.Reset()
+		err = putFileFromCheckpoint(tsk, srcStorage, dstStorage, srcFile, srcFilePath, dstDirPath, cp)
+	}
+	if err == nil {
+		dropCheckpoint(dstStorage, cp, false)
+	} else if errors.Is(tsk.Ctx.Err(), context.Canceled) && tsk.GetOwnState() != task.PAUSING {
+		// canceled, the upload won't be resumed
+		dropCheckpoint(dstStorage, cp, true)
+	}
+	return err
+}
+
+// putFileFromCheckpoint put the src file from the offset of the checkpoint,
+// or from the beginning if the checkpoint is nil or the src doesn't support range
+func putFileFromCheckpoint(tsk *task.Task[uint64], srcStorage, dstStorage driver.Driver, srcFile model.Obj, srcFilePath, dstDirPath string, cp *model.UploadCheckpoint) error {
+	var offset int64
+	if cp != nil {
+		offset = cp.Offset
+	}
 	link, _, err := op.Link(tsk.Ctx, srcStorage, srcFilePath, model.LinkArgs{})
 	if err != nil {
 		return errors.WithMessagef(err, "failed get [%s] link", srcFilePath)
 	}
-	stream, err := getFileStreamFromLink(srcFile, link)
+	stream, start, err := getFileStreamFromLinkAt(tsk.Ctx, srcStorage, srcFilePath, srcFile, link, offset)
 	if err != nil {
 		return errors.WithMessagef(err, "failed get [%s] stream", srcFilePath)
 	}
+	if start != offset {
+		log.Warnf("the src [%s] doesn't support range, restart from the beginning", srcFilePath)
+		// the uploaded parts can't be continued, drop them before the session is reset
+		if cp.Session != "" {
+			if err := dstStorage.(driver.ResumablePut).AbortResumable(tsk.Ctx, cp); err != nil {
+				log.Warnf("failed abort upload session of [%s]: %+v", cp.DstPath, err)
+			}
+		}
+		cp.Reset()
+	}
+	tsk.SetDoneBytes(start)
+	stream.CountRead = tsk.AddDoneBytes
+	stream.Checkpoint = cp
 	return op.Put(tsk.Ctx, dstStorage, dstDirPath, stream, tsk.SetProgress, true)
 }
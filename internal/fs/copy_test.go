@@ -0,0 +1,165 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+// setupCopy create the src storage with a file, and the empty dst storage in dstDir
+func setupCopy(t *testing.T, name string) (src, dst driver.Driver, data []byte, dstDir string) {
+	conf.Conf.TempDir = t.TempDir()
+	srcDir := t.TempDir()
+	dstDir = t.TempDir()
+	data = make([]byte, 1024*1024)
+	_, _ = rand.Read(data)
+	if err := os.WriteFile(filepath.Join(srcDir, "f.bin"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []struct{ mount, dir string }{{"/" + name + "_src", srcDir}, {"/" + name + "_dst", dstDir}} {
+		_, err := op.CreateStorage(context.Background(), model.Storage{
+			Driver:    "Local",
+			MountPath: s.mount,
+			Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, s.dir),
+		})
+		if err != nil {
+			t.Fatalf("failed to create storage: %+v", err)
+		}
+	}
+	src, _ = op.GetStorageByMountPath("/" + name + "_src")
+	dst, _ = op.GetStorageByMountPath("/" + name + "_dst")
+	return src, dst, data, dstDir
+}
+
+// saveCheckpoint save the checkpoint as if the copy failed after the offset
+func saveCheckpoint(t *testing.T, src, dst driver.Driver, offset int64, partial string) *model.UploadCheckpoint {
+	srcFile, err := op.Get(context.Background(), src, "/f.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp := getCheckpoint(src, dst, srcFile, "/f.bin", "/")
+	if err = cp.Commit(offset, map[string]string{"path": partial}); err != nil {
+		t.Fatal(err)
+	}
+	return cp
+}
+
+func copyFile(t *testing.T, src, dst driver.Driver) {
+	tsk := task.WithCancelCtx(&task.Task[uint64]{})
+	if err := copyFileBetween2Storages(tsk, src, dst, "/f.bin", "/"); err != nil {
+		t.Fatalf("failed copy: %+v", err)
+	}
+	if done, total := tsk.GetBytes(); done != total {
+		t.Errorf("expect done bytes %d, got %d", total, done)
+	}
+}
+
+func TestCopy_Resume(t *testing.T) {
+	src, dst, data, dstDir := setupCopy(t, "resume")
+	dstPath := filepath.Join(dstDir, "f.bin")
+	// the committed bytes are different from the src, so they must be kept if resumed,
+	// and the bytes after the checkpoint must be dropped
+	const offset = 300 * 1024
+	partial := append(make([]byte, offset), bytes.Repeat([]byte{0xff}, 100)...)
+	if err := os.WriteFile(dstPath+".alist_partial", partial, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cp := saveCheckpoint(t, src, dst, offset, dstPath+".alist_partial")
+	copyFile(t, src, dst)
+	got, err := os.ReadFile(dstPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := append(make([]byte, offset), data[offset:]...)
+	if !bytes.Equal(got, expected) {
+		t.Errorf("expect the copy resumed from %d", offset)
+	}
+	if _, err := db.GetUploadCheckpoint(cp.Key); err == nil {
+		t.Errorf("expect the checkpoint deleted after the copy")
+	}
+}
+
+func TestCopy_ResumeExpired(t *testing.T) {
+	src, dst, data, dstDir := setupCopy(t, "expired")
+	dstPath := filepath.Join(dstDir, "f.bin")
+	// the partial file is lost, the copy is restarted
+	saveCheckpoint(t, src, dst, 300*1024, dstPath+".alist_partial")
+	copyFile(t, src, dst)
+	got, err := os.ReadFile(dstPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("expect the copy restarted")
+	}
+}
+
+func TestGetFileStreamFromLinkAt(t *testing.T) {
+	src, _, data, _ := setupCopy(t, "link")
+	srcFile, err := op.Get(context.Background(), src, "/f.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const offset = 1000
+	tests := []struct {
+		name         string
+		contentRange string
+		start        int64
+	}{
+		{name: "aligned", contentRange: fmt.Sprintf("bytes %d-%d/%d", offset, len(data)-1, len(data)), start: offset},
+		{name: "misaligned", contentRange: fmt.Sprintf("bytes 0-%d/%d", len(data)-1, len(data))},
+		{name: "missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Range") == "" {
+					_, _ = w.Write(data)
+					return
+				}
+				// the partial content is answered with the content range of the case
+				if tt.contentRange != "" {
+					w.Header().Set("Content-Range", tt.contentRange)
+				}
+				w.WriteHeader(http.StatusPartialContent)
+				_, _ = w.Write(data[tt.start:])
+			}))
+			defer server.Close()
+			stream, start, err := getFileStreamFromLinkAt(context.Background(), src, "/f.bin", srcFile, &model.Link{URL: server.URL}, offset)
+			if err != nil {
+				t.Fatalf("failed get stream: %+v", err)
+			}
+			defer stream.Close()
+			got, _ := io.ReadAll(stream)
+			if start != tt.start || !bytes.Equal(got, data[tt.start:]) {
+				t.Errorf("expect the stream start at %d, got %d", tt.start, start)
+			}
+		})
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 
 	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -29,6 +32,35 @@ func List(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 	return res, nil
 }
 
+type ListPageArgs struct {
+	Refresh bool
+	NoLog   bool
+	Page    int
+	PerPage int
+}
+
+// ListPage list only the page of the path if the storage support paged listing, more is true if there are more pages.
+// errs.NotImplement is returned if it's not supported, then the path should be listed by List
+func ListPage(ctx context.Context, path string, args *ListPageArgs) (objs []model.Obj, more bool, err error) {
+	objs, more, err = listPage(ctx, path, args)
+	if err != nil && !errors.Is(err, errs.NotImplement) {
+		if !args.NoLog {
+			log.Errorf("failed list page %d of %s: %+v", args.Page, path, err)
+		}
+		return nil, false, err
+	}
+	return objs, more, err
+}
+
+// WalkPages call fn with the pages of the path in order, so a huge dir can be streamed without loading it at once
+func WalkPages(ctx context.Context, path string, perPage int, fn func(objs []model.Obj) error) error {
+	err := walkPages(ctx, path, perPage, fn)
+	if err != nil {
+		log.Errorf("failed walk pages of %s: %+v", path, err)
+	}
+	return err
+}
+
 type GetArgs struct {
 	NoLog bool
 }
@@ -70,6 +102,12 @@ func Move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) er
 }
 
 func Copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool) (bool, error) {
+	t, err := CopyTask(ctx, srcObjPath, dstDirPath, lazyCache...)
+	return t != nil, err
+}
+
+// CopyTask is the same as Copy, but returns the task if it's copied by the task
+func CopyTask(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool) (*task.Task[uint64], error) {
 	res, err := _copy(ctx, srcObjPath, dstDirPath, lazyCache...)
 	if err != nil {
 		log.Errorf("failed copy %s to %s: %+v", srcObjPath, dstDirPath, err)
@@ -3,6 +3,7 @@ package fs
 import (
 	"context"
 
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -43,6 +44,52 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 	return objs, nil
 }
 
+// listPage list the page of the path by the paged listing of the storage,
+// errs.NotImplement is returned if the storage doesn't support it or the path has virtual files
+func listPage(ctx context.Context, path string, args *ListPageArgs) ([]model.Obj, bool, error) {
+	meta, _ := ctx.Value("meta").(*model.Meta)
+	user, _ := ctx.Value("user").(*model.User)
+	if len(op.GetStorageVirtualFilesByPath(path)) > 0 {
+		// the virtual files are merged into the whole listing
+		return nil, false, errs.NotImplement
+	}
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "failed get storage")
+	}
+	objs, more, err := op.ListPage(ctx, storage, actualPath, model.ListArgs{ReqPath: path}, args.Page, args.PerPage, args.Refresh)
+	if err != nil {
+		return nil, false, err
+	}
+	return hideObjs(user, meta, path, objs), more, nil
+}
+
+// walkPages call fn with the pages of the path in order, the path is listed as a whole if it has virtual files
+func walkPages(ctx context.Context, path string, perPage int, fn func(objs []model.Obj) error) error {
+	meta, _ := ctx.Value("meta").(*model.Meta)
+	user, _ := ctx.Value("user").(*model.User)
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil || len(op.GetStorageVirtualFilesByPath(path)) > 0 {
+		objs, err := list(ctx, path, &ListArgs{})
+		if err != nil {
+			return err
+		}
+		return fn(objs)
+	}
+	return op.WalkPages(ctx, storage, actualPath, model.ListArgs{ReqPath: path}, perPage, func(objs []model.Obj) error {
+		return fn(hideObjs(user, meta, path, objs))
+	})
+}
+
+func hideObjs(user *model.User, meta *model.Meta, path string, objs []model.Obj) []model.Obj {
+	if !whetherHide(user, meta, path) {
+		return objs
+	}
+	om := model.NewObjMerge()
+	om.InitHideReg(meta.Hide)
+	return om.Merge(objs)
+}
+
 func whetherHide(user *model.User, meta *model.Meta, path string) bool {
 	// if is admin, don't hide
 	if user == nil || user.CanSeeHides() {
@@ -8,8 +8,8 @@ import (
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/pkg/task"
-	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/pkg/errors"
 )
 
@@ -27,16 +27,17 @@ func putAsTask(dstDirPath string, file *model.FileStream) error {
 		return errors.WithStack(errs.UploadNotSupported)
 	}
 	if file.NeedStore() {
-		tempFile, err := utils.CreateTempFile(file)
-		if err != nil {
-			return errors.Wrapf(err, "failed to create temp file")
+		// the request is finished before the task runs, the hashes needed by the driver are computed meanwhile
+		if err := stream.Store(file, storage.Config().PreHash...); err != nil {
+			return err
 		}
-		file.SetReadCloser(tempFile)
 	}
 	UploadTaskManager.Submit(task.WithCancelCtx(&task.Task[uint64]{
 		Name: fmt.Sprintf("upload %s to [%s](%s)", file.GetName(), storage.GetStorage().MountPath, dstDirActualPath),
 		Func: func(task *task.Task[uint64]) error {
-			return op.Put(task.Ctx, storage, dstDirActualPath, file, nil, true)
+			task.SetTotalBytes(file.GetSize())
+			file.CountRead = task.AddDoneBytes
+			return op.Put(task.Ctx, storage, dstDirActualPath, file, task.SetProgress, true)
 		},
 	}))
 	return nil
@@ -1,6 +1,7 @@
 package fs
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,12 +10,16 @@ import (
 	"strings"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/downloader"
+	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/http_range"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 func ClearCache(path string) {
@@ -34,34 +39,84 @@ func containsByName(files []model.Obj, file model.Obj) bool {
 	return false
 }
 
-func getFileStreamFromLink(file model.Obj, link *model.Link) (*model.FileStream, error) {
+// getFileStreamFromLinkAt get the stream starting at the offset,
+// the stream starts at the returned offset, which is 0 if the link doesn't support range.
+// The url is downloaded by the concurrent range requests if the storage enable it
+func getFileStreamFromLinkAt(ctx context.Context, storage driver.Driver, path string, file model.Obj, link *model.Link, offset int64) (*model.FileStream, int64, error) {
 	var rc io.ReadCloser
 	mimetype := utils.GetMimeType(file.GetName())
-	if link.Data != nil {
+	if offset > 0 && offset >= file.GetSize() {
+		// all the bytes are committed, the upload only needs to be completed
+		rc, offset = http.NoBody, file.GetSize()
+	} else if link.RangeReader != nil {
+		var err error
+		rc, err = link.RangeReader.RangeRead(ctx, http_range.Range{Start: offset, Length: -1})
+		if err != nil {
+			return nil, 0, errors.WithMessagef(err, "failed to read from %d", offset)
+		}
+	} else if link.Data != nil {
 		rc = link.Data
+		if offset > 0 {
+			if s, ok := link.Data.(io.Seeker); ok {
+				if _, err := s.Seek(offset, io.SeekStart); err != nil {
+					return nil, 0, errors.Wrapf(err, "failed to seek to %d", offset)
+				}
+			} else {
+				offset = 0
+			}
+		}
 	} else if link.FilePath != nil {
 		// create a new temp symbolic link, because it will be deleted after upload
 		newFilePath := stdpath.Join(conf.Conf.TempDir, fmt.Sprintf("%s-%s", uuid.NewString(), file.GetName()))
 		err := utils.SymlinkOrCopyFile(*link.FilePath, newFilePath)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		f, err := os.Open(newFilePath)
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed to open file %s", *link.FilePath)
+			return nil, 0, errors.Wrapf(err, "failed to open file %s", *link.FilePath)
+		}
+		if offset > 0 {
+			if _, err := f.Seek(offset, io.SeekStart); err != nil {
+				_ = f.Close()
+				_ = os.Remove(newFilePath)
+				return nil, 0, errors.Wrapf(err, "failed to seek file %s", *link.FilePath)
+			}
 		}
 		rc = f
+	} else if drc := downloadLink(ctx, storage, path, file, link, offset); drc != nil {
+		rc = drc
 	} else {
-		req, err := http.NewRequest(http.MethodGet, link.URL, nil)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.URL, nil)
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed to create request for %s", link.URL)
+			return nil, 0, errors.Wrapf(err, "failed to create request for %s", link.URL)
 		}
 		for h, val := range link.Header {
 			req.Header[h] = val
 		}
+		if offset > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		}
 		res, err := common.HttpClient().Do(req)
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed to get response for %s", link.URL)
+			return nil, 0, errors.Wrapf(err, "failed to get response for %s", link.URL)
+		}
+		if offset > 0 && res.StatusCode != http.StatusPartialContent {
+			if res.StatusCode != http.StatusOK {
+				// the range is rejected, request the whole file
+				_ = res.Body.Close()
+				return getFileStreamFromLinkAt(ctx, storage, path, file, link, 0)
+			}
+			// the range is ignored, the whole file is returned
+			offset = 0
+		} else if offset > 0 {
+			// the partial content is used only if it starts at the offset
+			ra, _, err := http_range.ParseContentRange(res.Header.Get("Content-Range"))
+			if err != nil || ra.Start != offset {
+				_ = res.Body.Close()
+				log.Warnf("the content range [%s] of [%s] doesn't start at %d, request the whole file", res.Header.Get("Content-Range"), path, offset)
+				return getFileStreamFromLinkAt(ctx, storage, path, file, link, 0)
+			}
 		}
 		mt := res.Header.Get("Content-Type")
 		if mt != "" && strings.ToLower(mt) != "application/octet-stream" {
@@ -78,5 +133,26 @@ func getFileStreamFromLink(file model.Obj, link *model.Link) (*model.FileStream,
 		ReadCloser: rc,
 		Mimetype:   mimetype,
 	}
-	return stream, nil
+	return stream, offset, nil
+}
+
+// downloadLink download the url from the offset by the concurrent range requests,
+// nil is returned if the storage doesn't enable it or it fails, then the url should be requested directly
+func downloadLink(ctx context.Context, storage driver.Driver, path string, file model.Obj, link *model.Link, offset int64) io.ReadCloser {
+	opts, ok := downloader.StorageOptions(storage.GetStorage())
+	if !ok || link.URL == "" || file.GetSize() <= offset {
+		return nil
+	}
+	rc, err := downloader.Download(ctx, downloader.Req{
+		Link:    link,
+		Refresh: downloader.LinkRefresher(stdpath.Join(storage.GetStorage().MountPath, path), model.LinkArgs{}),
+		Offset:  offset,
+		Length:  file.GetSize() - offset,
+		Options: opts,
+	})
+	if err != nil {
+		log.Warnf("failed download [%s] concurrently, fallback to a single request: %+v", path, err)
+		return nil
+	}
+	return rc
 }
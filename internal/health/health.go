@@ -0,0 +1,228 @@
+// Package health probe the loaded storages periodically,
+// reload the broken ones with backoff and record their status changes
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/generic_sync"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	maxBackoff   = time.Hour
+	probeTimeout = time.Minute
+	// keep status history of the last 30 days
+	historyKeep = 30 * 24 * time.Hour
+)
+
+type State struct {
+	StorageID uint      `json:"storage_id"`
+	MountPath string    `json:"mount_path"`
+	Status    string    `json:"status"`
+	Failures  int       `json:"failures"`
+	LastCheck time.Time `json:"last_check"`
+	NextCheck time.Time `json:"next_check"`
+}
+
+var (
+	states generic_sync.MapOf[uint, *State]
+	// statesMu guard the fields of the states, they are read by the handlers while checking
+	statesMu sync.RWMutex
+	checking sync.Mutex
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+)
+
+// Start (re)start the health checker with the interval in setting, 0 means disabled
+func Start() {
+	interval := setting.GetInt(conf.StorageHealthInterval, 0)
+	if interval <= 0 {
+		Stop()
+		log.Infof("storage health check disabled")
+		return
+	}
+	start(time.Duration(interval) * time.Minute)
+}
+
+func start(interval time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+	stop()
+	ctx, cancelFn := context.WithCancel(context.Background())
+	cancel = cancelFn
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				CheckAll(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop the health checker, the running check is canceled
+func Stop() {
+	mu.Lock()
+	defer mu.Unlock()
+	stop()
+}
+
+func stop() {
+	if cancel != nil {
+		cancel()
+		cancel = nil
+	}
+}
+
+func init() {
+	op.RegisterSettingItemHook(conf.StorageHealthInterval, func(item *model.SettingItem) error {
+		// the hook is also called while initializing settings, the checker is started after storages loaded
+		if conf.StoragesLoaded {
+			go Start()
+		}
+		return nil
+	})
+}
+
+// GetStates returns the copies of the states
+func GetStates() []State {
+	statesMu.RLock()
+	defer statesMu.RUnlock()
+	res := make([]State, 0)
+	for _, state := range states.Values() {
+		res = append(res, *state)
+	}
+	return res
+}
+
+// CheckAll probe all loaded storages that are not in backoff
+func CheckAll(ctx context.Context) {
+	if !conf.StoragesLoaded {
+		return
+	}
+	if !checking.TryLock() {
+		log.Debugf("last storage health check is still running")
+		return
+	}
+	defer checking.Unlock()
+	interval := time.Duration(setting.GetInt(conf.StorageHealthInterval, 10)) * time.Minute
+	loaded := make(map[uint]struct{})
+	for _, storage := range op.GetAllStorages() {
+		if ctx.Err() != nil {
+			return
+		}
+		s := storage.GetStorage()
+		loaded[s.ID] = struct{}{}
+		state, _ := states.LoadOrStore(s.ID, &State{
+			StorageID: s.ID,
+			MountPath: s.MountPath,
+			Status:    s.Status,
+		})
+		statesMu.RLock()
+		next := state.NextCheck
+		statesMu.RUnlock()
+		if time.Now().Before(next) {
+			continue
+		}
+		check(ctx, storage, state, interval)
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	// forget the removed storages
+	states.Range(func(id uint, state *State) bool {
+		if _, ok := loaded[id]; !ok {
+			states.Delete(id)
+		}
+		return true
+	})
+	if err := db.DeleteStorageStatusesBefore(time.Now().Add(-historyKeep)); err != nil {
+		log.Errorf("failed clean storage status history: %+v", err)
+	}
+}
+
+func check(ctx context.Context, storage driver.Driver, state *State, interval time.Duration) {
+	s := storage.GetStorage()
+	pctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	err := op.PingStorage(pctx, storage)
+	cancel()
+	if err != nil && ctx.Err() == nil {
+		log.Warnf("storage [%s] health check failed: %+v, reloading", s.MountPath, err)
+		rctx, cancel := context.WithTimeout(ctx, probeTimeout)
+		err = op.ReloadStorage(rctx, s.ID)
+		cancel()
+		if err == nil {
+			log.Infof("storage [%s] reloaded", s.MountPath)
+		}
+	}
+	if ctx.Err() != nil {
+		// canceled by stop, the storage is not broken
+		return
+	}
+	status := op.WORK
+	if err != nil {
+		if reloaded, e := op.GetStorageByMountPath(s.MountPath); e == nil {
+			s = reloaded.GetStorage()
+		}
+		status = s.Status
+		if status == op.WORK {
+			status = err.Error()
+		}
+	}
+	statesMu.Lock()
+	state.LastCheck = time.Now()
+	state.MountPath = s.MountPath
+	if err == nil {
+		state.Failures = 0
+		state.NextCheck = time.Time{}
+	} else {
+		state.Failures++
+		state.NextCheck = time.Now().Add(backoff(interval, state.Failures))
+	}
+	old := state.Status
+	state.Status = status
+	copied := *state
+	statesMu.Unlock()
+	if old != status {
+		recordStatus(old, &copied)
+	}
+}
+
+// backoff double the interval for every failure, capped at maxBackoff
+func backoff(interval time.Duration, failures int) time.Duration {
+	d := interval
+	for i := 1; i < failures && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
+
+// recordStatus save the status changed to the history and notify it
+func recordStatus(old string, state *State) {
+	record := &model.StorageStatus{
+		StorageID: state.StorageID,
+		MountPath: state.MountPath,
+		Status:    state.Status,
+		Time:      time.Now(),
+	}
+	if err := db.CreateStorageStatus(record); err != nil {
+		log.Errorf("failed save storage status: %+v", err)
+	}
+	go notify(old, record)
+}
@@ -0,0 +1,192 @@
+package health
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var (
+	broken  atomic.Bool
+	block   atomic.Bool
+	blocked = make(chan struct{}, 1)
+)
+
+// probe is a storage whose ping fails while broken, and blocks until canceled while block
+type probe struct {
+	model.Storage
+	driver.RootID
+}
+
+func (d *probe) Config() driver.Config {
+	return driver.Config{Name: "HealthProbe", NoCache: true}
+}
+
+func (d *probe) GetAddition() driver.Additional {
+	return &d.RootID
+}
+
+func (d *probe) Init(ctx context.Context) error {
+	if broken.Load() {
+		return errors.New("broken")
+	}
+	return nil
+}
+
+func (d *probe) Drop(ctx context.Context) error {
+	return nil
+}
+
+func (d *probe) Ping(ctx context.Context) error {
+	if block.Load() {
+		select {
+		case blocked <- struct{}{}:
+		default:
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if broken.Load() {
+		return errors.New("broken")
+	}
+	return nil
+}
+
+func (d *probe) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	return nil, nil
+}
+
+func (d *probe) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	return nil, errors.New("not implement")
+}
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+	op.RegisterDriver(func() driver.Driver {
+		return &probe{}
+	})
+	conf.StoragesLoaded = true
+}
+
+func getState(t *testing.T, id uint) State {
+	t.Helper()
+	for _, state := range GetStates() {
+		if state.StorageID == id {
+			return state
+		}
+	}
+	t.Fatalf("expect the state of storage %d, got %+v", id, GetStates())
+	return State{}
+}
+
+func TestCheckAll(t *testing.T) {
+	id, err := op.CreateStorage(context.Background(), model.Storage{Driver: "HealthProbe", MountPath: "/probe", Addition: `{}`})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	defer func() {
+		_ = op.DeleteStorageById(context.Background(), id)
+	}()
+	ctx := context.Background()
+
+	CheckAll(ctx)
+	if state := getState(t, id); state.Status != op.WORK || state.Failures != 0 || state.LastCheck.IsZero() {
+		t.Errorf("expect the storage work, got %+v", state)
+	}
+
+	// the broken storage failed to reload, and is not checked again until backoff
+	broken.Store(true)
+	CheckAll(ctx)
+	state := getState(t, id)
+	if state.Status == op.WORK || state.Failures != 1 || !state.NextCheck.After(time.Now()) {
+		t.Errorf("expect the storage broken with backoff, got %+v", state)
+	}
+	CheckAll(ctx)
+	if got := getState(t, id); got.Failures != 1 || !got.LastCheck.Equal(state.LastCheck) {
+		t.Errorf("expect the storage skipped while backoff, got %+v", got)
+	}
+	statuses, _, err := db.GetStorageStatuses(id, 1, 10)
+	if err != nil || len(statuses) == 0 || statuses[0].Status != state.Status {
+		t.Errorf("expect the status change recorded, got %+v %v", statuses, err)
+	}
+
+	// the states returned are copies
+	state.Status = "changed"
+	if getState(t, id).Status == "changed" {
+		t.Errorf("expect the states copied")
+	}
+
+	// the storage is reloaded once it's fixed
+	broken.Store(false)
+	statesMu.Lock()
+	s, _ := states.Load(id)
+	s.NextCheck = time.Time{}
+	statesMu.Unlock()
+	CheckAll(ctx)
+	if state := getState(t, id); state.Status != op.WORK || state.Failures != 0 || !state.NextCheck.IsZero() {
+		t.Errorf("expect the storage reloaded, got %+v", state)
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	id, err := op.CreateStorage(context.Background(), model.Storage{Driver: "HealthProbe", MountPath: "/probe_block", Addition: `{}`})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	defer func() {
+		_ = op.DeleteStorageById(context.Background(), id)
+	}()
+	block.Store(true)
+	defer block.Store(false)
+
+	start(time.Millisecond)
+	select {
+	case <-blocked:
+	case <-time.After(5 * time.Second):
+		Stop()
+		t.Fatalf("expect the storage checked")
+	}
+
+	// stop return at once, and cancel the running check
+	done := make(chan struct{})
+	go func() {
+		Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("expect stop not wait for the running check")
+	}
+	for i := 0; ; i++ {
+		if checking.TryLock() {
+			checking.Unlock()
+			break
+		}
+		if i > 100 {
+			t.Fatalf("expect the running check canceled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// the canceled check doesn't mark the storage broken
+	if state := getState(t, id); state.Status != op.WORK || state.Failures != 0 {
+		t.Errorf("expect the storage not broken by cancel, got %+v", state)
+	}
+	// stop twice is fine
+	Stop()
+}
@@ -0,0 +1,51 @@
+package health
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	log "github.com/sirupsen/logrus"
+)
+
+type Event struct {
+	Event     string    `json:"event"`
+	StorageID uint      `json:"storage_id"`
+	MountPath string    `json:"mount_path"`
+	OldStatus string    `json:"old_status"`
+	Status    string    `json:"status"`
+	Time      time.Time `json:"time"`
+}
+
+// notify post the status change to the webhook in setting
+func notify(old string, record *model.StorageStatus) {
+	url := setting.GetStr(conf.StorageStatusWebhook)
+	if url == "" {
+		return
+	}
+	body, err := utils.Json.Marshal(Event{
+		Event:     "storage_status_changed",
+		StorageID: record.StorageID,
+		MountPath: record.MountPath,
+		OldStatus: old,
+		Status:    record.Status,
+		Time:      record.Time,
+	})
+	if err != nil {
+		log.Errorf("failed marshal storage status event: %+v", err)
+		return
+	}
+	res, err := common.HttpClient().Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Errorf("failed post storage status webhook: %+v", err)
+		return
+	}
+	_ = res.Body.Close()
+	if res.StatusCode >= 400 {
+		log.Errorf("storage status webhook respond status %d", res.StatusCode)
+	}
+}
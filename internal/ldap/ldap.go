@@ -0,0 +1,221 @@
+// Package ldap authenticate the users by binding to the ldap server,
+// and provision them on first login with the permissions mapped from their groups
+package ldap
+
+import (
+	"crypto/tls"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// Source is the source of the users provisioned by ldap
+const Source = "ldap"
+
+const timeout = 10 * time.Second
+
+// binds cache the groups of the successful binds by the credentials for a while,
+// so the clients sending the password in every request, such as webdav, don't bind every time
+var binds = cache.NewMemCache(cache.WithShards[[]string](16))
+
+const bindExpiration = time.Minute
+
+type Config struct {
+	Server             string
+	ManagerDN          string
+	ManagerPassword    string
+	UserSearchBase     string
+	UserSearchFilter   string
+	GroupAttribute     string
+	GroupMapping       []model.GroupMapping // the groups are matched by dn or cn
+	DefaultPermission  int32
+	DefaultBasePath    string
+	InsecureSkipVerify bool
+}
+
+func Enabled() bool {
+	return setting.GetBool(conf.LdapLoginEnabled)
+}
+
+func getConfig() (*Config, error) {
+	cfg := &Config{
+		Server:             setting.GetStr(conf.LdapServer),
+		ManagerDN:          setting.GetStr(conf.LdapManagerDN),
+		ManagerPassword:    setting.GetStr(conf.LdapManagerPassword),
+		UserSearchBase:     setting.GetStr(conf.LdapUserSearchBase),
+		UserSearchFilter:   setting.GetStr(conf.LdapUserSearchFilter),
+		GroupAttribute:     setting.GetStr(conf.LdapGroupAttribute),
+		DefaultPermission:  int32(setting.GetInt(conf.LdapDefaultPermission, 0)),
+		DefaultBasePath:    setting.GetStr(conf.LdapDefaultBasePath),
+		InsecureSkipVerify: conf.Conf.TlsInsecureSkipVerify,
+	}
+	if mapping := setting.GetStr(conf.LdapGroupMapping); mapping != "" {
+		if err := utils.Json.UnmarshalFromString(mapping, &cfg.GroupMapping); err != nil {
+			return nil, errors.Wrapf(err, "invalid %s", conf.LdapGroupMapping)
+		}
+	}
+	return cfg, nil
+}
+
+// Authenticate validate the password of the user, the ldap users and the unknown users
+// are authenticated by the ldap server if it's enabled, others by the local password
+func Authenticate(username, password string) (*model.User, error) {
+	user, err := op.GetUserByName(username)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if Enabled() && (user == nil || user.Source == Source) {
+		return Login(username, password)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := user.ValidatePassword(password); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Login bind to the ldap server with the credentials, then create or update the user
+func Login(username, password string) (*model.User, error) {
+	cfg, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+	groups, err := cfg.cachedBind(username, password)
+	if err != nil {
+		return nil, err
+	}
+	mapped := cfg.mapUser(username, groups)
+	user, err := op.GetUserByName(username)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if err := op.CreateUser(&mapped); err != nil {
+			return nil, err
+		}
+		return &mapped, nil
+	}
+	if user.Source != Source {
+		return nil, errors.Errorf("user %s is not an ldap user", username)
+	}
+	// sync the permissions with the groups on every login
+	if user.Role != mapped.Role || user.Permission != mapped.Permission || user.BasePath != mapped.BasePath {
+		updated := *user
+		updated.Role, updated.Permission, updated.BasePath = mapped.Role, mapped.Permission, mapped.BasePath
+		if err := op.UpdateUser(&updated); err != nil {
+			return nil, err
+		}
+		return &updated, nil
+	}
+	return user, nil
+}
+
+func (cfg *Config) dial() (*goldap.Conn, error) {
+	conn, err := goldap.DialURL(cfg.Server,
+		goldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		goldap.DialWithTLSConfig(&tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed connect to ldap server")
+	}
+	conn.SetTimeout(timeout)
+	return conn, nil
+}
+
+// cachedBind returns the groups of the successful bind with the credentials in a minute, or bind again
+func (cfg *Config) cachedBind(username, password string) ([]string, error) {
+	key := utils.GetSHA256Encode(username + "\x00" + password)
+	if groups, ok := binds.Get(key); ok {
+		return groups, nil
+	}
+	groups, err := cfg.bind(username, password)
+	if err != nil {
+		return nil, err
+	}
+	binds.Set(key, groups, cache.WithEx[[]string](bindExpiration))
+	return groups, nil
+}
+
+// bind search the user and bind with the password, returns the groups of the user
+func (cfg *Config) bind(username, password string) ([]string, error) {
+	// an empty password means unauthenticated bind, which always succeeds
+	if password == "" {
+		return nil, errors.WithStack(errs.EmptyPassword)
+	}
+	conn, err := cfg.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if cfg.ManagerDN != "" {
+		if err := conn.Bind(cfg.ManagerDN, cfg.ManagerPassword); err != nil {
+			return nil, errors.Wrapf(err, "failed bind with manager dn")
+		}
+	}
+	filter := strings.ReplaceAll(cfg.UserSearchFilter, "{username}", goldap.EscapeFilter(username))
+	res, err := conn.Search(goldap.NewSearchRequest(cfg.UserSearchBase,
+		goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 2, int(timeout.Seconds()), false,
+		filter, []string{"dn", cfg.GroupAttribute}, nil))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed search user")
+	}
+	if len(res.Entries) != 1 {
+		return nil, errors.Errorf("found %d ldap users of %s", len(res.Entries), username)
+	}
+	entry := res.Entries[0]
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials) {
+			return nil, errors.WithStack(errs.WrongPassword)
+		}
+		return nil, errors.Wrapf(err, "failed bind with user dn")
+	}
+	return entry.GetAttributeValues(cfg.GroupAttribute), nil
+}
+
+// mapUser build the user with the role, permission and base path mapped from the groups
+func (cfg *Config) mapUser(username string, groups []string) model.User {
+	user := model.User{
+		Username: username,
+		Source:   Source,
+	}
+	user.ApplyGroupMapping(cfg.GroupMapping, func(group string) bool {
+		return inGroups(group, groups)
+	}, cfg.DefaultPermission, cfg.DefaultBasePath)
+	return user
+}
+
+// inGroups check if the group, in dn or cn, is one of the groups in dn
+func inGroups(group string, groups []string) bool {
+	groupDN, err := goldap.ParseDN(group)
+	isDN := err == nil && len(groupDN.RDNs) > 0 && strings.Contains(group, "=")
+	for _, g := range groups {
+		dn, err := goldap.ParseDN(g)
+		if err != nil {
+			if strings.EqualFold(g, group) {
+				return true
+			}
+			continue
+		}
+		if isDN {
+			if groupDN.EqualFold(dn) {
+				return true
+			}
+		} else if len(dn.RDNs) > 0 && len(dn.RDNs[0].Attributes) > 0 &&
+			strings.EqualFold(dn.RDNs[0].Attributes[0].Value, group) {
+			return true
+		}
+	}
+	return false
+}
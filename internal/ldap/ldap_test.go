@@ -0,0 +1,125 @@
+package ldap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	ldapserver "github.com/vjeantet/ldapserver"
+)
+
+var accounts = map[string]string{
+	"cn=admin,dc=example,dc=com":           "secret",
+	"uid=alice,ou=users,dc=example,dc=com": "alice-pwd",
+}
+
+func startServer(t *testing.T) string {
+	ldapserver.Logger = ldapserver.DiscardingLogger
+	routes := ldapserver.NewRouteMux()
+	routes.Bind(func(w ldapserver.ResponseWriter, m *ldapserver.Message) {
+		r := m.GetBindRequest()
+		res := ldapserver.NewBindResponse(ldapserver.LDAPResultSuccess)
+		if pwd, ok := accounts[string(r.Name())]; !ok || pwd != string(r.AuthenticationSimple()) {
+			res.SetResultCode(ldapserver.LDAPResultInvalidCredentials)
+		}
+		w.Write(res)
+	})
+	routes.Search(func(w ldapserver.ResponseWriter, m *ldapserver.Message) {
+		r := m.GetSearchRequest()
+		if strings.Contains(r.FilterString(), "alice") {
+			e := ldapserver.NewSearchResultEntry("uid=alice,ou=users,dc=example,dc=com")
+			e.AddAttribute("memberOf", "cn=staff,ou=groups,dc=example,dc=com", "cn=Editors,ou=groups,dc=example,dc=com")
+			w.Write(e)
+		}
+		w.Write(ldapserver.NewSearchResultDoneResponse(ldapserver.LDAPResultSuccess))
+	})
+	server := ldapserver.NewServer()
+	server.Handle(routes)
+	addr := make(chan string)
+	go func() {
+		_ = server.ListenAndServe("127.0.0.1:0", func(s *ldapserver.Server) {
+			addr <- s.Listener.Addr().String()
+		})
+	}()
+	t.Cleanup(server.Stop)
+	return "ldap://" + <-addr
+}
+
+func TestBind(t *testing.T) {
+	cfg := &Config{
+		Server:           startServer(t),
+		ManagerDN:        "cn=admin,dc=example,dc=com",
+		ManagerPassword:  "secret",
+		UserSearchBase:   "ou=users,dc=example,dc=com",
+		UserSearchFilter: "(&(objectClass=person)(uid={username}))",
+		GroupAttribute:   "memberOf",
+	}
+	groups, err := cfg.bind("alice", "alice-pwd")
+	if err != nil {
+		t.Fatalf("failed bind: %+v", err)
+	}
+	if len(groups) != 2 {
+		t.Errorf("expect 2 groups, got %v", groups)
+	}
+	if _, err := cfg.bind("alice", "wrong"); !errors.Is(err, errs.WrongPassword) {
+		t.Errorf("expect wrong password, got %+v", err)
+	}
+	if _, err := cfg.bind("alice", ""); !errors.Is(err, errs.EmptyPassword) {
+		t.Errorf("expect empty password, got %+v", err)
+	}
+	if _, err := cfg.bind("bob", "pwd"); err == nil {
+		t.Errorf("unknown user should not be bound")
+	}
+	cfg.ManagerPassword = "wrong"
+	if _, err := cfg.bind("alice", "alice-pwd"); err == nil {
+		t.Errorf("wrong manager password should fail")
+	}
+}
+
+func TestCachedBind(t *testing.T) {
+	cfg := &Config{
+		Server:           startServer(t),
+		UserSearchBase:   "ou=users,dc=example,dc=com",
+		UserSearchFilter: "(&(objectClass=person)(uid={username}))",
+		GroupAttribute:   "memberOf",
+	}
+	if _, err := cfg.cachedBind("alice", "alice-pwd"); err != nil {
+		t.Fatalf("failed bind: %+v", err)
+	}
+	// the successful bind is cached, the others are not
+	cfg.Server = "ldap://127.0.0.1:1"
+	if groups, err := cfg.cachedBind("alice", "alice-pwd"); err != nil || len(groups) != 2 {
+		t.Errorf("expect the cached bind, got %v %+v", groups, err)
+	}
+	if _, err := cfg.cachedBind("alice", "wrong"); err == nil {
+		t.Errorf("wrong password should not be cached")
+	}
+}
+
+func TestMapUser(t *testing.T) {
+	cfg := &Config{
+		GroupMapping: []model.GroupMapping{
+			{Group: "cn=Staff,ou=groups,dc=example,dc=com", Permission: 1 << 8, BasePath: "/home/{username}"},
+			{Group: "editors", Permission: 1 << 3, BasePath: "/shared"},
+			{Group: "admins", Permission: 1 << 9},
+		},
+		DefaultPermission: 1,
+		DefaultBasePath:   "/public",
+	}
+	groups := []string{"cn=staff,ou=groups,dc=example,dc=com", "cn=Editors,ou=groups,dc=example,dc=com"}
+	user := cfg.mapUser("alice", groups)
+	if user.Role != model.GENERAL || user.Permission != 1<<8|1<<3 || user.BasePath != "/home/alice" || user.Source != Source {
+		t.Errorf("unexpected mapped user: %+v", user)
+	}
+	user = cfg.mapUser("bob", nil)
+	if user.Permission != 1 || user.BasePath != "/public" {
+		t.Errorf("expect the default permission and base path, got %+v", user)
+	}
+	user = cfg.mapUser("carol", []string{"cn=admins,ou=groups,dc=example,dc=com"})
+	// the admin can't be mapped, there is only one
+	if user.Role != model.GENERAL || user.Permission != 1<<9 {
+		t.Errorf("expect general user, got %+v", user)
+	}
+}
@@ -0,0 +1,107 @@
+package listcache
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var bucketName = []byte("list_cache")
+
+// boltStore persist the listings in an embedded bolt file of the local node,
+// a value is the unix nano time it expires followed by the data
+type boltStore struct {
+	db *bolt.DB
+}
+
+func NewBoltStore(file string) (Store, error) {
+	if err := os.MkdirAll(filepath.Dir(file), 0o777); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	db, err := bolt.Open(file, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed open list cache file %s", file)
+	}
+	s := &boltStore{db: db}
+	if err = s.db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketName)
+		return err
+	}); err != nil {
+		_ = db.Close()
+		return nil, errors.WithStack(err)
+	}
+	if err = s.delExpired(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *boltStore) Get(key string) ([]byte, time.Duration, bool, error) {
+	var data []byte
+	var ttl time.Duration
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucketName).Get([]byte(key))
+		if len(v) < 8 {
+			return nil
+		}
+		ttl = time.Until(time.Unix(0, int64(binary.BigEndian.Uint64(v))))
+		if ttl <= 0 {
+			return nil
+		}
+		// the value is only valid in the transaction
+		data = append([]byte(nil), v[8:]...)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, false, errors.WithStack(err)
+	}
+	return data, ttl, data != nil, nil
+}
+
+func (s *boltStore) Set(key string, data []byte, ttl time.Duration) error {
+	v := make([]byte, 8+len(data))
+	binary.BigEndian.PutUint64(v, uint64(time.Now().Add(ttl).UnixNano()))
+	copy(v[8:], data)
+	return errors.WithStack(s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketName).Put([]byte(key), v)
+	}))
+}
+
+func (s *boltStore) Del(key string) error {
+	return errors.WithStack(s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketName).Delete([]byte(key))
+	}))
+}
+
+// delExpired drop the listings expired before the start
+func (s *boltStore) delExpired() error {
+	now := uint64(time.Now().UnixNano())
+	return errors.WithStack(s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		var expired [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			if len(v) < 8 || binary.BigEndian.Uint64(v) < now {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
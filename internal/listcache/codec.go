@@ -0,0 +1,85 @@
+package listcache
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	kindObject = iota
+	kindThumb
+	kindURL
+	kindThumbURL
+)
+
+// cachedObj is the stored form of the plain objs of the model package
+type cachedObj struct {
+	Kind     int       `json:"k,omitempty"`
+	ID       string    `json:"i,omitempty"`
+	Path     string    `json:"p,omitempty"`
+	Name     string    `json:"n"`
+	Size     int64     `json:"s,omitempty"`
+	Modified time.Time `json:"m"`
+	IsFolder bool      `json:"d,omitempty"`
+	Thumb    string    `json:"t,omitempty"`
+	URL      string    `json:"u,omitempty"`
+}
+
+// errNotPlain is returned if the objs of the driver can't be restored from the stored form,
+// these listings are only kept in memory
+var errNotPlain = errors.New("the objs are not plain objs")
+
+func encode(objs []model.Obj) ([]byte, error) {
+	res := make([]cachedObj, 0, len(objs))
+	for _, obj := range objs {
+		var c cachedObj
+		switch o := model.UnwrapObj(obj).(type) {
+		case *model.Object:
+			c = fromObject(kindObject, o)
+		case *model.ObjThumb:
+			c = fromObject(kindThumb, &o.Object)
+			c.Thumb = o.Thumbnail.Thumbnail
+		case *model.ObjectURL:
+			c = fromObject(kindURL, &o.Object)
+			c.URL = o.Url.Url
+		case *model.ObjThumbURL:
+			c = fromObject(kindThumbURL, &o.Object)
+			c.Thumb, c.URL = o.Thumbnail.Thumbnail, o.Url.Url
+		default:
+			return nil, errNotPlain
+		}
+		res = append(res, c)
+	}
+	return utils.Json.Marshal(res)
+}
+
+func fromObject(kind int, o *model.Object) cachedObj {
+	return cachedObj{Kind: kind, ID: o.ID, Path: o.Path, Name: o.Name, Size: o.Size, Modified: o.Modified, IsFolder: o.IsFolder}
+}
+
+func decode(data []byte) ([]model.Obj, error) {
+	var cached []cachedObj
+	if err := utils.Json.Unmarshal(data, &cached); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	objs := make([]model.Obj, 0, len(cached))
+	for _, c := range cached {
+		o := model.Object{ID: c.ID, Path: c.Path, Name: c.Name, Size: c.Size, Modified: c.Modified, IsFolder: c.IsFolder}
+		switch c.Kind {
+		case kindThumb:
+			objs = append(objs, &model.ObjThumb{Object: o, Thumbnail: model.Thumbnail{Thumbnail: c.Thumb}})
+		case kindURL:
+			objs = append(objs, &model.ObjectURL{Object: o, Url: model.Url{Url: c.URL}})
+		case kindThumbURL:
+			objs = append(objs, &model.ObjThumbURL{Object: o, Thumbnail: model.Thumbnail{Thumbnail: c.Thumb}, Url: model.Url{Url: c.URL}})
+		default:
+			objs = append(objs, &o)
+		}
+	}
+	// the names are mapped as op.List does
+	model.WrapObjsName(objs)
+	return objs, nil
+}
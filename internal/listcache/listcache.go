@@ -0,0 +1,75 @@
+package listcache
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+// Backend store the listings of the dirs by the keys, a listing is dropped once the ttl expires
+type Backend interface {
+	Get(key string) ([]model.Obj, bool)
+	Set(key string, objs []model.Obj, ttl time.Duration)
+	Del(key string)
+	// Publish tell the other nodes sharing the backend that the listing is changed,
+	// so they drop the listing in their memory
+	Publish(key string)
+	// Subscribe call fn with the keys changed by the other nodes
+	Subscribe(fn func(key string))
+	Close() error
+}
+
+// New create the backend of the config, the listings are kept in memory if the type is empty
+func New(c conf.ListCache) (Backend, error) {
+	switch strings.ToLower(c.Type) {
+	case "", "memory":
+		return NewMemory(), nil
+	case "bolt":
+		store, err := NewBoltStore(c.BoltFile)
+		if err != nil {
+			return nil, err
+		}
+		return NewTiered(store, nil), nil
+	case "redis":
+		store, err := NewRedisStore(c)
+		if err != nil {
+			return nil, err
+		}
+		return NewTiered(store, store), nil
+	default:
+		return nil, errors.Errorf("unknown list cache type: %s", c.Type)
+	}
+}
+
+type memory struct {
+	cache cache.ICache[[]model.Obj]
+}
+
+// NewMemory returns the in-process backend, the objs are kept as they are
+func NewMemory() Backend {
+	return &memory{cache: cache.NewMemCache(cache.WithShards[[]model.Obj](64))}
+}
+
+func (m *memory) Get(key string) ([]model.Obj, bool) {
+	return m.cache.Get(key)
+}
+
+func (m *memory) Set(key string, objs []model.Obj, ttl time.Duration) {
+	m.cache.Set(key, objs, cache.WithEx[[]model.Obj](ttl))
+}
+
+func (m *memory) Del(key string) {
+	m.cache.Del(key)
+}
+
+func (m *memory) Publish(key string) {}
+
+func (m *memory) Subscribe(fn func(key string)) {}
+
+func (m *memory) Close() error {
+	return nil
+}
@@ -0,0 +1,164 @@
+package listcache
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+type driverObj struct {
+	model.Object
+}
+
+func testObjs() []model.Obj {
+	objs := []model.Obj{
+		&model.Object{Name: "dir", IsFolder: true, Modified: time.Unix(1600000000, 0)},
+		&model.ObjThumb{Object: model.Object{ID: "1", Name: "a.jpg", Size: 10}, Thumbnail: model.Thumbnail{Thumbnail: "http://thumb"}},
+		&model.ObjThumbURL{Object: model.Object{Path: "/b.mp4", Name: "b.mp4", Size: 20}, Url: model.Url{Url: "http://url"}},
+	}
+	model.WrapObjsName(objs)
+	return objs
+}
+
+func TestCodec(t *testing.T) {
+	data, err := encode(testObjs())
+	if err != nil {
+		t.Fatalf("failed encode: %+v", err)
+	}
+	objs, err := decode(data)
+	if err != nil {
+		t.Fatalf("failed decode: %+v", err)
+	}
+	if len(objs) != 3 || !objs[0].IsDir() || !objs[0].ModTime().Equal(time.Unix(1600000000, 0)) {
+		t.Fatalf("expect the objs restored, got %+v", objs)
+	}
+	if thumb, ok := model.GetThumb(objs[1]); !ok || thumb != "http://thumb" || objs[1].GetID() != "1" {
+		t.Errorf("expect the thumb restored, got %s", thumb)
+	}
+	if u, ok := model.UnwrapObj(objs[2]).(model.URL); !ok || u.URL() != "http://url" || objs[2].GetPath() != "/b.mp4" {
+		t.Errorf("expect the url restored")
+	}
+	if _, err = encode([]model.Obj{&driverObj{}}); err != errNotPlain {
+		t.Errorf("expect the objs of the driver not encoded, got %v", err)
+	}
+}
+
+func TestBolt(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "list_cache.db")
+	store, err := NewBoltStore(file)
+	if err != nil {
+		t.Fatalf("failed open: %+v", err)
+	}
+	b := NewTiered(store, nil)
+	b.Set("/a", testObjs(), time.Hour)
+	b.Set("/expired", testObjs(), time.Millisecond)
+	// the objs of the driver are only in memory
+	b.Set("/driver", []model.Obj{&driverObj{}}, time.Hour)
+	if _, ok := b.Get("/driver"); !ok {
+		t.Errorf("expect the objs of the driver in memory")
+	}
+	_ = b.Close()
+	time.Sleep(5 * time.Millisecond)
+
+	// the listings survive the restart
+	store, err = NewBoltStore(file)
+	if err != nil {
+		t.Fatalf("failed reopen: %+v", err)
+	}
+	b = NewTiered(store, nil)
+	defer b.Close()
+	if objs, ok := b.Get("/a"); !ok || len(objs) != 3 || objs[1].GetName() != "a.jpg" {
+		t.Errorf("expect the listing persisted, got %v", objs)
+	}
+	if _, ok := b.Get("/expired"); ok {
+		t.Errorf("expect the expired listing dropped")
+	}
+	if _, ok := b.Get("/driver"); ok {
+		t.Errorf("expect the objs of the driver not persisted")
+	}
+	b.Del("/a")
+	if _, ok := b.Get("/a"); ok {
+		t.Errorf("expect the listing deleted")
+	}
+}
+
+func TestRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	newNode := func() Backend {
+		b, err := New(conf.ListCache{Type: "redis", RedisAddress: mr.Addr()})
+		if err != nil {
+			t.Fatalf("failed connect: %+v", err)
+		}
+		t.Cleanup(func() { _ = b.Close() })
+		return b
+	}
+	a, b := newNode(), newNode()
+
+	a.Set("/s3", testObjs(), time.Minute)
+	if objs, ok := b.Get("/s3"); !ok || len(objs) != 3 {
+		t.Fatalf("expect the listing shared, got %v", objs)
+	}
+	if ttl := mr.TTL("alist:list:/s3"); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("expect the ttl of the storage, got %v", ttl)
+	}
+
+	// the listing set after a cache miss is not published
+	b.Get("/s3")
+	a.Set("/s3", testObjs()[:2], time.Minute)
+	time.Sleep(50 * time.Millisecond)
+	if objs, ok := b.Get("/s3"); !ok || len(objs) != 3 {
+		t.Errorf("expect the listing in memory kept, got %v", objs)
+	}
+
+	// the node drop the listing in its memory once it's changed by the other node
+	var changed []string
+	var mu sync.Mutex
+	b.Subscribe(func(key string) {
+		mu.Lock()
+		defer mu.Unlock()
+		changed = append(changed, key)
+	})
+	a.Set("/s3", testObjs()[:1], time.Minute)
+	a.Publish("/s3")
+	waitFor(t, func() bool {
+		objs, ok := b.Get("/s3")
+		return ok && len(objs) == 1
+	}, "expect the updated listing")
+	a.Del("/s3")
+	a.Publish("/s3")
+	waitFor(t, func() bool {
+		_, ok := b.Get("/s3")
+		return !ok
+	}, "expect the listing deleted")
+	mu.Lock()
+	if len(changed) != 2 || changed[0] != "/s3" {
+		t.Errorf("expect the subscriber called with the changes, got %v", changed)
+	}
+	mu.Unlock()
+
+	// the objs of the driver are only in the memory of the node, the other node lists again
+	b.Set("/onedrive", testObjs(), time.Minute)
+	b.Get("/onedrive")
+	a.Set("/onedrive", []model.Obj{&driverObj{}}, time.Minute)
+	a.Publish("/onedrive")
+	waitFor(t, func() bool {
+		_, ok := b.Get("/onedrive")
+		return !ok
+	}, "expect the stale listing dropped")
+}
+
+func waitFor(t *testing.T, cond func() bool, msg string) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf(msg)
+}
@@ -0,0 +1,105 @@
+package listcache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+// RedisStore share the listings between the nodes, the changes are published to the channel,
+// so the nodes drop the listings in their memory
+type RedisStore struct {
+	client  *redis.Client
+	prefix  string
+	channel string
+	// node is the id of the node, the changes published by itself are ignored
+	node   string
+	pubsub *redis.PubSub
+}
+
+func NewRedisStore(c conf.ListCache) (*RedisStore, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     c.RedisAddress,
+		Password: c.RedisPassword,
+		DB:       c.RedisDB,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, errors.Wrapf(err, "failed connect redis %s", c.RedisAddress)
+	}
+	prefix := c.RedisPrefix
+	if prefix == "" {
+		prefix = "alist:list:"
+	}
+	return &RedisStore{
+		client:  client,
+		prefix:  prefix,
+		channel: prefix + "changed",
+		node:    random.String(16),
+	}, nil
+}
+
+func (s *RedisStore) Get(key string) ([]byte, time.Duration, bool, error) {
+	ctx := context.Background()
+	pipe := s.client.Pipeline()
+	get := pipe.Get(ctx, s.prefix+key)
+	ttl := pipe.PTTL(ctx, s.prefix+key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, 0, false, nil
+		}
+		return nil, 0, false, errors.WithStack(err)
+	}
+	data, err := get.Bytes()
+	if err != nil {
+		return nil, 0, false, errors.WithStack(err)
+	}
+	return data, ttl.Val(), true, nil
+}
+
+func (s *RedisStore) Set(key string, data []byte, ttl time.Duration) error {
+	return errors.WithStack(s.client.Set(context.Background(), s.prefix+key, data, ttl).Err())
+}
+
+func (s *RedisStore) Del(key string) error {
+	return errors.WithStack(s.client.Del(context.Background(), s.prefix+key).Err())
+}
+
+func (s *RedisStore) Publish(key string) error {
+	return errors.WithStack(s.client.Publish(context.Background(), s.channel, s.node+" "+key).Err())
+}
+
+func (s *RedisStore) Subscribe(fn func(key string)) {
+	s.pubsub = s.client.Subscribe(context.Background(), s.channel)
+	// wait for the subscription, so the changes after it are received
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := s.pubsub.Receive(ctx); err != nil {
+		log.Warnf("failed subscribe the changes of list cache: %+v", err)
+	}
+	go func() {
+		for msg := range s.pubsub.Channel() {
+			node, key, ok := strings.Cut(msg.Payload, " ")
+			if !ok || node == s.node {
+				continue
+			}
+			log.Debugf("list cache of %s is changed by %s", key, node)
+			fn(key)
+		}
+	}()
+}
+
+func (s *RedisStore) Close() error {
+	if s.pubsub != nil {
+		_ = s.pubsub.Close()
+	}
+	return s.client.Close()
+}
@@ -0,0 +1,120 @@
+package listcache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Store persist the encoded listings, the ttl returned by Get is the remaining time to live
+type Store interface {
+	Get(key string) (data []byte, ttl time.Duration, ok bool, err error)
+	Set(key string, data []byte, ttl time.Duration) error
+	Del(key string) error
+	Close() error
+}
+
+// Notifier tell the other nodes sharing the store that a listing is changed
+type Notifier interface {
+	Publish(key string) error
+	// Subscribe call fn with the keys changed by the other nodes
+	Subscribe(fn func(key string))
+}
+
+// tiered keep the listings in memory in front of the store, so the listings are decoded only once.
+// The listings of the objs that can't be stored are only kept in memory
+type tiered struct {
+	local    Backend
+	store    Store
+	notifier Notifier
+
+	mu       sync.RWMutex
+	handlers []func(key string)
+}
+
+// NewTiered returns a backend that persist the listings in the store,
+// the memory of the other nodes is invalidated through the notifier if it's not nil
+func NewTiered(store Store, notifier Notifier) Backend {
+	t := &tiered{local: NewMemory(), store: store, notifier: notifier}
+	if notifier != nil {
+		notifier.Subscribe(t.changed)
+	}
+	return t
+}
+
+// changed drop the listing changed by the other node in memory
+func (t *tiered) changed(key string) {
+	t.local.Del(key)
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, fn := range t.handlers {
+		fn(key)
+	}
+}
+
+func (t *tiered) Get(key string) ([]model.Obj, bool) {
+	if objs, ok := t.local.Get(key); ok {
+		return objs, true
+	}
+	data, ttl, ok, err := t.store.Get(key)
+	if err != nil {
+		log.Warnf("failed get list cache of %s: %+v", key, err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	objs, err := decode(data)
+	if err != nil {
+		log.Warnf("failed decode list cache of %s: %+v", key, err)
+		return nil, false
+	}
+	t.local.Set(key, objs, ttl)
+	return objs, true
+}
+
+func (t *tiered) Set(key string, objs []model.Obj, ttl time.Duration) {
+	t.local.Set(key, objs, ttl)
+	data, err := encode(objs)
+	if err != nil && !errors.Is(err, errNotPlain) {
+		log.Warnf("failed encode list cache of %s: %+v", key, err)
+	}
+	if err == nil && ttl > 0 {
+		err = t.store.Set(key, data, ttl)
+	} else {
+		// the listing is only in memory, drop the stale one in the store
+		err = t.store.Del(key)
+	}
+	if err != nil {
+		log.Warnf("failed set list cache of %s: %+v", key, err)
+	}
+}
+
+func (t *tiered) Del(key string) {
+	t.local.Del(key)
+	if err := t.store.Del(key); err != nil {
+		log.Warnf("failed del list cache of %s: %+v", key, err)
+	}
+}
+
+func (t *tiered) Subscribe(fn func(key string)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handlers = append(t.handlers, fn)
+}
+
+func (t *tiered) Publish(key string) {
+	if t.notifier == nil {
+		return
+	}
+	if err := t.notifier.Publish(key); err != nil {
+		log.Warnf("failed publish the change of list cache %s: %+v", key, err)
+	}
+}
+
+func (t *tiered) Close() error {
+	return t.store.Close()
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	ScopeRead            = "read"
+	ScopeWrite           = "write"
+	ScopeAdmin           = "admin"
+	ScopeWebdav          = "webdav"
+	ScopeOfflineDownload = "offline-download"
+)
+
+var Scopes = []string{ScopeRead, ScopeWrite, ScopeAdmin, ScopeWebdav, ScopeOfflineDownload}
+
+// ApiToken is a personal token for automations, only the hash of the token is stored
+type ApiToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"index"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-" gorm:"unique"`
+	Scopes     string     `json:"scopes"`      // comma separated
+	PathPrefix string     `json:"path_prefix"` // relative to the base path of the user
+	ExpiresAt  *time.Time `json:"expires_at"`  // nil means never expire
+	LastUsed   *time.Time `json:"last_used"`
+	Created    time.Time  `json:"created"`
+}
+
+func (t ApiToken) HasScope(scope string) bool {
+	for _, s := range strings.Split(t.Scopes, ",") {
+		if strings.TrimSpace(s) == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (t ApiToken) IsExpired() bool {
+	return t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now())
+}
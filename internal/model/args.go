@@ -1,15 +1,31 @@
 package model
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/alist-org/alist/v3/pkg/http_range"
 )
 
 type ListArgs struct {
 	ReqPath string
 }
 
+type ListPageArgs struct {
+	ReqPath string
+	// Cursor is the NextCursor of the previous page, empty for the first page
+	Cursor  string
+	PerPage int
+}
+
+type ObjsPage struct {
+	Objs []Obj
+	// NextCursor is empty if it's the last page
+	NextCursor string
+}
+
 type LinkArgs struct {
 	IP      string
 	Header  http.Header
@@ -18,13 +34,25 @@ type LinkArgs struct {
 }
 
 type Link struct {
-	URL        string                                             `json:"url"`
-	Header     http.Header                                        `json:"header"` // needed header
-	Data       io.ReadCloser                                      // return file reader directly
-	Status     int                                                // status maybe 200 or 206, etc
-	FilePath   *string                                            // local file, return the filepath
-	Expiration *time.Duration                                     // url expiration time
-	Handle     func(w http.ResponseWriter, r *http.Request) error `json:"-"` // custom handler
+	URL         string                                             `json:"url"`
+	Header      http.Header                                        `json:"header"` // needed header
+	Data        io.ReadCloser                                      // return file reader directly
+	Status      int                                                // status maybe 200 or 206, etc
+	FilePath    *string                                            // local file, return the filepath
+	Expiration  *time.Duration                                     // url expiration time
+	Handle      func(w http.ResponseWriter, r *http.Request) error `json:"-"` // custom handler
+	RangeReader RangeReader                                        `json:"-"` // open the file at any range, the Range of the request is handled by the server
+}
+
+// RangeReader open a reader of the range of the file, the Length of the range is -1 to read to the end
+type RangeReader interface {
+	RangeRead(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error)
+}
+
+type RangeReaderFunc func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error)
+
+func (f RangeReaderFunc) RangeRead(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+	return f(ctx, httpRange)
 }
 
 type OtherArgs struct {
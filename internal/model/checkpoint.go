@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// UploadCheckpoint records the committed part of a resumable upload,
+// so that a failed copy can be resumed instead of restarted from byte zero
+type UploadCheckpoint struct {
+	ID uint `json:"id" gorm:"primaryKey"`
+	// Key identifies the copy by the src and dst paths, the size and modified time of the src file
+	Key     string `json:"-" gorm:"uniqueIndex;size:64"`
+	SrcPath string `json:"src_path"`
+	DstPath string `json:"dst_path"`
+	Size    int64  `json:"size"`
+	// Offset is the bytes committed by the dst storage, the upload is resumed from it
+	Offset int64 `json:"offset"`
+	// Session is the upload session of the driver as json, such as the upload id and the committed parts
+	Session   string    `json:"session" gorm:"type:text"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	save func(*UploadCheckpoint) error
+}
+
+// SetSaver set the func to persist the checkpoint on commit
+func (c *UploadCheckpoint) SetSaver(save func(*UploadCheckpoint) error) {
+	c.save = save
+}
+
+// GetSession unmarshal the session of the driver, returns false if there is no session yet
+func (c *UploadCheckpoint) GetSession(session any) (bool, error) {
+	if c.Session == "" {
+		return false, nil
+	}
+	if err := utils.Json.UnmarshalFromString(c.Session, session); err != nil {
+		return false, errors.Wrapf(err, "failed unmarshal upload session")
+	}
+	return true, nil
+}
+
+// Commit record the bytes committed by the dst storage and the session to resume from,
+// it's called by the driver after each part is committed
+func (c *UploadCheckpoint) Commit(offset int64, session any) error {
+	s, err := utils.Json.MarshalToString(session)
+	if err != nil {
+		return errors.Wrapf(err, "failed marshal upload session")
+	}
+	c.Offset, c.Session = offset, s
+	if c.save != nil {
+		return c.save(c)
+	}
+	return nil
+}
+
+// Reset drop the session, so the upload is restarted from the beginning
+func (c *UploadCheckpoint) Reset() {
+	c.Offset, c.Session = 0, ""
+}
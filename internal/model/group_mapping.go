@@ -0,0 +1,44 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// GroupMapping map a group of the users from the external sources, such as ldap,
+// oidc and the reverse proxy, to the permission and base path
+type GroupMapping struct {
+	Group      string `json:"group"`
+	Permission int32  `json:"permission"`
+	// {username} is replaced with the username
+	BasePath string `json:"base_path"`
+}
+
+// ApplyGroupMapping set the permission and base path of the user from the mappings
+// of the groups matched, the permissions are merged and the first base path is used,
+// the default permission and base path are used if no group is matched.
+// The role is always general, the admin is the only one created locally
+func (u *User) ApplyGroupMapping(mappings []GroupMapping, match func(group string) bool, defaultPermission int32, defaultBasePath string) {
+	u.Role = GENERAL
+	u.Permission = 0
+	basePath := ""
+	matched := false
+	for _, m := range mappings {
+		if !match(m.Group) {
+			continue
+		}
+		matched = true
+		u.Permission |= m.Permission
+		if basePath == "" {
+			basePath = m.BasePath
+		}
+	}
+	if !matched {
+		u.Permission = defaultPermission
+	}
+	if basePath == "" {
+		basePath = defaultBasePath
+	}
+	u.BasePath = utils.FixAndCleanPath(strings.ReplaceAll(basePath, "{username}", u.Username))
+}
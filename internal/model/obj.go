@@ -36,6 +36,8 @@ type FileStreamer interface {
 	NeedStore() bool
 	GetReadCloser() io.ReadCloser
 	GetOld() Obj
+	// GetHash returns the hex hash of the type, empty if it's not computed
+	GetHash(typ string) string
 }
 
 type URL interface {
@@ -46,6 +48,12 @@ type Thumb interface {
 	Thumb() string
 }
 
+// Hash is implemented by objs whose driver reports a content hash,
+// hashType is the lower-case algorithm name, such as sha1 or md5
+type Hash interface {
+	Hash() (hashType string, hash string)
+}
+
 type SetPath interface {
 	SetPath(path string)
 }
@@ -136,6 +144,17 @@ func GetUrl(obj Obj) (url string, ok bool) {
 	return url, false
 }
 
+func GetHash(obj Obj) (hashType string, hash string, ok bool) {
+	if obj, ok := obj.(Hash); ok {
+		hashType, hash = obj.Hash()
+		return hashType, hash, hash != ""
+	}
+	if unwrap, ok := obj.(ObjUnwrap); ok {
+		return GetHash(unwrap.Unwrap())
+	}
+	return hashType, hash, false
+}
+
 // Merge
 func NewObjMerge() *ObjMerge {
 	return &ObjMerge{
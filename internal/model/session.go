@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Session is created on login, the access tokens carry its id in the jti claim,
+// so that they can be revoked before expiration
+type Session struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"index"`
+	IP          string    `json:"ip"`
+	UserAgent   string    `json:"user_agent"`
+	RefreshHash string    `json:"-" gorm:"index"`
+	Created     time.Time `json:"created"`
+	LastSeen    time.Time `json:"last_seen"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (s Session) IsExpired() bool {
+	return s.ExpiresAt.Before(time.Now())
+}
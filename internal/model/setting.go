@@ -9,6 +9,10 @@ const (
 	ARIA2
 	INDEX
 	SSO
+	LDAP
+	OIDC
+	PROXY_AUTH
+	TASK
 )
 
 const (
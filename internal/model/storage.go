@@ -16,6 +16,7 @@ type Storage struct {
 	EnableSign      bool      `json:"enable_sign"`
 	Sort
 	Proxy
+	RequestPolicy
 }
 
 type Sort struct {
@@ -28,6 +29,16 @@ type Proxy struct {
 	WebProxy     bool   `json:"web_proxy"`
 	WebdavPolicy string `json:"webdav_policy"`
 	DownProxyUrl string `json:"down_proxy_url"`
+	BlockCache   bool   `json:"block_cache"` // cache the proxied content on local disk
+	// DownConcurrency is the number of the concurrent range requests to download a link, less than 2 means disabled
+	DownConcurrency int `json:"down_concurrency"`
+	DownChunkSize   int `json:"down_chunk_size"` // MB, 0 means default
+}
+
+// RequestPolicy is used by the drivers that request the upstream api through http
+type RequestPolicy struct {
+	MaxRetries int     `json:"max_retries"` // 0 means default, negative means no retry
+	QPS        float64 `json:"qps"`         // 0 means unlimited
 }
 
 func (s *Storage) GetStorage() *Storage {
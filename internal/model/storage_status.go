@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// StorageStatus is a record of storage status change
+type StorageStatus struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	StorageID uint      `json:"storage_id" gorm:"index"`
+	MountPath string    `json:"mount_path"`
+	Status    string    `json:"status" gorm:"type:text"`
+	Time      time.Time `json:"time"`
+}
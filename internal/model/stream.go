@@ -2,6 +2,9 @@ package model
 
 import (
 	"io"
+	"net/http"
+	"os"
+	"sync/atomic"
 )
 
 type FileStream struct {
@@ -10,6 +13,66 @@ type FileStream struct {
 	Mimetype     string
 	WebPutAsTask bool
 	Old          Obj
+	// CountRead is called with the bytes read by Read or from GetReadCloser if not nil
+	CountRead func(n int64)
+	// counted is the bytes passed to CountRead, accessed atomically
+	counted int64
+	// Checkpoint is set if the upload can be resumed, the stream starts at its offset
+	Checkpoint *UploadCheckpoint
+	// Hashes are the hex hashes of the stream by the types, computed when the stream is stored
+	Hashes map[string]string
+	// KeepFile is set if the stream is a file of the caller, it's not removed after the upload like the temp files
+	KeepFile bool
+}
+
+func (f *FileStream) Read(p []byte) (int, error) {
+	n, err := f.ReadCloser.Read(p)
+	f.Count(int64(n))
+	return n, err
+}
+
+// Count calls CountRead with the bytes read. The bytes beyond the size of the stream are not counted,
+// so the stream counted when it's stored in the temp file is not counted again when the temp file is uploaded
+func (f *FileStream) Count(n int64) {
+	if f.CountRead == nil || n <= 0 {
+		return
+	}
+	total := f.GetSize()
+	if total <= 0 {
+		// the size is unknown
+		f.CountRead(n)
+		return
+	}
+	if f.Checkpoint != nil {
+		total -= f.Checkpoint.Offset
+	}
+	for {
+		counted := atomic.LoadInt64(&f.counted)
+		if counted+n > total {
+			n = total - counted
+		}
+		if n <= 0 {
+			return
+		}
+		if atomic.CompareAndSwapInt64(&f.counted, counted, counted+n) {
+			f.CountRead(n)
+			return
+		}
+	}
+}
+
+// countReadCloser calls count with the bytes read
+type countReadCloser struct {
+	io.ReadCloser
+	count func(n int64)
+}
+
+func (c countReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	if n > 0 {
+		c.count(int64(n))
+	}
+	return n, err
 }
 
 func (f *FileStream) GetMimetype() string {
@@ -20,14 +83,26 @@ func (f *FileStream) NeedStore() bool {
 	return f.WebPutAsTask
 }
 
+// GetReadCloser returns the reader counted by CountRead. The files are returned as they are,
+// so the drivers can still use them as files, their bytes are counted by CountUnread after the upload
 func (f *FileStream) GetReadCloser() io.ReadCloser {
-	return f.ReadCloser
+	if f.CountRead == nil || f.ReadCloser == http.NoBody {
+		return f.ReadCloser
+	}
+	if _, ok := f.ReadCloser.(*os.File); ok {
+		return f.ReadCloser
+	}
+	return countReadCloser{ReadCloser: f.ReadCloser, count: f.Count}
 }
 
 func (f *FileStream) SetReadCloser(rc io.ReadCloser) {
 	f.ReadCloser = rc
 }
 
+func (f *FileStream) GetHash(typ string) string {
+	return f.Hashes[typ]
+}
+
 func (f *FileStream) GetOld() Obj {
 	return f.Old
 }
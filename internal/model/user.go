@@ -15,7 +15,8 @@ const (
 type User struct {
 	ID       uint   `json:"id" gorm:"primaryKey"`                      // unique key
 	Username string `json:"username" gorm:"unique" binding:"required"` // username
-	Password string `json:"password"`                                  // password
+	Password string `json:"password" gorm:"-"`                         // plaintext password, only used to set a new one
+	PwdHash  string `json:"-"`                                         // salted hash of the password
 	BasePath string `json:"base_path"`                                 // base path
 	Role     int    `json:"role"`                                      // user's role
 	Disabled bool   `json:"disabled"`
@@ -33,7 +34,9 @@ type User struct {
 	//  10: can add qbittorrent tasks
 	Permission int32  `json:"permission"`
 	OtpSecret  string `json:"-"`
+	TokenGen   int    `json:"-"` // bumped to invalidate the issued tokens
 	SsoID      string `json:"sso_id"`
+	Source     string `json:"source"` // where the user come from, empty means local
 }
 
 func (u User) IsGuest() bool {
@@ -48,12 +51,23 @@ func (u User) ValidatePassword(password string) error {
 	if password == "" {
 		return errors.WithStack(errs.EmptyPassword)
 	}
-	if u.Password != password {
+	if !utils.VerifyPassword(u.PwdHash, password) {
 		return errors.WithStack(errs.WrongPassword)
 	}
 	return nil
 }
 
+// SetPassword hash the password and clear the plaintext one
+func (u *User) SetPassword(password string) error {
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	u.PwdHash = hash
+	u.Password = ""
+	return nil
+}
+
 func (u User) CanSeeHides() bool {
 	return u.IsAdmin() || u.Permission&1 == 1
 }
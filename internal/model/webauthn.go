@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// WebAuthnCredential is a passkey of the user, used to login without password or as the second factor
+type WebAuthnCredential struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"index"`
+	Name         string     `json:"name"`
+	CredentialID string     `json:"-" gorm:"unique"` // base64url encoded raw id
+	Credential   string     `json:"-"`               // json of the public key, sign count and flags
+	LastUsed     *time.Time `json:"last_used"`
+	Created      time.Time  `json:"created"`
+}
+
+// RecoveryCode is a one-time code to pass the 2FA if the second factors are lost,
+// only the hash of the code is stored
+type RecoveryCode struct {
+	ID       uint   `gorm:"primaryKey"`
+	UserID   uint   `gorm:"index"`
+	CodeHash string `gorm:"index"`
+}
@@ -0,0 +1,210 @@
+// Package mtls verify the client certificates on the https listener,
+// and authenticate the users by the subject or the san of the certificates
+package mtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	Off      = "off"
+	Optional = "optional"
+	Required = "required"
+)
+
+type Verifier struct {
+	mode     string
+	userBy   string
+	cas      []*x509.Certificate
+	pool     *x509.CertPool
+	crlFile  string
+	mu       sync.RWMutex
+	crlMtime time.Time
+	revoked  map[string]struct{}
+}
+
+// the verifier of the running server, nil if the client certificates are off
+var verifier *Verifier
+
+// Init create the verifier of the scheme and returns the tls config of the listener,
+// nil is returned if the client certificates are off
+func Init(scheme conf.Scheme) (*tls.Config, error) {
+	v, err := NewVerifier(scheme)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	verifier = v
+	return v.TLSConfig(), nil
+}
+
+func NewVerifier(scheme conf.Scheme) (*Verifier, error) {
+	mode := strings.ToLower(scheme.ClientAuth)
+	switch mode {
+	case "", Off:
+		return nil, nil
+	case Optional, Required:
+	default:
+		return nil, errors.Errorf("invalid client_auth: %s, should be off, optional or required", scheme.ClientAuth)
+	}
+	userBy := strings.ToLower(scheme.ClientCertUser)
+	if userBy == "" {
+		userBy = "cn"
+	}
+	if !utils.SliceContains([]string{"cn", "email", "dns", "uri"}, userBy) {
+		return nil, errors.Errorf("invalid client_cert_user: %s, should be cn, email, dns or uri", scheme.ClientCertUser)
+	}
+	data, err := os.ReadFile(scheme.ClientCAFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed read client ca file")
+	}
+	v := &Verifier{mode: mode, userBy: userBy, pool: x509.NewCertPool(), crlFile: scheme.ClientCRLFile}
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		ca, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed parse client ca")
+		}
+		v.cas = append(v.cas, ca)
+		v.pool.AddCert(ca)
+	}
+	if len(v.cas) == 0 {
+		return nil, errors.Errorf("no certificate found in client ca file %s", scheme.ClientCAFile)
+	}
+	if err := v.loadCRL(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (v *Verifier) TLSConfig() *tls.Config {
+	clientAuth := tls.VerifyClientCertIfGiven
+	if v.mode == Required {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+	return &tls.Config{
+		ClientAuth: clientAuth,
+		ClientCAs:  v.pool,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.VerifiedChains) > 0 && v.IsRevoked(cs.VerifiedChains[0][0]) {
+				return errors.New("client certificate is revoked")
+			}
+			return nil
+		},
+	}
+}
+
+// loadCRL load the crl if it's modified, the crl should be signed by one of the cas
+func (v *Verifier) loadCRL() error {
+	if v.crlFile == "" {
+		return nil
+	}
+	info, err := os.Stat(v.crlFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed stat client crl file")
+	}
+	v.mu.RLock()
+	loaded := info.ModTime().Equal(v.crlMtime)
+	v.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+	data, err := os.ReadFile(v.crlFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed read client crl file")
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	crl, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return errors.Wrapf(err, "failed parse client crl")
+	}
+	signed := false
+	for _, ca := range v.cas {
+		if crl.CheckSignatureFrom(ca) == nil {
+			signed = true
+			break
+		}
+	}
+	if !signed {
+		return errors.New("client crl is not signed by the client ca")
+	}
+	revoked := make(map[string]struct{}, len(crl.RevokedCertificates))
+	for _, c := range crl.RevokedCertificates {
+		revoked[c.SerialNumber.String()] = struct{}{}
+	}
+	v.mu.Lock()
+	v.revoked, v.crlMtime = revoked, info.ModTime()
+	v.mu.Unlock()
+	return nil
+}
+
+// IsRevoked check the certificate with the crl, which is reloaded once it's modified
+func (v *Verifier) IsRevoked(cert *x509.Certificate) bool {
+	if err := v.loadCRL(); err != nil {
+		// keep using the loaded crl if the new one is broken
+		utils.Log.Warnf("failed reload client crl: %+v", err)
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	_, ok := v.revoked[cert.SerialNumber.String()]
+	return ok
+}
+
+// Username returns the name of the certificate used as the username
+func (v *Verifier) Username(cert *x509.Certificate) string {
+	switch v.userBy {
+	case "email":
+		if len(cert.EmailAddresses) > 0 {
+			return cert.EmailAddresses[0]
+		}
+	case "dns":
+		if len(cert.DNSNames) > 0 {
+			return cert.DNSNames[0]
+		}
+	case "uri":
+		if len(cert.URIs) > 0 {
+			return cert.URIs[0].String()
+		}
+	default:
+		return cert.Subject.CommonName
+	}
+	return ""
+}
+
+// Authenticate returns the user of the verified client certificate of the request,
+// nil is returned if the client certificates are off or the request has no certificate
+func Authenticate(r *http.Request) (*model.User, error) {
+	if verifier == nil || r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return nil, nil
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	// the crl may be updated after the connection is established
+	if verifier.IsRevoked(cert) {
+		return nil, errors.New("client certificate is revoked")
+	}
+	username := verifier.Username(cert)
+	if username == "" {
+		return nil, errors.Errorf("no %s in the client certificate", verifier.userBy)
+	}
+	user, err := op.GetUserByName(username)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed get user %s of the client certificate", username)
+	}
+	return user, nil
+}
@@ -0,0 +1,180 @@
+package mtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64, tmpl *x509.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl.SerialNumber = big.NewInt(serial)
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func (ca *testCA) crl(t *testing.T, serials ...int64) []byte {
+	tmpl := &x509.RevocationList{Number: big.NewInt(time.Now().UnixNano()), ThisUpdate: time.Now(), NextUpdate: time.Now().Add(time.Hour)}
+	for _, s := range serials {
+		tmpl.RevokedCertificates = append(tmpl.RevokedCertificates, pkix.RevokedCertificate{SerialNumber: big.NewInt(s), RevocationTime: time.Now()})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, tmpl, ca.cert, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestVerifier(t *testing.T) {
+	ca := newTestCA(t)
+	caFile := writeFile(t, "ca.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}))
+	crlFile := writeFile(t, "ca.crl", ca.crl(t, 3))
+	v, err := NewVerifier(conf.Scheme{ClientAuth: "required", ClientCAFile: caFile, ClientCRLFile: crlFile})
+	if err != nil {
+		t.Fatalf("failed create verifier: %+v", err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(v.Username(r.TLS.VerifiedChains[0][0])))
+	}))
+	server.TLS = v.TLSConfig()
+	server.StartTLS()
+	defer server.Close()
+	get := func(certs ...tls.Certificate) (string, error) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       certs,
+		}}}
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		b := make([]byte, 64)
+		n, _ := resp.Body.Read(b)
+		return string(b[:n]), nil
+	}
+
+	alice := ca.issue(t, 2, &x509.Certificate{Subject: pkix.Name{CommonName: "alice"}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	if name, err := get(alice); err != nil || name != "alice" {
+		t.Errorf("expect alice, got %s %+v", name, err)
+	}
+	bob := ca.issue(t, 3, &x509.Certificate{Subject: pkix.Name{CommonName: "bob"}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	if _, err := get(bob); err == nil {
+		t.Errorf("revoked certificate should be rejected")
+	}
+	if _, err := get(); err == nil {
+		t.Errorf("certificate is required")
+	}
+	other := newTestCA(t).issue(t, 2, &x509.Certificate{Subject: pkix.Name{CommonName: "alice"}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	if _, err := get(other); err == nil {
+		t.Errorf("certificate of other ca should be rejected")
+	}
+
+	// the crl is reloaded once it's modified
+	if err := os.WriteFile(crlFile, ca.crl(t, 2), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Chtimes(crlFile, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	if _, err := get(alice); err == nil {
+		t.Errorf("certificate revoked by the new crl should be rejected")
+	}
+	if name, err := get(bob); err != nil || name != "bob" {
+		t.Errorf("expect bob, got %s %+v", name, err)
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	ca := newTestCA(t)
+	caFile := writeFile(t, "ca.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}))
+	if v, err := NewVerifier(conf.Scheme{ClientAuth: "off", ClientCAFile: caFile}); v != nil || err != nil {
+		t.Errorf("expect no verifier, got %+v %+v", v, err)
+	}
+	for _, scheme := range []conf.Scheme{
+		{ClientAuth: "maybe", ClientCAFile: caFile},
+		{ClientAuth: "optional", ClientCAFile: caFile, ClientCertUser: "serial"},
+		{ClientAuth: "optional", ClientCAFile: filepath.Join(t.TempDir(), "none.pem")},
+		{ClientAuth: "optional", ClientCAFile: caFile, ClientCRLFile: writeFile(t, "other.crl", newTestCA(t).crl(t, 1))},
+	} {
+		if _, err := NewVerifier(scheme); err == nil {
+			t.Errorf("expect error for %+v", scheme)
+		}
+	}
+	v, err := NewVerifier(conf.Scheme{ClientAuth: "optional", ClientCAFile: caFile, ClientCertUser: "uri"})
+	if err != nil {
+		t.Fatalf("failed create verifier: %+v", err)
+	}
+	if v.TLSConfig().ClientAuth != tls.VerifyClientCertIfGiven {
+		t.Errorf("optional mode should verify the certificates if given")
+	}
+	u, _ := url.Parse("spiffe://example.com/alice")
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "alice"}, URIs: []*url.URL{u}, EmailAddresses: []string{"alice@example.com"}}
+	if name := v.Username(cert); name != "spiffe://example.com/alice" {
+		t.Errorf("unexpected username: %s", name)
+	}
+	v.userBy = "email"
+	if name := v.Username(cert); name != "alice@example.com" {
+		t.Errorf("unexpected username: %s", name)
+	}
+	v.userBy = "dns"
+	if name := v.Username(cert); name != "" {
+		t.Errorf("expect empty username, got %s", name)
+	}
+}
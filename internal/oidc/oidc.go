@@ -0,0 +1,348 @@
+// Package oidc authenticate the users by the openid connect providers,
+// the users are bound by the issuer and subject of the id token and can be registered on first login
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+// SourcePrefix is the prefix of the source of the users provisioned by oidc,
+// followed by the name of the provider
+const SourcePrefix = "oidc:"
+
+const discoveryPath = "/.well-known/openid-configuration"
+
+// the login should be completed in the expiration after redirected to the provider
+const StateExpiration = 10 * time.Minute
+
+// StateCookie keep the state in the browser that starts the login,
+// so the callback can't be finished in another browser
+const StateCookie = "alist_oidc_state"
+
+type Provider struct {
+	// the name is used in the callback url, so it should be url safe
+	Name string `json:"name"`
+	// issuer url or the discovery url of the provider
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	Scopes       []string `json:"scopes"`
+	// the claims of the id token used as the username and the groups,
+	// the username only names the user on registration, since it may be changed at the provider
+	UsernameClaim string `json:"username_claim"`
+	GroupsClaim   string `json:"groups_claim"`
+	// create the user on first login, otherwise only the users already
+	// registered by this provider can login
+	AutoRegister      bool                 `json:"auto_register"`
+	GroupMapping      []model.GroupMapping `json:"group_mapping"`
+	DefaultPermission int32                `json:"default_permission"`
+	DefaultBasePath   string               `json:"default_base_path"`
+}
+
+// Identity is the user claimed by the verified id token
+type Identity struct {
+	// Subject is the stable id of the user at the provider, see subjectID
+	Subject  string
+	Username string
+	Groups   []string
+}
+
+// subjectID returns the id of the user bound to the users by their SsoID.
+// The sub claim is unique only in the issuer, and the issuer can't contain a fragment
+func subjectID(issuer, subject string) string {
+	return issuer + "#" + subject
+}
+
+type authState struct {
+	provider    string
+	redirectURI string
+	nonce       string
+	verifier    string
+}
+
+var (
+	states     = cache.NewMemCache(cache.WithShards[*authState](2))
+	discovered = cache.NewMemCache(cache.WithShards[*gooidc.Provider](2))
+	// HttpClient is used to discover the providers, exchange the codes and fetch the keys
+	HttpClient = &http.Client{Timeout: 30 * time.Second}
+)
+
+func init() {
+	op.RegisterSettingItemHook(conf.OIDCProviders, func(item *model.SettingItem) error {
+		if _, err := parseProviders(item.Value); err != nil {
+			return err
+		}
+		// the issuers may be changed, discover them again
+		discovered.Clear()
+		return nil
+	})
+}
+
+func Enabled() bool {
+	return setting.GetBool(conf.OIDCLoginEnabled)
+}
+
+func parseProviders(s string) ([]Provider, error) {
+	var providers []Provider
+	if s == "" {
+		return providers, nil
+	}
+	if err := utils.Json.UnmarshalFromString(s, &providers); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s", conf.OIDCProviders)
+	}
+	names := make(map[string]struct{})
+	for i := range providers {
+		p := &providers[i]
+		if p.Name == "" || strings.ContainsAny(p.Name, "/?#% ") {
+			return nil, errors.Errorf("invalid oidc provider name: [%s]", p.Name)
+		}
+		if _, ok := names[p.Name]; ok {
+			return nil, errors.Errorf("duplicate oidc provider: %s", p.Name)
+		}
+		names[p.Name] = struct{}{}
+		if p.Issuer == "" || p.ClientID == "" {
+			return nil, errors.Errorf("issuer and client_id of oidc provider %s are required", p.Name)
+		}
+		p.Issuer = strings.TrimSuffix(strings.TrimSuffix(p.Issuer, discoveryPath), "/")
+		if p.UsernameClaim == "" {
+			p.UsernameClaim = "preferred_username"
+		}
+		if p.GroupsClaim == "" {
+			p.GroupsClaim = "groups"
+		}
+		if !utils.SliceContains(p.Scopes, gooidc.ScopeOpenID) {
+			p.Scopes = append([]string{gooidc.ScopeOpenID}, p.Scopes...)
+		}
+		if p.DefaultBasePath == "" {
+			p.DefaultBasePath = "/"
+		}
+	}
+	return providers, nil
+}
+
+// GetProviders returns the configured providers
+func GetProviders() ([]Provider, error) {
+	return parseProviders(setting.GetStr(conf.OIDCProviders))
+}
+
+func GetProvider(name string) (*Provider, error) {
+	providers, err := GetProviders()
+	if err != nil {
+		return nil, err
+	}
+	for i := range providers {
+		if providers[i].Name == name {
+			return &providers[i], nil
+		}
+	}
+	return nil, errors.Errorf("oidc provider %s not found", name)
+}
+
+func (p *Provider) Source() string {
+	return SourcePrefix + p.Name
+}
+
+func clientContext(ctx context.Context) context.Context {
+	return gooidc.ClientContext(ctx, HttpClient)
+}
+
+// discover fetch the configuration of the provider, the keys are fetched
+// and cached by the key set of the provider when verifying the tokens
+func (p *Provider) discover(ctx context.Context) (*gooidc.Provider, error) {
+	if provider, ok := discovered.Get(p.Issuer); ok {
+		return provider, nil
+	}
+	provider, err := gooidc.NewProvider(clientContext(ctx), p.Issuer)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed discover oidc provider %s", p.Name)
+	}
+	discovered.Set(p.Issuer, provider, cache.WithEx[*gooidc.Provider](time.Hour))
+	return provider, nil
+}
+
+func (p *Provider) oauth2Config(provider *gooidc.Provider, redirectURI string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  redirectURI,
+		Scopes:       p.Scopes,
+	}
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// AuthURL returns the url to redirect the user to the provider and the state,
+// the state should be set in the StateCookie of the browser.
+// The state, nonce and pkce verifier are kept until the callback
+func (p *Provider) AuthURL(ctx context.Context, redirectURI string) (string, string, error) {
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	st := &authState{provider: p.Name, redirectURI: redirectURI}
+	var state string
+	for _, s := range []*string{&state, &st.nonce, &st.verifier} {
+		if *s, err = randomString(); err != nil {
+			return "", "", err
+		}
+	}
+	states.Set(state, st, cache.WithEx[*authState](StateExpiration))
+	challenge := sha256.Sum256([]byte(st.verifier))
+	return p.oauth2Config(provider, redirectURI).AuthCodeURL(state,
+		gooidc.Nonce(st.nonce),
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256")), state, nil
+}
+
+// Exchange redeem the code with the state, and returns the identity claimed by the verified id token.
+// browserState is the state in the StateCookie of the browser, it must match the state of the callback
+func (p *Provider) Exchange(ctx context.Context, state, browserState, code string) (*Identity, error) {
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return nil, errors.New("the oidc login is not started by this browser")
+	}
+	// the state can be used only once
+	st, ok := states.GetDel(state)
+	if !ok || st.provider != p.Name {
+		return nil, errors.New("invalid or expired oidc state")
+	}
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = clientContext(ctx)
+	token, err := p.oauth2Config(provider, st.redirectURI).Exchange(ctx, code,
+		oauth2.SetAuthURLParam("code_verifier", st.verifier))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed exchange oidc code")
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("no id_token in the oidc token response")
+	}
+	idToken, err := provider.Verifier(&gooidc.Config{ClientID: p.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed verify oidc id token")
+	}
+	if idToken.Nonce != st.nonce {
+		return nil, errors.New("invalid oidc nonce")
+	}
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, errors.Wrapf(err, "failed parse oidc claims")
+	}
+	if idToken.Subject == "" {
+		return nil, errors.New("no sub claim in the oidc id token")
+	}
+	username, _ := claims[p.UsernameClaim].(string)
+	if username == "" {
+		return nil, errors.Errorf("no %s claim in the oidc id token", p.UsernameClaim)
+	}
+	return &Identity{
+		Subject:  subjectID(idToken.Issuer, idToken.Subject),
+		Username: username,
+		Groups:   groupsClaim(claims[p.GroupsClaim]),
+	}, nil
+}
+
+// groupsClaim accept the groups as an array or a comma separated string
+func groupsClaim(v any) []string {
+	switch v := v.(type) {
+	case string:
+		var groups []string
+		for _, g := range strings.Split(v, ",") {
+			if g = strings.TrimSpace(g); g != "" {
+				groups = append(groups, g)
+			}
+		}
+		return groups
+	case []any:
+		groups := make([]string, 0, len(v))
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+		return groups
+	}
+	return nil
+}
+
+// Login complete the login with the callback of the provider, then create or update the user.
+// The user is found by the subject, so a username changed at the provider can't take over another user
+func (p *Provider) Login(ctx context.Context, state, browserState, code string) (*model.User, error) {
+	identity, err := p.Exchange(ctx, state, browserState, code)
+	if err != nil {
+		return nil, err
+	}
+	mapped := p.mapUser(identity)
+	user, err := op.GetUserBySSOID(identity.Subject)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if !p.AutoRegister {
+			return nil, errors.Errorf("user %s is not registered", identity.Username)
+		}
+		// the existing user of the name is never bound, it's not known to be the same person
+		if _, err := op.GetUserByName(identity.Username); err == nil {
+			return nil, errors.Errorf("user %s already exists", identity.Username)
+		}
+		if err := op.CreateUser(&mapped); err != nil {
+			return nil, err
+		}
+		return &mapped, nil
+	}
+	// never take over the local users or the users of other sources
+	if user.Source != p.Source() {
+		return nil, errors.Errorf("user %s is not registered by oidc provider %s", user.Username, p.Name)
+	}
+	// sync the permissions with the groups on every login if the groups are mapped,
+	// otherwise the permissions are managed by the admin
+	if len(p.GroupMapping) > 0 && (user.Role != mapped.Role || user.Permission != mapped.Permission || user.BasePath != mapped.BasePath) {
+		updated := *user
+		updated.Role, updated.Permission, updated.BasePath = mapped.Role, mapped.Permission, mapped.BasePath
+		if err := op.UpdateUser(&updated); err != nil {
+			return nil, err
+		}
+		return &updated, nil
+	}
+	return user, nil
+}
+
+// mapUser build the user with the role, permission and base path mapped from the groups
+func (p *Provider) mapUser(identity *Identity) model.User {
+	user := model.User{
+		Username: identity.Username,
+		SsoID:    identity.Subject,
+		Source:   p.Source(),
+	}
+	user.ApplyGroupMapping(p.GroupMapping, func(group string) bool {
+		return utils.SliceContains(identity.Groups, group)
+	}, p.DefaultPermission, p.DefaultBasePath)
+	return user
+}
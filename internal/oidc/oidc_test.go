@@ -0,0 +1,272 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+type fakeProvider struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	signKey   *rsa.PrivateKey
+	challenge string
+	nonce     string
+	groups    []string
+	sub       string
+	username  string
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeProvider{key: key, signKey: key, groups: []string{"staff", "editors"}, sub: "1", username: "alice"}
+	mux := http.NewServeMux()
+	mux.HandleFunc(discoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		_ = utils.Json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                 f.URL,
+			"authorization_endpoint": f.URL + "/authorize",
+			"token_endpoint":         f.URL + "/token",
+			"jwks_uri":               f.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = utils.Json.NewEncoder(w).Encode(map[string]any{"keys": []any{map[string]any{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if r.FormValue("code") != "the-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != f.challenge {
+			w.WriteHeader(400)
+			_ = utils.Json.NewEncoder(w).Encode(map[string]any{"error": "invalid_grant"})
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":                f.URL,
+			"aud":                "alist",
+			"sub":                f.sub,
+			"exp":                time.Now().Add(time.Hour).Unix(),
+			"iat":                time.Now().Unix(),
+			"nonce":              f.nonce,
+			"preferred_username": f.username,
+			"groups":             f.groups,
+		})
+		token.Header["kid"] = "test"
+		idToken, err := token.SignedString(f.signKey)
+		if err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = utils.Json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     idToken,
+		})
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// authorize parse the auth url like the provider, returns the state
+func (f *fakeProvider) authorize(t *testing.T, authURL string) string {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("scope") != "openid profile" {
+		t.Fatalf("unexpected auth url: %s", authURL)
+	}
+	f.challenge, f.nonce = q.Get("code_challenge"), q.Get("nonce")
+	return q.Get("state")
+}
+
+func TestExchange(t *testing.T) {
+	f := newFakeProvider(t)
+	providers, err := parseProviders(`[{"name": "test", "issuer": "` + f.URL + discoveryPath + `", "client_id": "alist", "scopes": ["profile"]}]`)
+	if err != nil {
+		t.Fatalf("failed parse providers: %+v", err)
+	}
+	p := &providers[0]
+	ctx := context.Background()
+	authURL, _, err := p.AuthURL(ctx, "http://localhost/api/auth/oidc/test/callback")
+	if err != nil {
+		t.Fatalf("failed get auth url: %+v", err)
+	}
+	state := f.authorize(t, authURL)
+	// the callback finished in another browser is rejected
+	if _, err := p.Exchange(ctx, state, "", "the-code"); err == nil {
+		t.Errorf("the state not in the browser should be rejected")
+	}
+	if _, err := p.Exchange(ctx, state, "other", "the-code"); err == nil {
+		t.Errorf("the state of another login should be rejected")
+	}
+	identity, err := p.Exchange(ctx, state, state, "the-code")
+	if err != nil {
+		t.Fatalf("failed exchange: %+v", err)
+	}
+	if identity.Username != "alice" || identity.Subject != f.URL+"#1" || len(identity.Groups) != 2 {
+		t.Errorf("unexpected identity: %+v", identity)
+	}
+	if _, err := p.Exchange(ctx, state, state, "the-code"); err == nil {
+		t.Errorf("the state should be used only once")
+	}
+
+	// a forged nonce is rejected
+	state = f.authorize(t, mustAuthURL(t, p))
+	f.nonce = "forged"
+	if _, err := p.Exchange(ctx, state, state, "the-code"); err == nil {
+		t.Errorf("forged nonce should be rejected")
+	}
+
+	// the token signed by another key is rejected
+	state = f.authorize(t, mustAuthURL(t, p))
+	f.signKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+	if _, err := p.Exchange(ctx, state, state, "the-code"); err == nil {
+		t.Errorf("token signed by another key should be rejected")
+	}
+
+	// the wrong pkce verifier is rejected by the provider
+	f.signKey = f.key
+	state = f.authorize(t, mustAuthURL(t, p))
+	f.challenge = "forged"
+	if _, err := p.Exchange(ctx, state, state, "the-code"); err == nil {
+		t.Errorf("wrong code verifier should be rejected")
+	}
+}
+
+func mustAuthURL(t *testing.T, p *Provider) string {
+	authURL, _, err := p.AuthURL(context.Background(), "http://localhost/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return authURL
+}
+
+func TestParseProviders(t *testing.T) {
+	for _, s := range []string{
+		`[{"name": "a b", "issuer": "https://idp", "client_id": "x"}]`,
+		`[{"name": "a", "client_id": "x"}]`,
+		`[{"name": "a", "issuer": "https://idp", "client_id": "x"}, {"name": "a", "issuer": "https://idp2", "client_id": "y"}]`,
+		`{}`,
+	} {
+		if _, err := parseProviders(s); err == nil {
+			t.Errorf("expect error for %s", s)
+		}
+	}
+	providers, err := parseProviders(`[{"name": "a", "issuer": "https://idp/", "client_id": "x"}, {"name": "b", "issuer": "https://idp2", "client_id": "y", "scopes": ["openid", "email"]}]`)
+	if err != nil {
+		t.Fatalf("failed parse providers: %+v", err)
+	}
+	if providers[0].Issuer != "https://idp" || providers[0].UsernameClaim != "preferred_username" || len(providers[0].Scopes) != 1 {
+		t.Errorf("unexpected defaults: %+v", providers[0])
+	}
+	if len(providers[1].Scopes) != 2 {
+		t.Errorf("openid scope should not be duplicated: %+v", providers[1].Scopes)
+	}
+}
+
+func TestMapUser(t *testing.T) {
+	p := &Provider{
+		Name: "test",
+		GroupMapping: []model.GroupMapping{
+			{Group: "staff", Permission: 1 << 8, BasePath: "/home/{username}"},
+			{Group: "editors", Permission: 1 << 3, BasePath: "/shared"},
+			{Group: "admins", Permission: 1 << 9},
+		},
+		DefaultPermission: 1,
+		DefaultBasePath:   "/public",
+	}
+	user := p.mapUser(&Identity{Username: "alice", Groups: []string{"staff", "editors"}})
+	if user.Role != model.GENERAL || user.Permission != 1<<8|1<<3 || user.BasePath != "/home/alice" || user.Source != "oidc:test" {
+		t.Errorf("unexpected mapped user: %+v", user)
+	}
+	user = p.mapUser(&Identity{Username: "bob"})
+	if user.Permission != 1 || user.BasePath != "/public" {
+		t.Errorf("expect the default permission and base path, got %+v", user)
+	}
+	if groups := groupsClaim("a, b"); len(groups) != 2 {
+		t.Errorf("unexpected groups: %v", groups)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	f := newFakeProvider(t)
+	providers, err := parseProviders(`[{"name": "test", "issuer": "` + f.URL + `", "client_id": "alist", "scopes": ["profile"], "auto_register": true}]`)
+	if err != nil {
+		t.Fatalf("failed parse providers: %+v", err)
+	}
+	p := &providers[0]
+	ctx := context.Background()
+	login := func() (*model.User, error) {
+		state := f.authorize(t, mustAuthURL(t, p))
+		return p.Login(ctx, state, state, "the-code")
+	}
+	// registered on first login with the subject
+	user, err := login()
+	if err != nil || user.Username != "alice" || user.SsoID != f.URL+"#1" {
+		t.Fatalf("expect the user registered, got %+v %v", user, err)
+	}
+	defer func() {
+		_ = op.DeleteUserById(user.ID)
+	}()
+
+	// the renamed user at the provider is still the same user
+	f.username = "renamed"
+	if got, err := login(); err != nil || got.ID != user.ID || got.Username != "alice" {
+		t.Errorf("expect the user found by the subject, got %+v %v", got, err)
+	}
+
+	// another user at the provider can't take over alice by the username
+	f.sub, f.username = "2", "alice"
+	if got, err := login(); err == nil {
+		t.Errorf("expect the user of the same name not bound, got %+v", got)
+	}
+	// nor the local users
+	local := &model.User{Username: "bob", Role: model.GENERAL}
+	if err := op.CreateUser(local); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = op.DeleteUserById(local.ID)
+	}()
+	f.username = "bob"
+	if got, err := login(); err == nil {
+		t.Errorf("expect the local user not bound, got %+v", got)
+	}
+}
@@ -0,0 +1,94 @@
+package op
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// ApiTokenPrefix distinguish the api tokens from the jwt and the admin token
+const ApiTokenPrefix = "alist-pat-"
+
+// the last used time is updated at most once per minute
+const lastUsedInterval = time.Minute
+
+func IsApiToken(token string) bool {
+	return strings.HasPrefix(token, ApiTokenPrefix)
+}
+
+// CreateApiToken save the token and returns the raw token, which can't be got again
+func CreateApiToken(t *model.ApiToken) (string, error) {
+	scopes := strings.Split(t.Scopes, ",")
+	for i := range scopes {
+		scopes[i] = strings.TrimSpace(scopes[i])
+		if !utils.SliceContains(model.Scopes, scopes[i]) {
+			return "", errors.Errorf("invalid scope: %s", scopes[i])
+		}
+	}
+	t.Scopes = strings.Join(scopes, ",")
+	if t.PathPrefix != "" {
+		t.PathPrefix = utils.FixAndCleanPath(t.PathPrefix)
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.WithStack(err)
+	}
+	raw := ApiTokenPrefix + hex.EncodeToString(b)
+	t.TokenHash = utils.GetSHA256Encode(raw)
+	t.Created = time.Now()
+	if err := db.CreateApiToken(t); err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+func GetApiTokensByUser(userID uint) ([]model.ApiToken, error) {
+	return db.GetApiTokensByUser(userID)
+}
+
+// DeleteApiToken revoke the token of the user
+func DeleteApiToken(userID, id uint) error {
+	t, err := db.GetApiTokenById(id)
+	if err != nil {
+		return err
+	}
+	if t.UserID != userID {
+		return errors.WithStack(errs.InvalidApiToken)
+	}
+	return db.DeleteApiTokenById(id)
+}
+
+// GetUserByApiToken validate the raw token and returns a copy of its user
+// whose base path is narrowed to the path prefix of the token
+func GetUserByApiToken(raw string) (*model.User, *model.ApiToken, error) {
+	t, err := db.GetApiTokenByHash(utils.GetSHA256Encode(raw))
+	if err != nil {
+		return nil, nil, errors.WithStack(errs.InvalidApiToken)
+	}
+	if t.IsExpired() {
+		return nil, nil, errors.WithStack(errs.ExpiredApiToken)
+	}
+	user, err := GetUserById(t.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if t.PathPrefix != "" {
+		user.BasePath = utils.FixAndCleanPath(stdpath.Join(user.BasePath, t.PathPrefix))
+	}
+	now := time.Now()
+	if t.LastUsed == nil || now.Sub(*t.LastUsed) > lastUsedInterval {
+		t.LastUsed = &now
+		if err := db.UpdateApiTokenLastUsed(t.ID, now); err != nil {
+			utils.Log.Warnf("failed update last used time of api token: %+v", err)
+		}
+	}
+	return user, t, nil
+}
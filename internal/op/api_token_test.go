@@ -0,0 +1,58 @@
+package op_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func TestApiToken(t *testing.T) {
+	user := &model.User{Username: "token_user", Password: "pwd", BasePath: "/base"}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	if _, err := op.CreateApiToken(&model.ApiToken{UserID: user.ID, Scopes: "read,unknown"}); err == nil {
+		t.Errorf("unknown scope should be rejected")
+	}
+	apiToken := model.ApiToken{UserID: user.ID, Name: "ci", Scopes: "read, write", PathPrefix: "sub"}
+	raw, err := op.CreateApiToken(&apiToken)
+	if err != nil {
+		t.Fatalf("failed create api token: %+v", err)
+	}
+	if !op.IsApiToken(raw) || apiToken.TokenHash == raw {
+		t.Errorf("the raw token should be prefixed and not stored")
+	}
+	u, got, err := op.GetUserByApiToken(raw)
+	if err != nil {
+		t.Fatalf("failed get user by api token: %+v", err)
+	}
+	if u.BasePath != "/base/sub" || !got.HasScope(model.ScopeWrite) || got.HasScope(model.ScopeAdmin) {
+		t.Errorf("unexpected user %+v or token %+v", u, got)
+	}
+	if _, _, err := op.GetUserByApiToken(raw + "x"); !errors.Is(err, errs.InvalidApiToken) {
+		t.Errorf("expect invalid token, got %+v", err)
+	}
+
+	expiresAt := time.Now().Add(-time.Minute)
+	expired, err := op.CreateApiToken(&model.ApiToken{UserID: user.ID, Scopes: "read", ExpiresAt: &expiresAt})
+	if err != nil {
+		t.Fatalf("failed create api token: %+v", err)
+	}
+	if _, _, err := op.GetUserByApiToken(expired); !errors.Is(err, errs.ExpiredApiToken) {
+		t.Errorf("expect expired token, got %+v", err)
+	}
+
+	if err := op.DeleteApiToken(user.ID+1, apiToken.ID); err == nil {
+		t.Errorf("the token of other user should not be revoked")
+	}
+	if err := op.DeleteApiToken(user.ID, apiToken.ID); err != nil {
+		t.Fatalf("failed revoke api token: %+v", err)
+	}
+	if _, _, err := op.GetUserByApiToken(raw); err == nil {
+		t.Errorf("the revoked token should be rejected")
+	}
+}
@@ -102,6 +102,36 @@ func getMainItems(config driver.Config) []driver.Item {
 			Required: true,
 		})
 	}
+	if config.RequestPolicy {
+		items = append(items, []driver.Item{{
+			Name:    "max_retries",
+			Type:    conf.TypeNumber,
+			Default: "3",
+			Help:    "max retries of the upstream api request on network error, 429 or 5xx, negative to disable",
+		}, {
+			Name:    "qps",
+			Type:    conf.TypeNumber,
+			Default: "0",
+			Help:    "max requests per second to the upstream api, 0 means unlimited",
+		}}...)
+	}
+	if !config.OnlyLocal {
+		items = append(items, []driver.Item{{
+			Name: "block_cache",
+			Type: conf.TypeBool,
+			Help: "cache the content proxied by the server on local disk",
+		}, {
+			Name:    "down_concurrency",
+			Type:    conf.TypeNumber,
+			Default: "0",
+			Help:    "download the proxied or copied file by the concurrent range requests, less than 2 to disable",
+		}, {
+			Name:    "down_chunk_size",
+			Type:    conf.TypeNumber,
+			Default: "4",
+			Help:    "the size of each range request in MB",
+		}}...)
+	}
 	items = append(items, driver.Item{
 		Name: "down_proxy_url",
 		Type: conf.TypeText,
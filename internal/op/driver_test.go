@@ -15,3 +15,20 @@ func TestDriverItemsMap(t *testing.T) {
 		t.Errorf("expected driverInfoMap not empty, but got empty")
 	}
 }
+
+func TestRequestPolicyItems(t *testing.T) {
+	hasRetries := func(name string) bool {
+		for _, item := range op.GetDriverInfoMap()[name].Common {
+			if item.Name == "max_retries" {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasRetries("AliyundriveOpen") {
+		t.Errorf("expect max_retries for the driver honor the request policy")
+	}
+	if hasRetries("Local") || hasRetries("S3") {
+		t.Errorf("expect no max_retries for the driver ignore the request policy")
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"github.com/Xhofe/go-cache"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/listcache"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/pkg/generic_sync"
 	"github.com/alist-org/alist/v3/pkg/singleflight"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -19,11 +21,13 @@ import (
 
 // In order to facilitate adding some other things before and after file op
 
-var listCache = cache.NewMemCache(cache.WithShards[[]model.Obj](64))
+var listCache = listcache.NewMemory()
 var listG singleflight.Group[[]model.Obj]
 
 func updateCacheObj(storage driver.Driver, path string, oldObj model.Obj, newObj model.Obj) {
 	key := Key(storage, path)
+	clearPageCache(key)
+	defer listCache.Publish(key)
 	objs, ok := listCache.Get(key)
 	if ok {
 		for i, obj := range objs {
@@ -32,12 +36,14 @@ func updateCacheObj(storage driver.Driver, path string, oldObj model.Obj, newObj
 				break
 			}
 		}
-		listCache.Set(key, objs, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+		listCache.Set(key, objs, listCacheTTL(storage))
 	}
 }
 
 func delCacheObj(storage driver.Driver, path string, obj model.Obj) {
 	key := Key(storage, path)
+	clearPageCache(key)
+	defer listCache.Publish(key)
 	objs, ok := listCache.Get(key)
 	if ok {
 		for i, oldObj := range objs {
@@ -46,7 +52,7 @@ func delCacheObj(storage driver.Driver, path string, obj model.Obj) {
 				break
 			}
 		}
-		listCache.Set(key, objs, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+		listCache.Set(key, objs, listCacheTTL(storage))
 	}
 }
 
@@ -54,6 +60,8 @@ var addSortDebounceMap generic_sync.MapOf[string, func(func())]
 
 func addCacheObj(storage driver.Driver, path string, newObj model.Obj) {
 	key := Key(storage, path)
+	clearPageCache(key)
+	defer listCache.Publish(key)
 	objs, ok := listCache.Get(key)
 	if ok {
 		for i, obj := range objs {
@@ -80,12 +88,28 @@ func addCacheObj(storage driver.Driver, path string, newObj model.Obj) {
 			})
 		}
 
-		listCache.Set(key, objs, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+		listCache.Set(key, objs, listCacheTTL(storage))
 	}
 }
 
+// SetListCache replace the backend of the listings cache, the previous one is closed.
+// The pages cached are dropped with the listings changed by the other nodes
+func SetListCache(backend listcache.Backend) {
+	backend.Subscribe(clearPageCache)
+	old := listCache
+	listCache = backend
+	_ = old.Close()
+}
+
+func listCacheTTL(storage driver.Driver) time.Duration {
+	return time.Minute * time.Duration(storage.GetStorage().CacheExpiration)
+}
+
 func ClearCache(storage driver.Driver, path string) {
-	listCache.Del(Key(storage, path))
+	key := Key(storage, path)
+	listCache.Del(key)
+	clearPageCache(key)
+	listCache.Publish(key)
 }
 
 func Key(storage driver.Driver, path string) string {
@@ -143,7 +167,7 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 		if !storage.Config().NoCache {
 			if len(files) > 0 {
 				log.Debugf("set cache: %s => %+v", key, files)
-				listCache.Set(key, files, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+				listCache.Set(key, files, listCacheTTL(storage))
 			} else {
 				log.Debugf("del cache: %s", key)
 				listCache.Del(key)
@@ -151,6 +175,10 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 		}
 		return files, nil
 	})
+	if err == nil && utils.IsBool(refresh...) {
+		// refreshed by the user, the other nodes drop the stale listing
+		listCache.Publish(key)
+	}
 	return objs, err
 }
 
@@ -261,6 +289,11 @@ func Link(ctx context.Context, storage driver.Driver, path string, args model.Li
 	return link, file, err
 }
 
+// ClearLinkCache drop the cached link, so the next Link gets a new one from the driver
+func ClearLinkCache(storage driver.Driver, path string, ip string) {
+	linkCache.Del(Key(storage, path) + ":" + ip)
+}
+
 // Other api
 func Other(ctx context.Context, storage driver.Driver, args model.FsOtherArgs) (interface{}, error) {
 	obj, err := GetUnwrap(ctx, storage, args.Path)
@@ -483,7 +516,7 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file *mo
 		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
 	}
 	defer func() {
-		if f, ok := file.GetReadCloser().(*os.File); ok {
+		if f, ok := file.ReadCloser.(*os.File); ok && !file.KeepFile {
 			err := os.RemoveAll(f.Name())
 			if err != nil {
 				log.Errorf("failed to remove file [%s]", f.Name())
@@ -530,25 +563,38 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file *mo
 	if up == nil {
 		up = func(p int) {}
 	}
+	if err = stream.Prepare(storage.Config(), file); err != nil {
+		return errors.WithMessagef(err, "failed to prepare stream of [%s]", file.GetName())
+	}
 
-	switch s := storage.(type) {
-	case driver.PutResult:
-		var newObj model.Obj
-		newObj, err = s.Put(ctx, parentDir, file, up)
-		if err == nil {
-			if newObj != nil {
-				addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
-			} else if !utils.IsBool(lazyCache...) {
-				ClearCache(storage, dstDirPath)
-			}
-		}
-	case driver.Put:
-		err = s.Put(ctx, parentDir, file, up)
+	if r, ok := storage.(driver.ResumablePut); ok && file.Checkpoint != nil {
+		err = r.PutResumable(ctx, parentDir, file, file.Checkpoint, up)
 		if err == nil && !utils.IsBool(lazyCache...) {
 			ClearCache(storage, dstDirPath)
 		}
-	default:
-		return errs.NotImplement
+	} else {
+		switch s := storage.(type) {
+		case driver.PutResult:
+			var newObj model.Obj
+			newObj, err = s.Put(ctx, parentDir, file, up)
+			if err == nil {
+				if newObj != nil {
+					addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
+				} else if !utils.IsBool(lazyCache...) {
+					ClearCache(storage, dstDirPath)
+				}
+			}
+		case driver.Put:
+			err = s.Put(ctx, parentDir, file, up)
+			if err == nil && !utils.IsBool(lazyCache...) {
+				ClearCache(storage, dstDirPath)
+			}
+		default:
+			return errs.NotImplement
+		}
+	}
+	if err == nil {
+		stream.CountUnread(file)
 	}
 	log.Debugf("put file [%s] done", file.GetName())
 	if storage.Config().NoOverwriteUpload && fi != nil && fi.GetSize() > 0 {
@@ -78,15 +78,35 @@ func UpdateMeta(u *model.Meta) error {
 		return err
 	}
 	metaCache.Del(old.Path)
+	if err := hashMetaPassword(u); err != nil {
+		return err
+	}
 	return db.UpdateMeta(u)
 }
 
 func CreateMeta(u *model.Meta) error {
 	u.Path = utils.FixAndCleanPath(u.Path)
 	metaCache.Del(u.Path)
+	if err := hashMetaPassword(u); err != nil {
+		return err
+	}
 	return db.CreateMeta(u)
 }
 
+// hashMetaPassword hash the password if it's changed,
+// the hash is sent back unchanged if the password is not modified
+func hashMetaPassword(m *model.Meta) error {
+	if m.Password == "" || utils.IsPasswordHashed(m.Password) {
+		return nil
+	}
+	hash, err := utils.HashPassword(m.Password)
+	if err != nil {
+		return err
+	}
+	m.Password = hash
+	return nil
+}
+
 func GetMetaById(id uint) (*model.Meta, error) {
 	return db.GetMetaById(id)
 }
@@ -0,0 +1,155 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// dirPages is the pages of a dir fetched in order, the cursor of a page is the NextCursor of the previous one
+type dirPages struct {
+	sync.Mutex
+	perPage int
+	pages   []*model.ObjsPage
+}
+
+var pageCache = cache.NewMemCache(cache.WithShards[*dirPages](64))
+
+func clearPageCache(key string) {
+	pageCache.Del(key)
+}
+
+// ListPage list the page of the dir by the driver.PagedLister, so only the requested page and the pages before it are fetched.
+// The fetched pages are cached, and the page is sliced from the whole listing if it's cached.
+// more is true if there are more pages, errs.NotImplement is returned if the driver doesn't support it
+// or the storage is sorted locally
+func ListPage(ctx context.Context, storage driver.Driver, path string, args model.ListArgs, page, perPage int, refresh ...bool) (objs []model.Obj, more bool, err error) {
+	lister, ok := storage.(driver.PagedLister)
+	// the objs can't be sorted locally page by page
+	if !ok || (storage.Config().LocalSort && storage.GetStorage().OrderBy != "") {
+		return nil, false, errs.NotImplement
+	}
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return nil, false, errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	path = utils.FixAndCleanPath(path)
+	log.Debugf("op.ListPage %s, page: %d", path, page)
+	key := Key(storage, path)
+	if utils.IsBool(refresh...) {
+		listCache.Del(key)
+		clearPageCache(key)
+		listCache.Publish(key)
+	} else if files, ok := listCache.Get(key); ok {
+		log.Debugf("use cache when list page %s", path)
+		start := (page - 1) * perPage
+		if start >= len(files) {
+			return []model.Obj{}, false, nil
+		}
+		end := start + perPage
+		if end > len(files) {
+			end = len(files)
+		}
+		return files[start:end], end < len(files), nil
+	}
+	dir, err := GetUnwrap(ctx, storage, path)
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "failed get dir")
+	}
+	if !dir.IsDir() {
+		return nil, false, errors.WithStack(errs.NotFolder)
+	}
+	dp, ok := pageCache.Get(key)
+	if !ok || dp.perPage != perPage {
+		dp = &dirPages{perPage: perPage}
+		if !storage.Config().NoCache {
+			pageCache.Set(key, dp, cache.WithEx[*dirPages](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+		}
+	}
+	dp.Lock()
+	defer dp.Unlock()
+	for len(dp.pages) < page {
+		cursor := ""
+		if n := len(dp.pages); n > 0 {
+			cursor = dp.pages[n-1].NextCursor
+			if cursor == "" {
+				// the page is after the last one
+				return []model.Obj{}, false, nil
+			}
+		}
+		p, err := listPage(ctx, storage, lister, dir, model.ListPageArgs{ReqPath: args.ReqPath, Cursor: cursor, PerPage: perPage})
+		if err != nil {
+			return nil, false, err
+		}
+		dp.pages = append(dp.pages, p)
+	}
+	p := dp.pages[page-1]
+	return p.Objs, p.NextCursor != "", nil
+}
+
+// WalkPages call fn with the pages of the dir in order without caching them, so a huge dir can be streamed.
+// The dir is listed as a whole if the driver doesn't implement driver.PagedLister or the storage is sorted locally
+func WalkPages(ctx context.Context, storage driver.Driver, path string, args model.ListArgs, perPage int, fn func(objs []model.Obj) error) error {
+	lister, ok := storage.(driver.PagedLister)
+	if !ok || (storage.Config().LocalSort && storage.GetStorage().OrderBy != "") {
+		objs, err := List(ctx, storage, path, args)
+		if err != nil {
+			return err
+		}
+		return fn(objs)
+	}
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	path = utils.FixAndCleanPath(path)
+	if files, ok := listCache.Get(Key(storage, path)); ok {
+		return fn(files)
+	}
+	dir, err := GetUnwrap(ctx, storage, path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dir")
+	}
+	if !dir.IsDir() {
+		return errors.WithStack(errs.NotFolder)
+	}
+	cursor := ""
+	for {
+		p, err := listPage(ctx, storage, lister, dir, model.ListPageArgs{ReqPath: args.ReqPath, Cursor: cursor, PerPage: perPage})
+		if err != nil {
+			return err
+		}
+		if err = fn(p.Objs); err != nil {
+			return err
+		}
+		if p.NextCursor == "" {
+			return nil
+		}
+		cursor = p.NextCursor
+	}
+}
+
+func listPage(ctx context.Context, storage driver.Driver, lister driver.PagedLister, dir model.Obj, args model.ListPageArgs) (*model.ObjsPage, error) {
+	p, err := lister.ListPage(ctx, dir, args)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list page")
+	}
+	// set path
+	for _, f := range p.Objs {
+		if s, ok := f.(model.SetPath); ok && f.GetPath() == "" && dir.GetPath() != "" {
+			s.SetPath(stdpath.Join(dir.GetPath(), f.GetName()))
+		}
+	}
+	// warp obj name
+	model.WrapObjsName(p.Objs)
+	// the pages are in the order of the upstream, only the folders in the page are extracted
+	model.ExtractFolder(p.Objs, storage.GetStorage().ExtractFolder)
+	return p, nil
+}
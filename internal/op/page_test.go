@@ -0,0 +1,146 @@
+package op_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/listcache"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+// pagedDriver list n files by the pages, the cursor is the offset
+type pagedDriver struct {
+	model.Storage
+	n     int
+	pages int
+}
+
+func (d *pagedDriver) Config() driver.Config          { return driver.Config{Name: "Paged"} }
+func (d *pagedDriver) GetAddition() driver.Additional { return &struct{}{} }
+func (d *pagedDriver) Init(ctx context.Context) error { return nil }
+func (d *pagedDriver) Drop(ctx context.Context) error { return nil }
+func (d *pagedDriver) Get(ctx context.Context, path string) (model.Obj, error) {
+	return &model.Object{Name: "dir", Path: path, IsFolder: true}, nil
+}
+
+func (d *pagedDriver) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	return nil, fmt.Errorf("the dir should be listed by pages")
+}
+
+func (d *pagedDriver) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	return nil, nil
+}
+
+func (d *pagedDriver) ListPage(ctx context.Context, dir model.Obj, args model.ListPageArgs) (*model.ObjsPage, error) {
+	d.pages++
+	start, _ := strconv.Atoi(args.Cursor)
+	page := &model.ObjsPage{}
+	for i := start; i < d.n && i < start+args.PerPage; i++ {
+		page.Objs = append(page.Objs, &model.Object{Name: fmt.Sprintf("file%d", i)})
+	}
+	if start+args.PerPage < d.n {
+		page.NextCursor = strconv.Itoa(start + args.PerPage)
+	}
+	return page, nil
+}
+
+func TestListPage(t *testing.T) {
+	d := &pagedDriver{n: 25}
+	d.MountPath = "/paged"
+	d.CacheExpiration = 30
+	ctx := context.Background()
+
+	objs, more, err := op.ListPage(ctx, d, "/", model.ListArgs{}, 3, 10)
+	if err != nil {
+		t.Fatalf("failed list page: %+v", err)
+	}
+	if len(objs) != 5 || objs[0].GetName() != "file20" || more {
+		t.Errorf("expect the last 5 files, got %d files, more: %v", len(objs), more)
+	}
+	if d.pages != 3 {
+		t.Errorf("expect 3 pages fetched by the cursors, got %d", d.pages)
+	}
+
+	// the pages before are cached
+	objs, more, err = op.ListPage(ctx, d, "/", model.ListArgs{}, 2, 10)
+	if err != nil || len(objs) != 10 || objs[0].GetName() != "file10" || !more {
+		t.Errorf("expect the second page with more, got %d files, more: %v, err: %v", len(objs), more, err)
+	}
+	objs, more, _ = op.ListPage(ctx, d, "/", model.ListArgs{}, 4, 10)
+	if len(objs) != 0 || more {
+		t.Errorf("expect an empty page after the last one, got %d files", len(objs))
+	}
+	if d.pages != 3 {
+		t.Errorf("expect the pages from the cache, got %d fetched", d.pages)
+	}
+
+	op.ClearCache(d, "/")
+	if _, _, err = op.ListPage(ctx, d, "/", model.ListArgs{}, 1, 10); err != nil || d.pages != 4 {
+		t.Errorf("expect the page fetched again after the cache cleared, got %d fetched, err: %v", d.pages, err)
+	}
+}
+
+func TestWalkPages(t *testing.T) {
+	d := &pagedDriver{n: 25}
+	d.MountPath = "/walk"
+	var names []string
+	err := op.WalkPages(context.Background(), d, "/", model.ListArgs{}, 10, func(objs []model.Obj) error {
+		for _, obj := range objs {
+			names = append(names, obj.GetName())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed walk pages: %+v", err)
+	}
+	if len(names) != 25 || names[24] != "file24" || d.pages != 3 {
+		t.Errorf("expect 25 files in 3 pages, got %d files in %d pages", len(names), d.pages)
+	}
+}
+
+// sharedCache is a list cache whose changes of the other nodes are triggered by the test
+type sharedCache struct {
+	listcache.Backend
+	subscribers []func(key string)
+	published   []string
+}
+
+func (c *sharedCache) Publish(key string) {
+	c.published = append(c.published, key)
+}
+
+func (c *sharedCache) Subscribe(fn func(key string)) {
+	c.subscribers = append(c.subscribers, fn)
+}
+
+func TestListPageChangedByOtherNode(t *testing.T) {
+	shared := &sharedCache{Backend: listcache.NewMemory()}
+	op.SetListCache(shared)
+	defer op.SetListCache(listcache.NewMemory())
+	d := &pagedDriver{n: 25}
+	d.MountPath = "/shared"
+	d.CacheExpiration = 30
+	ctx := context.Background()
+
+	if _, _, err := op.ListPage(ctx, d, "/", model.ListArgs{}, 1, 10); err != nil {
+		t.Fatalf("failed list page: %+v", err)
+	}
+	if len(shared.published) != 0 {
+		t.Errorf("expect the listing not published, got %v", shared.published)
+	}
+	for _, fn := range shared.subscribers {
+		fn("/shared")
+	}
+	if _, _, err := op.ListPage(ctx, d, "/", model.ListArgs{}, 1, 10); err != nil || d.pages != 2 {
+		t.Errorf("expect the page fetched again after changed by the other node, got %d fetched, err: %v", d.pages, err)
+	}
+
+	op.ClearCache(d, "/")
+	if len(shared.published) != 1 || shared.published[0] != "/shared" {
+		t.Errorf("expect the cleared listing published, got %v", shared.published)
+	}
+}
@@ -0,0 +1,67 @@
+package op
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	recoveryCodeCount    = 10
+	recoveryCodeAlphabet = "abcdefghijkmnpqrstuvwxyz23456789"
+)
+
+func newRecoveryCode() (string, error) {
+	var sb strings.Builder
+	for i := 0; i < 12; i++ {
+		if i > 0 && i%4 == 0 {
+			sb.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(recoveryCodeAlphabet))))
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		sb.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+// hashRecoveryCode ignore the case and the separators of the code
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return utils.GetSHA256Encode(code)
+}
+
+// GenerateRecoveryCodes replace the recovery codes of the user with new ones,
+// returns the raw codes which can't be got again
+func GenerateRecoveryCodes(userID uint) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i], hashes[i] = code, hashRecoveryCode(code)
+	}
+	if err := db.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func CountRecoveryCodes(userID uint) (int64, error) {
+	return db.CountRecoveryCodes(userID)
+}
+
+// UseRecoveryCode consume the code of the user, returns false if it's invalid or used
+func UseRecoveryCode(userID uint, code string) (bool, error) {
+	if strings.TrimSpace(code) == "" {
+		return false, nil
+	}
+	return db.DeleteRecoveryCode(userID, hashRecoveryCode(code))
+}
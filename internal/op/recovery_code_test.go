@@ -0,0 +1,49 @@
+package op_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func TestRecoveryCode(t *testing.T) {
+	user := &model.User{Username: "recovery_user", Password: "pwd", BasePath: "/"}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	codes, err := op.GenerateRecoveryCodes(user.ID)
+	if err != nil {
+		t.Fatalf("failed generate recovery codes: %+v", err)
+	}
+	if count, _ := op.CountRecoveryCodes(user.ID); count != int64(len(codes)) {
+		t.Errorf("expect %d recovery codes, got %d", len(codes), count)
+	}
+	// the case and the separators are ignored
+	if ok, err := op.UseRecoveryCode(user.ID, strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))); !ok || err != nil {
+		t.Errorf("failed use recovery code: %v %+v", ok, err)
+	}
+	if ok, _ := op.UseRecoveryCode(user.ID, codes[0]); ok {
+		t.Errorf("the recovery code should be used only once")
+	}
+	if ok, _ := op.UseRecoveryCode(user.ID+1, codes[1]); ok {
+		t.Errorf("the recovery code of other user should be rejected")
+	}
+
+	// regenerating invalidate the old codes
+	if _, err := op.GenerateRecoveryCodes(user.ID); err != nil {
+		t.Fatalf("failed regenerate recovery codes: %+v", err)
+	}
+	if ok, _ := op.UseRecoveryCode(user.ID, codes[1]); ok {
+		t.Errorf("the old recovery code should be invalidated")
+	}
+
+	// canceling 2FA remove the codes
+	if err := op.Cancel2FAByUser(user); err != nil {
+		t.Fatalf("failed cancel 2FA: %+v", err)
+	}
+	if count, _ := op.CountRecoveryCodes(user.ID); count != 0 {
+		t.Errorf("expect no recovery code, got %d", count)
+	}
+}
@@ -0,0 +1,142 @@
+package op
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// the sessions are checked on every request, so cache them for a while,
+// the revoked ones are removed from the cache immediately
+var sessionCache = cache.NewMemCache(cache.WithShards[*model.Session](2))
+
+func sessionExpiration() time.Duration {
+	return time.Duration(conf.Conf.TokenExpiresIn) * time.Hour
+}
+
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CreateSession create a session for the user logged in and returns the refresh token
+func CreateSession(user *model.User, ip, userAgent string) (*model.Session, string, error) {
+	refresh, err := newRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	s := &model.Session{
+		ID:          uuid.NewString(),
+		UserID:      user.ID,
+		IP:          ip,
+		UserAgent:   userAgent,
+		RefreshHash: utils.GetSHA256Encode(refresh),
+		Created:     now,
+		LastSeen:    now,
+		ExpiresAt:   now.Add(sessionExpiration()),
+	}
+	if err := db.CreateSession(s); err != nil {
+		return nil, "", err
+	}
+	// clean the expired sessions by the way
+	if err := db.DeleteSessionsExpiredBefore(now); err != nil {
+		utils.Log.Warnf("failed delete expired sessions: %+v", err)
+	}
+	return s, refresh, nil
+}
+
+// GetSession returns the session if it's not revoked or expired
+func GetSession(id string) (*model.Session, error) {
+	if id == "" {
+		return nil, errors.WithStack(errs.InvalidSession)
+	}
+	s, ok := sessionCache.Get(id)
+	if !ok {
+		var err error
+		s, err = db.GetSessionById(id)
+		if err != nil {
+			return nil, errors.WithStack(errs.InvalidSession)
+		}
+		sessionCache.Set(id, s, cache.WithEx[*model.Session](time.Minute))
+	}
+	if s.IsExpired() {
+		return nil, errors.WithStack(errs.InvalidSession)
+	}
+	now := time.Now()
+	if now.Sub(s.LastSeen) > time.Minute {
+		// the cached session is shared by the requests, update a copy of it
+		updated := *s
+		updated.LastSeen = now
+		s = &updated
+		sessionCache.Set(id, s, cache.WithEx[*model.Session](time.Minute))
+		if err := db.UpdateSessionLastSeen(id, now); err != nil {
+			utils.Log.Warnf("failed update last seen time of session: %+v", err)
+		}
+	}
+	return s, nil
+}
+
+// RefreshSession extend the session of the refresh token and rotate the refresh token
+func RefreshSession(refresh, ip, userAgent string) (*model.Session, string, error) {
+	s, err := db.GetSessionByRefreshHash(utils.GetSHA256Encode(refresh))
+	if err != nil || s.IsExpired() {
+		return nil, "", errors.WithStack(errs.InvalidSession)
+	}
+	newRefresh, err := newRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	s.RefreshHash = utils.GetSHA256Encode(newRefresh)
+	s.IP = ip
+	s.UserAgent = userAgent
+	s.LastSeen = now
+	s.ExpiresAt = now.Add(sessionExpiration())
+	if err := db.UpdateSession(s); err != nil {
+		return nil, "", err
+	}
+	sessionCache.Del(s.ID)
+	return s, newRefresh, nil
+}
+
+func GetSessionsByUser(userID uint) ([]model.Session, error) {
+	return db.GetSessionsByUser(userID)
+}
+
+// DeleteSession revoke the session of the user
+func DeleteSession(userID uint, id string) error {
+	s, err := db.GetSessionById(id)
+	if err != nil {
+		return err
+	}
+	if s.UserID != userID {
+		return errors.WithStack(errs.InvalidSession)
+	}
+	sessionCache.Del(id)
+	return db.DeleteSessionById(id)
+}
+
+// DeleteSessionsByUser revoke all sessions of the user
+func DeleteSessionsByUser(userID uint) error {
+	sessions, err := db.GetSessionsByUser(userID)
+	if err != nil {
+		return err
+	}
+	for _, s := range sessions {
+		sessionCache.Del(s.ID)
+	}
+	return db.DeleteSessionsByUser(userID)
+}
@@ -0,0 +1,49 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func TestSession(t *testing.T) {
+	user := &model.User{Username: "session_user", Password: "pwd", BasePath: "/"}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	s, refresh, err := op.CreateSession(user, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("failed create session: %+v", err)
+	}
+	if _, err := op.GetSession(s.ID); err != nil {
+		t.Fatalf("failed get session: %+v", err)
+	}
+	if _, err := op.GetSession(""); err == nil {
+		t.Errorf("the token without session id should be rejected")
+	}
+
+	refreshed, newRefresh, err := op.RefreshSession(refresh, "127.0.0.2", "test")
+	if err != nil {
+		t.Fatalf("failed refresh session: %+v", err)
+	}
+	if refreshed.ID != s.ID || newRefresh == refresh {
+		t.Errorf("the session should be kept and the refresh token rotated")
+	}
+	if _, _, err := op.RefreshSession(refresh, "127.0.0.1", "test"); err == nil {
+		t.Errorf("the used refresh token should be rejected")
+	}
+
+	// changing password revoke all sessions and bump the token generation
+	gen := user.TokenGen
+	user.Password = "new"
+	if err := op.UpdateUser(user); err != nil {
+		t.Fatalf("failed update user: %+v", err)
+	}
+	if user.TokenGen != gen+1 {
+		t.Errorf("expect token generation %d, got %d", gen+1, user.TokenGen)
+	}
+	if _, err := op.GetSession(s.ID); err == nil {
+		t.Errorf("the session should be revoked after password change")
+	}
+}
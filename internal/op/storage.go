@@ -216,6 +216,40 @@ func DeleteStorageById(ctx context.Context, id uint) error {
 	return nil
 }
 
+// ReloadStorage drop the loaded driver of the storage if exists, then load it from database again
+func ReloadStorage(ctx context.Context, id uint) error {
+	storage, err := db.GetStorageById(id)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	if storage.Disabled {
+		return errors.Errorf("this storage have disabled")
+	}
+	if storageDriver, err := GetStorageByMountPath(storage.MountPath); err == nil {
+		if err := storageDriver.Drop(ctx); err != nil {
+			log.Warnf("failed drop storage [%s] before reload: %+v", storage.MountPath, err)
+		}
+	}
+	return LoadStorage(ctx, *storage)
+}
+
+// PingStorage check if the storage is usable, by driver.Pinger if implemented,
+// otherwise by listing the root folder without cache
+func PingStorage(ctx context.Context, storage driver.Driver) error {
+	if storage.GetStorage().Status != WORK {
+		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	if p, ok := storage.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	root, err := GetUnwrap(ctx, storage, "/")
+	if err != nil {
+		return errors.WithMessage(err, "failed get root")
+	}
+	_, err = storage.List(ctx, root, model.ListArgs{ReqPath: storage.GetStorage().MountPath})
+	return errors.WithMessage(err, "failed list root")
+}
+
 // MustSaveDriverStorage call from specific driver
 func MustSaveDriverStorage(driver driver.Driver) {
 	err := saveDriverStorage(driver)
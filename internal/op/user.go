@@ -9,6 +9,8 @@ import (
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/pkg/singleflight"
 	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
 var userCache = cache.NewMemCache(cache.WithShards[*model.User](2))
@@ -64,12 +66,25 @@ func GetUserById(id uint) (*model.User, error) {
 	return db.GetUserById(id)
 }
 
+// GetUserBySSOID returns the user bound to the id of the single sign on
+func GetUserBySSOID(ssoID string) (*model.User, error) {
+	if ssoID == "" {
+		return nil, errors.WithStack(gorm.ErrRecordNotFound)
+	}
+	return db.GetUserBySSOID(ssoID)
+}
+
 func GetUsers(pageIndex, pageSize int) (users []model.User, count int64, err error) {
 	return db.GetUsers(pageIndex, pageSize)
 }
 
 func CreateUser(u *model.User) error {
 	u.BasePath = utils.FixAndCleanPath(u.BasePath)
+	if u.Password != "" {
+		if err := u.SetPassword(u.Password); err != nil {
+			return err
+		}
+	}
 	return db.CreateUser(u)
 }
 
@@ -82,6 +97,18 @@ func DeleteUserById(id uint) error {
 		return errs.DeleteAdminOrGuest
 	}
 	userCache.Del(old.Username)
+	if err := db.DeleteApiTokensByUser(id); err != nil {
+		return err
+	}
+	if err := DeleteSessionsByUser(id); err != nil {
+		return err
+	}
+	if err := db.DeleteWebAuthnCredentialsByUser(id); err != nil {
+		return err
+	}
+	if err := db.ReplaceRecoveryCodes(id, nil); err != nil {
+		return err
+	}
 	return db.DeleteUserById(id)
 }
 
@@ -98,12 +125,40 @@ func UpdateUser(u *model.User) error {
 	}
 	userCache.Del(old.Username)
 	u.BasePath = utils.FixAndCleanPath(u.BasePath)
-	return db.UpdateUser(u)
+	// the issued tokens are invalidated on password change
+	u.TokenGen = old.TokenGen
+	u.Source = old.Source
+	revoke := u.Disabled && !old.Disabled
+	if u.Password != "" {
+		if err := u.SetPassword(u.Password); err != nil {
+			return err
+		}
+		u.TokenGen++
+		revoke = true
+	} else if u.PwdHash != old.PwdHash {
+		// the hash is set directly, such as imported from a bundle
+		u.TokenGen++
+		revoke = true
+	}
+	if err := db.UpdateUser(u); err != nil {
+		return err
+	}
+	if revoke {
+		return DeleteSessionsByUser(u.ID)
+	}
+	return nil
 }
 
+// Cancel2FAByUser remove all second factors of the user, the totp, the passkeys and the recovery codes
 func Cancel2FAByUser(u *model.User) error {
 	u.OtpSecret = ""
-	return UpdateUser(u)
+	if err := UpdateUser(u); err != nil {
+		return err
+	}
+	if err := db.DeleteWebAuthnCredentialsByUser(u.ID); err != nil {
+		return err
+	}
+	return db.ReplaceRecoveryCodes(u.ID, nil)
 }
 
 func Cancel2FAById(id uint) error {
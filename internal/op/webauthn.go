@@ -0,0 +1,48 @@
+package op
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetWebAuthnCredentialsByUser(userID uint) ([]model.WebAuthnCredential, error) {
+	return db.GetWebAuthnCredentialsByUser(userID)
+}
+
+func CreateWebAuthnCredential(c *model.WebAuthnCredential) error {
+	c.Created = time.Now()
+	return db.CreateWebAuthnCredential(c)
+}
+
+func UpdateWebAuthnCredentialUsed(c *model.WebAuthnCredential) error {
+	now := time.Now()
+	c.LastUsed = &now
+	return db.UpdateWebAuthnCredentialUsed(c.ID, c.Credential, now)
+}
+
+// DeleteWebAuthnCredential remove the passkey of the user
+func DeleteWebAuthnCredential(userID, id uint) error {
+	c, err := db.GetWebAuthnCredentialById(id)
+	if err != nil {
+		return err
+	}
+	if c.UserID != userID {
+		return errors.New("the passkey is not found")
+	}
+	return db.DeleteWebAuthnCredentialById(id)
+}
+
+// Is2FAEnabled check if the user has any second factor, the totp or the passkeys
+func Is2FAEnabled(u *model.User) (bool, error) {
+	if u.OtpSecret != "" {
+		return true, nil
+	}
+	credentials, err := db.GetWebAuthnCredentialsByUser(u.ID)
+	if err != nil {
+		return false, err
+	}
+	return len(credentials) > 0, nil
+}
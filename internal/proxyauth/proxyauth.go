@@ -0,0 +1,190 @@
+// Package proxyauth authenticate the users by the headers set by the trusted reverse proxy,
+// such as Authelia and oauth2-proxy, so that the users logged in at the edge needn't login again
+package proxyauth
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// Source is the source of the users provisioned by the reverse proxy
+const Source = "proxy"
+
+type Config struct {
+	TrustedProxies []*net.IPNet
+	UserHeader     string
+	GroupsHeader   string
+	AutoRegister   bool
+	// LocalUsers let the header login the existing users of other sources, such as the admin
+	LocalUsers        bool
+	GroupMapping      []model.GroupMapping
+	DefaultPermission int32
+	DefaultBasePath   string
+}
+
+func init() {
+	op.RegisterSettingItemHook(conf.ProxyAuthTrustedCIDRs, func(item *model.SettingItem) error {
+		_, err := parseCIDRs(item.Value)
+		return err
+	})
+	op.RegisterSettingItemHook(conf.ProxyAuthGroupMapping, func(item *model.SettingItem) error {
+		var mapping []model.GroupMapping
+		if err := utils.Json.UnmarshalFromString(item.Value, &mapping); err != nil {
+			return errors.Wrapf(err, "invalid %s", conf.ProxyAuthGroupMapping)
+		}
+		return nil
+	})
+}
+
+func Enabled() bool {
+	return setting.GetBool(conf.ProxyAuthEnabled)
+}
+
+// parseCIDRs parse the cidrs or ips separated by commas or lines
+func parseCIDRs(s string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, cidr := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '\n' || r == ' '
+	}) {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, errors.Errorf("invalid trusted proxy: %s", cidr)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid trusted proxy")
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+func getConfig() (*Config, error) {
+	trusted, err := parseCIDRs(setting.GetStr(conf.ProxyAuthTrustedCIDRs))
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{
+		TrustedProxies:    trusted,
+		UserHeader:        setting.GetStr(conf.ProxyAuthUserHeader, "Remote-User"),
+		GroupsHeader:      setting.GetStr(conf.ProxyAuthGroupsHeader),
+		AutoRegister:      setting.GetBool(conf.ProxyAuthAutoRegister),
+		LocalUsers:        setting.GetBool(conf.ProxyAuthLocalUsers),
+		DefaultPermission: int32(setting.GetInt(conf.ProxyAuthDefaultPermission, 0)),
+		DefaultBasePath:   setting.GetStr(conf.ProxyAuthDefaultBasePath, "/"),
+	}
+	if mapping := setting.GetStr(conf.ProxyAuthGroupMapping); mapping != "" {
+		if err := utils.Json.UnmarshalFromString(mapping, &cfg.GroupMapping); err != nil {
+			return nil, errors.Wrapf(err, "invalid %s", conf.ProxyAuthGroupMapping)
+		}
+	}
+	return cfg, nil
+}
+
+// isTrusted check the address of the peer rather than the forwarded ones, which can be forged
+func (cfg *Config) isTrusted(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range cfg.TrustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticate returns the user named by the header of the request from the trusted proxies,
+// nil is returned if it's disabled, or the request has no header or isn't from the trusted proxies
+func Authenticate(r *http.Request) (*model.User, error) {
+	if !Enabled() {
+		return nil, nil
+	}
+	cfg, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+	username := strings.TrimSpace(r.Header.Get(cfg.UserHeader))
+	if username == "" || !cfg.isTrusted(r.RemoteAddr) {
+		return nil, nil
+	}
+	var groups []string
+	if cfg.GroupsHeader != "" {
+		for _, g := range strings.Split(r.Header.Get(cfg.GroupsHeader), ",") {
+			if g = strings.TrimSpace(g); g != "" {
+				groups = append(groups, g)
+			}
+		}
+	}
+	return cfg.login(username, groups)
+}
+
+// login returns the user of the username, the users provisioned by the proxy
+// are created on first login and synced with the groups on every login
+func (cfg *Config) login(username string, groups []string) (*model.User, error) {
+	mapped := cfg.mapUser(username, groups)
+	user, err := op.GetUserByName(username)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if !cfg.AutoRegister {
+			return nil, errors.Errorf("user %s is not registered", username)
+		}
+		if err := op.CreateUser(&mapped); err != nil {
+			return nil, err
+		}
+		return &mapped, nil
+	}
+	// the existing users of other sources are trusted as is only if it's opted in,
+	// otherwise anyone can login as the admin once the proxy forward the header
+	if user.Source != Source {
+		if !cfg.LocalUsers {
+			return nil, errors.Errorf("user %s is not created by the proxy", username)
+		}
+		return user, nil
+	}
+	if user.Role != mapped.Role || user.Permission != mapped.Permission || user.BasePath != mapped.BasePath {
+		updated := *user
+		updated.Role, updated.Permission, updated.BasePath = mapped.Role, mapped.Permission, mapped.BasePath
+		if err := op.UpdateUser(&updated); err != nil {
+			return nil, err
+		}
+		return &updated, nil
+	}
+	return user, nil
+}
+
+// mapUser build the user with the role, permission and base path mapped from the groups
+func (cfg *Config) mapUser(username string, groups []string) model.User {
+	user := model.User{
+		Username: username,
+		Source:   Source,
+	}
+	user.ApplyGroupMapping(cfg.GroupMapping, func(group string) bool {
+		return utils.SliceContains(groups, group)
+	}, cfg.DefaultPermission, cfg.DefaultBasePath)
+	return user
+}
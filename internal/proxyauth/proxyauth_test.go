@@ -0,0 +1,107 @@
+package proxyauth
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+func TestIsTrusted(t *testing.T) {
+	trusted, err := parseCIDRs("10.0.0.0/8, 192.168.1.2\n::1")
+	if err != nil {
+		t.Fatalf("failed parse cidrs: %+v", err)
+	}
+	cfg := &Config{TrustedProxies: trusted}
+	for addr, expect := range map[string]bool{
+		"10.1.2.3:1234":    true,
+		"192.168.1.2:80":   true,
+		"192.168.1.3:80":   false,
+		"[::1]:8080":       true,
+		"[::2]:8080":       false,
+		"8.8.8.8:443":      false,
+		"@":                false,
+		"not an address":   false,
+		"10.0.0.1":         true,
+		"[fe80::1%lo]:123": false,
+	} {
+		if cfg.isTrusted(addr) != expect {
+			t.Errorf("expect trusted of %s to be %v", addr, expect)
+		}
+	}
+	if _, err := parseCIDRs("10.0.0.0/33"); err == nil {
+		t.Errorf("expect error for invalid cidr")
+	}
+	if _, err := parseCIDRs("localhost"); err == nil {
+		t.Errorf("expect error for invalid ip")
+	}
+	if nets, err := parseCIDRs(""); err != nil || len(nets) != 0 {
+		t.Errorf("expect no trusted proxy, got %v %+v", nets, err)
+	}
+}
+
+func TestMapUser(t *testing.T) {
+	cfg := &Config{
+		GroupMapping: []model.GroupMapping{
+			{Group: "admins", Permission: 1 << 9},
+			{Group: "users", Permission: 1 << 8, BasePath: "/home/{username}"},
+		},
+		DefaultPermission: 1,
+		DefaultBasePath:   "/",
+	}
+	user := cfg.mapUser("alice", []string{"users", "admins"})
+	if user.Role != model.GENERAL || user.Permission != 1<<8|1<<9 || user.BasePath != "/home/alice" || user.Source != Source {
+		t.Errorf("unexpected mapped user: %+v", user)
+	}
+	user = cfg.mapUser("bob", []string{"guests"})
+	if user.Role != model.GENERAL || user.Permission != 1 || user.BasePath != "/" {
+		t.Errorf("expect the default permission and base path, got %+v", user)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	if err := op.CreateUser(&model.User{Username: "root", Role: model.ADMIN, BasePath: "/"}); err != nil {
+		t.Fatalf("failed create admin: %+v", err)
+	}
+	cfg := &Config{
+		AutoRegister:      true,
+		GroupMapping:      []model.GroupMapping{{Group: "users", Permission: 1 << 8}},
+		DefaultPermission: 1,
+		DefaultBasePath:   "/",
+	}
+	// the local users can't be logged in by the header without opt in
+	if user, err := cfg.login("root", nil); err == nil {
+		t.Errorf("expect the admin not logged in by the header, got %+v", user)
+	}
+	cfg.LocalUsers = true
+	if user, err := cfg.login("root", nil); err != nil || !user.IsAdmin() {
+		t.Errorf("expect the admin logged in once opted in, got %+v %v", user, err)
+	}
+
+	// the users of the proxy are created and synced with the groups
+	user, err := cfg.login("carol", []string{"users"})
+	if err != nil || user.Source != Source || user.Permission != 1<<8 || user.Role != model.GENERAL {
+		t.Fatalf("expect the user created, got %+v %v", user, err)
+	}
+	user, err = cfg.login("carol", nil)
+	if err != nil || user.Permission != 1 {
+		t.Errorf("expect the user synced with the groups, got %+v %v", user, err)
+	}
+	cfg.AutoRegister = false
+	if _, err := cfg.login("dave", nil); err == nil {
+		t.Errorf("expect the user not registered")
+	}
+}
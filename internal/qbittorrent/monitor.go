@@ -84,6 +84,8 @@ func (m *Monitor) update() (bool, error) {
 		return true, err
 	}
 
+	m.tsk.SetTotalBytes(info.Size)
+	m.tsk.SetDoneBytes(info.Completed)
 	progress := float64(info.Completed) / float64(info.Size) * 100
 	m.tsk.SetProgress(int(progress))
 	switch info.State {
@@ -166,7 +168,9 @@ func (m *Monitor) complete() error {
 					},
 					ReadCloser: struct{ io.ReadSeekCloser }{f},
 					Mimetype:   mimetype,
+					CountRead:  tsk.AddDoneBytes,
 				}
+				tsk.SetTotalBytes(size)
 				return op.Put(tsk.Ctx, storage, dstDir, stream, tsk.SetProgress)
 			},
 		}))
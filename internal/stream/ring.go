@@ -0,0 +1,106 @@
+package stream
+
+import (
+	"io"
+	"sync"
+)
+
+// RingBuffer is a bounded pipe, Write is blocked when it's full and Read is blocked when it's empty,
+// so the writer goes ahead of the reader by at most the size of the buffer
+type RingBuffer struct {
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	buf      []byte
+	// r is the position to read, n is the buffered bytes
+	r, n int
+	// werr is returned by Read after the buffered bytes once the writer is closed
+	werr error
+	// rclosed is set once the reader is closed, then Write fails
+	rclosed bool
+}
+
+func NewRingBuffer(size int) *RingBuffer {
+	b := &RingBuffer{buf: make([]byte, size)}
+	b.notEmpty = sync.NewCond(&b.mu)
+	b.notFull = sync.NewCond(&b.mu)
+	return b
+}
+
+func (b *RingBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	written := 0
+	for len(p) > 0 {
+		for b.n == len(b.buf) && !b.rclosed && b.werr == nil {
+			b.notFull.Wait()
+		}
+		if b.rclosed || b.werr != nil {
+			return written, io.ErrClosedPipe
+		}
+		w := (b.r + b.n) % len(b.buf)
+		// the free space is contiguous until the end of the buffer or the read position
+		end := len(b.buf)
+		if w < b.r {
+			end = b.r
+		}
+		n := copy(b.buf[w:end], p)
+		b.n += n
+		written += n
+		p = p[n:]
+		b.notEmpty.Signal()
+	}
+	return written, nil
+}
+
+func (b *RingBuffer) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.n == 0 && !b.rclosed && b.werr == nil {
+		b.notEmpty.Wait()
+	}
+	if b.rclosed {
+		return 0, io.ErrClosedPipe
+	}
+	if b.n == 0 {
+		return 0, b.werr
+	}
+	end := b.r + b.n
+	if end > len(b.buf) {
+		end = len(b.buf)
+	}
+	n := copy(p, b.buf[b.r:end])
+	b.r = (b.r + n) % len(b.buf)
+	b.n -= n
+	b.notFull.Signal()
+	return n, nil
+}
+
+// CloseWithError close the writer, Read returns err after the buffered bytes, or io.EOF if err is nil
+func (b *RingBuffer) CloseWithError(err error) error {
+	if err == nil {
+		err = io.EOF
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.werr == nil {
+		b.werr = err
+	}
+	b.notEmpty.Broadcast()
+	b.notFull.Broadcast()
+	return nil
+}
+
+// Close close the reader, the buffered bytes are dropped and the blocked Write returns io.ErrClosedPipe
+func (b *RingBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rclosed = true
+	b.n = 0
+	b.notEmpty.Broadcast()
+	b.notFull.Broadcast()
+	return nil
+}
@@ -0,0 +1,130 @@
+package stream
+
+import (
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// readAheadSize is the max size of the ring buffer between the src and the upload
+const readAheadSize = 8 * 1024 * 1024
+
+// Prepare make the stream meet the needs of the driver before the upload.
+// The stream is stored in a temp file only if the driver needs a seekable stream or the hashes,
+// otherwise the src is read ahead into a ring buffer while the driver uploads
+func Prepare(config driver.Config, file *model.FileStream) error {
+	if config.NeedSeekableStream || len(config.PreHash) > 0 {
+		return Store(file, config.PreHash...)
+	}
+	readAhead(file)
+	return nil
+}
+
+// Store store the stream in a temp file, the hashes of the types are computed in the same pass.
+// The stream is not stored again if it's already a file, only the missing hashes are computed
+func Store(file *model.FileStream, types ...string) error {
+	hashes := map[string]hash.Hash{}
+	writers := make([]io.Writer, 0, len(types)+1)
+	for _, typ := range types {
+		if file.GetHash(typ) != "" {
+			continue
+		}
+		h := utils.NewHash(typ)
+		if h == nil {
+			return errors.Errorf("unknown hash type: %s", typ)
+		}
+		hashes[typ] = h
+		writers = append(writers, h)
+	}
+	rc := file.ReadCloser
+	if f, ok := rc.(*os.File); ok {
+		if len(hashes) == 0 {
+			return nil
+		}
+		// the stream may start in the middle of the file
+		pos, err := f.Seek(0, io.SeekCurrent)
+		if err == nil {
+			_, err = io.Copy(io.MultiWriter(writers...), f)
+		}
+		if err == nil {
+			_, err = f.Seek(pos, io.SeekStart)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to hash file")
+		}
+	} else {
+		f, err := os.CreateTemp(conf.Conf.TempDir, "file-*")
+		if err != nil {
+			return errors.Wrapf(err, "failed to create temp file")
+		}
+		// the bytes are counted while the src is downloaded, not again when the temp file is uploaded
+		_, err = io.Copy(io.MultiWriter(append(writers, f)...), file)
+		if err == nil {
+			_, err = f.Seek(0, io.SeekStart)
+		}
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+			return errors.Wrapf(err, "failed to store stream")
+		}
+		_ = rc.Close()
+		file.SetReadCloser(f)
+		// the temp file is removed after the upload
+		file.KeepFile = false
+	}
+	if file.Hashes == nil {
+		file.Hashes = map[string]string{}
+	}
+	for typ, h := range hashes {
+		file.Hashes[typ] = hex.EncodeToString(h.Sum(nil))
+	}
+	return nil
+}
+
+// CountUnread count the bytes of the stream not counted yet once the upload succeeded,
+// since the drivers may read the files by themselves, e.g. the temp files
+func CountUnread(file *model.FileStream) {
+	file.Count(file.GetSize())
+}
+
+// readAheadCloser closes both the ring buffer and the src,
+// so the goroutine reading the src is not blocked after the upload
+type readAheadCloser struct {
+	*RingBuffer
+	src io.Closer
+}
+
+func (r readAheadCloser) Close() error {
+	_ = r.RingBuffer.Close()
+	return r.src.Close()
+}
+
+// readAhead read the src into a ring buffer in a goroutine, so the src and the upload are not waiting for each other
+func readAhead(file *model.FileStream) {
+	rc := file.ReadCloser
+	if rc == nil || rc == http.NoBody || file.GetSize() <= 0 {
+		return
+	}
+	// the local files are fast enough
+	if _, ok := rc.(io.Seeker); ok {
+		return
+	}
+	size := int64(readAheadSize)
+	if file.GetSize() < size {
+		size = file.GetSize()
+	}
+	buf := NewRingBuffer(int(size))
+	go func() {
+		_, err := io.Copy(buf, rc)
+		_ = buf.CloseWithError(err)
+	}()
+	file.SetReadCloser(readAheadCloser{RingBuffer: buf, src: rc})
+}
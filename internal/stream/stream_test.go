@@ -0,0 +1,186 @@
+package stream
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+func TestRingBuffer(t *testing.T) {
+	data := make([]byte, 1024*1024+7)
+	_, _ = rand.Read(data)
+	buf := NewRingBuffer(4096)
+	go func() {
+		// write in odd sizes to wrap around the buffer
+		for p := data; len(p) > 0; {
+			n := 1000
+			if n > len(p) {
+				n = len(p)
+			}
+			if _, err := buf.Write(p[:n]); err != nil {
+				_ = buf.CloseWithError(err)
+				return
+			}
+			p = p[n:]
+		}
+		_ = buf.CloseWithError(nil)
+	}()
+	got, err := io.ReadAll(buf)
+	if err != nil {
+		t.Fatalf("failed read: %+v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("expect the same bytes read as written")
+	}
+}
+
+func TestRingBuffer_Close(t *testing.T) {
+	buf := NewRingBuffer(4)
+	srcErr := errors.New("src failed")
+	_, _ = buf.Write([]byte("ab"))
+	_ = buf.CloseWithError(srcErr)
+	got, err := io.ReadAll(buf)
+	if string(got) != "ab" || err != srcErr {
+		t.Errorf("expect the buffered bytes and the error of the writer, got %q, %v", got, err)
+	}
+
+	// the blocked writer returns once the reader is closed
+	buf = NewRingBuffer(4)
+	done := make(chan error)
+	go func() {
+		_, err := buf.Write([]byte("abcdefgh"))
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	_ = buf.Close()
+	select {
+	case err := <-done:
+		if err != io.ErrClosedPipe {
+			t.Errorf("expect %v, got %v", io.ErrClosedPipe, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("the writer is still blocked")
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	conf.Conf = conf.DefaultConfig()
+	conf.Conf.TempDir = t.TempDir()
+	data := []byte("hello world")
+	newFile := func() *model.FileStream {
+		return &model.FileStream{
+			Obj:        &model.Object{Name: "a.txt", Size: int64(len(data))},
+			ReadCloser: io.NopCloser(bytes.NewReader(data)),
+		}
+	}
+
+	// read ahead without storing
+	file := newFile()
+	if err := Prepare(driver.Config{}, file); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := file.GetReadCloser().(*os.File); ok {
+		t.Errorf("expect the stream not stored")
+	}
+	if got, _ := io.ReadAll(file); !bytes.Equal(got, data) {
+		t.Errorf("expect %q, got %q", data, got)
+	}
+	_ = file.Close()
+
+	// stored with the hashes
+	file = newFile()
+	if err := Prepare(driver.Config{PreHash: []string{utils.MD5, utils.SHA1}}, file); err != nil {
+		t.Fatal(err)
+	}
+	f, ok := file.GetReadCloser().(*os.File)
+	if !ok {
+		t.Fatalf("expect the stream stored in a temp file")
+	}
+	defer os.Remove(f.Name())
+	if got := file.GetHash(utils.MD5); got != utils.GetMD5Encode(string(data)) {
+		t.Errorf("expect md5 %s, got %s", utils.GetMD5Encode(string(data)), got)
+	}
+	if got := file.GetHash(utils.SHA1); got != utils.GetSHA1Encode(string(data)) {
+		t.Errorf("expect sha1 %s, got %s", utils.GetSHA1Encode(string(data)), got)
+	}
+	if got, _ := io.ReadAll(file); !bytes.Equal(got, data) {
+		t.Errorf("expect %q, got %q", data, got)
+	}
+	_ = file.Close()
+}
+
+func TestCountRead(t *testing.T) {
+	conf.Conf = conf.DefaultConfig()
+	conf.Conf.TempDir = t.TempDir()
+	data := []byte("hello world")
+	var counted int64
+	file := &model.FileStream{
+		Obj:        &model.Object{Name: "a.txt", Size: int64(len(data))},
+		ReadCloser: io.NopCloser(bytes.NewReader(data)),
+		CountRead:  func(n int64) { counted += n },
+	}
+	// the driver reading by GetReadCloser is counted
+	if _, err := io.ReadAll(file.GetReadCloser()); err != nil || counted != int64(len(data)) {
+		t.Errorf("expect the bytes read from the reader counted, got %d %v", counted, err)
+	}
+
+	// the stream stored in the temp file is counted while it's downloaded, not again when it's uploaded
+	counted = 0
+	file = &model.FileStream{
+		Obj:        &model.Object{Name: "a.txt", Size: int64(len(data))},
+		ReadCloser: io.NopCloser(bytes.NewReader(data)),
+		CountRead:  func(n int64) { counted += n },
+	}
+	if err := Store(file); err != nil {
+		t.Fatalf("failed store: %+v", err)
+	}
+	defer os.Remove(file.ReadCloser.(*os.File).Name())
+	if counted != int64(len(data)) {
+		t.Errorf("expect the bytes stored counted, got %d", counted)
+	}
+	if _, ok := file.GetReadCloser().(*os.File); !ok {
+		t.Fatalf("expect the temp file returned as it is")
+	}
+	_, _ = io.ReadAll(file)
+	CountUnread(file)
+	if counted != int64(len(data)) {
+		t.Errorf("expect the stored bytes not counted again, got %d", counted)
+	}
+
+	// the bytes the driver read by itself are counted after the upload
+	counted = 0
+	file = &model.FileStream{
+		Obj:        &model.Object{Name: "a.txt", Size: int64(len(data))},
+		ReadCloser: io.NopCloser(bytes.NewReader(data)),
+		CountRead:  func(n int64) { counted += n },
+	}
+	_, _ = file.Read(make([]byte, 2))
+	if counted != 2 {
+		t.Errorf("expect only the bytes read by the stream counted before the upload, got %d", counted)
+	}
+	CountUnread(file)
+	if counted != int64(len(data)) {
+		t.Errorf("expect the unread bytes counted after the upload, got %d", counted)
+	}
+
+	// the stream resumed starts at the offset of the checkpoint
+	counted = 0
+	file = &model.FileStream{
+		Obj:        &model.Object{Name: "a.txt", Size: int64(len(data))},
+		CountRead:  func(n int64) { counted += n },
+		Checkpoint: &model.UploadCheckpoint{Offset: 6},
+	}
+	CountUnread(file)
+	if counted != 5 {
+		t.Errorf("expect the bytes after the offset counted, got %d", counted)
+	}
+}
@@ -0,0 +1,267 @@
+// Package webauthn register the passkeys of the users and verify the assertions of them,
+// the passkeys can be used to login without password or as the second factor
+package webauthn
+
+import (
+	"encoding/base64"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/go-webauthn/webauthn/protocol"
+	gowebauthn "github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// the ceremony should be completed in the expiration after it begins
+const ceremonyExpiration = 5 * time.Minute
+
+type ceremony struct {
+	register bool
+	// 0 for the discoverable login, which the user is unknown until the assertion
+	userID  uint
+	session gowebauthn.SessionData
+}
+
+var ceremonies = cache.NewMemCache(cache.WithShards[*ceremony](2))
+
+// user implement the webauthn.User with the passkeys of the user
+type user struct {
+	*model.User
+	stored      []model.WebAuthnCredential
+	credentials []gowebauthn.Credential
+}
+
+func loadUser(u *model.User) (*user, error) {
+	stored, err := op.GetWebAuthnCredentialsByUser(u.ID)
+	if err != nil {
+		return nil, err
+	}
+	wu := &user{User: u, stored: stored, credentials: make([]gowebauthn.Credential, len(stored))}
+	for i := range stored {
+		if err := utils.Json.UnmarshalFromString(stored[i].Credential, &wu.credentials[i]); err != nil {
+			return nil, errors.Wrapf(err, "failed parse passkey %s", stored[i].Name)
+		}
+	}
+	return wu, nil
+}
+
+func (u *user) WebAuthnID() []byte {
+	return []byte(strconv.FormatUint(uint64(u.ID), 10))
+}
+
+func (u *user) WebAuthnName() string {
+	return u.Username
+}
+
+func (u *user) WebAuthnDisplayName() string {
+	return u.Username
+}
+
+func (u *user) WebAuthnIcon() string {
+	return ""
+}
+
+func (u *user) WebAuthnCredentials() []gowebauthn.Credential {
+	return u.credentials
+}
+
+// newWebAuthn create the relying party of the origin of the site,
+// the rp id is the host name of the origin
+func newWebAuthn(origin string) (*gowebauthn.WebAuthn, error) {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return nil, errors.Errorf("invalid origin: %s", origin)
+	}
+	return gowebauthn.New(&gowebauthn.Config{
+		RPID:          u.Hostname(),
+		RPDisplayName: setting.GetStr(conf.SiteTitle, "AList"),
+		RPOrigins:     []string{u.Scheme + "://" + u.Host},
+	})
+}
+
+func saveCeremony(c *ceremony) string {
+	id := uuid.NewString()
+	ceremonies.Set(id, c, cache.WithEx[*ceremony](ceremonyExpiration))
+	return id
+}
+
+// getCeremony returns the ceremony of the id, which can be used only once
+func getCeremony(id string, register bool) (*ceremony, error) {
+	c, ok := ceremonies.GetDel(id)
+	if !ok || c.register != register {
+		return nil, errors.New("invalid or expired webauthn session")
+	}
+	return c, nil
+}
+
+// BeginRegistration returns the options to create a passkey for the user and the id of the session
+func BeginRegistration(origin string, u *model.User) (*protocol.CredentialCreation, string, error) {
+	wa, err := newWebAuthn(origin)
+	if err != nil {
+		return nil, "", err
+	}
+	wu, err := loadUser(u)
+	if err != nil {
+		return nil, "", err
+	}
+	exclusions := make([]protocol.CredentialDescriptor, len(wu.credentials))
+	for i, c := range wu.credentials {
+		exclusions[i] = c.Descriptor()
+	}
+	creation, session, err := wa.BeginRegistration(wu,
+		gowebauthn.WithExclusions(exclusions),
+		// prefer the discoverable credentials, so that they can be used without username
+		gowebauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred))
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+	return creation, saveCeremony(&ceremony{register: true, userID: u.ID, session: *session}), nil
+}
+
+// FinishRegistration verify the attestation in the body and save the passkey
+func FinishRegistration(origin string, u *model.User, sessionID, name string, body io.Reader) (*model.WebAuthnCredential, error) {
+	c, err := getCeremony(sessionID, true)
+	if err != nil {
+		return nil, err
+	}
+	if c.userID != u.ID {
+		return nil, errors.New("the webauthn session is not of current user")
+	}
+	wa, err := newWebAuthn(origin)
+	if err != nil {
+		return nil, err
+	}
+	wu, err := loadUser(u)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBody(body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	credential, err := wa.CreateCredential(wu, c.session, parsed)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	data, err := utils.Json.MarshalToString(credential)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if name == "" {
+		name = "passkey " + time.Now().Format("2006-01-02 15:04")
+	}
+	stored := &model.WebAuthnCredential{
+		UserID:       u.ID,
+		Name:         name,
+		CredentialID: base64.RawURLEncoding.EncodeToString(credential.ID),
+		Credential:   data,
+	}
+	if err := op.CreateWebAuthnCredential(stored); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// BeginLogin returns the options to assert a passkey and the id of the session,
+// the passkeys of the user are allowed if the user is given, otherwise it's a
+// passwordless login with the discoverable passkeys, which must verify the user
+func BeginLogin(origin string, u *model.User) (*protocol.CredentialAssertion, string, error) {
+	wa, err := newWebAuthn(origin)
+	if err != nil {
+		return nil, "", err
+	}
+	var assertion *protocol.CredentialAssertion
+	var session *gowebauthn.SessionData
+	c := &ceremony{}
+	if u == nil {
+		assertion, session, err = wa.BeginDiscoverableLogin(
+			gowebauthn.WithUserVerification(protocol.VerificationRequired))
+	} else {
+		var wu *user
+		if wu, err = loadUser(u); err != nil {
+			return nil, "", err
+		}
+		c.userID = u.ID
+		assertion, session, err = wa.BeginLogin(wu)
+	}
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+	c.session = *session
+	return assertion, saveCeremony(c), nil
+}
+
+// FinishLogin verify the assertion in the body and returns the user of the passkey,
+// userID is the user expected by the second factor, 0 for the passwordless login
+func FinishLogin(origin, sessionID string, userID uint, body io.Reader) (*model.User, error) {
+	c, err := getCeremony(sessionID, false)
+	if err != nil {
+		return nil, err
+	}
+	if c.userID != userID {
+		return nil, errors.New("the webauthn session is not of the user")
+	}
+	wa, err := newWebAuthn(origin)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBody(body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var wu *user
+	var credential *gowebauthn.Credential
+	if c.userID == 0 {
+		credential, err = wa.ValidateDiscoverableLogin(func(rawID, userHandle []byte) (gowebauthn.User, error) {
+			id, err := strconv.ParseUint(string(userHandle), 10, 64)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			u, err := op.GetUserById(uint(id))
+			if err != nil {
+				return nil, err
+			}
+			wu, err = loadUser(u)
+			return wu, err
+		}, c.session, parsed)
+	} else {
+		var u *model.User
+		if u, err = op.GetUserById(c.userID); err != nil {
+			return nil, err
+		}
+		if wu, err = loadUser(u); err != nil {
+			return nil, err
+		}
+		credential, err = wa.ValidateLogin(wu, c.session, parsed)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if credential.Authenticator.CloneWarning {
+		return nil, errors.New("the sign count of the passkey is invalid, it may be cloned")
+	}
+	// save the new sign count
+	id := base64.RawURLEncoding.EncodeToString(credential.ID)
+	for i := range wu.stored {
+		if wu.stored[i].CredentialID != id {
+			continue
+		}
+		if wu.stored[i].Credential, err = utils.Json.MarshalToString(credential); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if err := op.UpdateWebAuthnCredentialUsed(&wu.stored[i]); err != nil {
+			return nil, err
+		}
+		break
+	}
+	return wu.User, nil
+}
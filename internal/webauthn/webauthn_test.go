@@ -0,0 +1,188 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/go-webauthn/webauthn/protocol"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const origin = "https://alist.example.com"
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+// authenticator is a virtual authenticator with an es256 key
+type authenticator struct {
+	key        *ecdsa.PrivateKey
+	id         []byte
+	userHandle []byte
+	count      uint32
+}
+
+func newAuthenticator(t *testing.T) *authenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return &authenticator{key: key, id: id}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (a *authenticator) authData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte("alist.example.com"))
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.count)
+	return append(data, attested...)
+}
+
+func clientData(t *testing.T, typ, challenge string) []byte {
+	data, err := utils.Json.Marshal(map[string]string{"type": typ, "challenge": challenge, "origin": origin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// create returns the attestation of the new credential in format none
+func (a *authenticator) create(t *testing.T, challenge string, userHandle []byte) []byte {
+	a.userHandle = userHandle
+	pub, err := cbor.Marshal(map[int]any{1: 2, 3: -7, -1: 1,
+		-2: a.key.X.FillBytes(make([]byte, 32)), -3: a.key.Y.FillBytes(make([]byte, 32))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attested := make([]byte, 16)
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.id)))
+	attested = append(append(attested, a.id...), pub...)
+	attestation, err := cbor.Marshal(map[string]any{"fmt": "none", "attStmt": map[string]any{}, "authData": a.authData(0x45, attested)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := utils.Json.Marshal(map[string]any{
+		"id": b64(a.id), "rawId": b64(a.id), "type": "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    b64(clientData(t, "webauthn.create", challenge)),
+			"attestationObject": b64(attestation),
+		},
+	})
+	return body
+}
+
+// get returns the assertion signed by the credential
+func (a *authenticator) get(t *testing.T, challenge string) []byte {
+	a.count++
+	authData := a.authData(0x05, nil)
+	cd := clientData(t, "webauthn.get", challenge)
+	hash := sha256.Sum256(cd)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), hash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := utils.Json.Marshal(map[string]any{
+		"id": b64(a.id), "rawId": b64(a.id), "type": "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    b64(cd),
+			"authenticatorData": b64(authData),
+			"signature":         b64(sig),
+			"userHandle":        b64(a.userHandle),
+		},
+	})
+	return body
+}
+
+func TestCeremonies(t *testing.T) {
+	user := &model.User{Username: "passkey_user", Password: "pwd", BasePath: "/"}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	a := newAuthenticator(t)
+
+	creation, session, err := BeginRegistration(origin, user)
+	if err != nil {
+		t.Fatalf("failed begin registration: %+v", err)
+	}
+	body := a.create(t, creation.Response.Challenge.String(), creation.Response.User.ID.(protocol.URLEncodedBase64))
+	if _, err := FinishRegistration(origin, &model.User{ID: user.ID + 1}, session, "", bytes.NewReader(body)); err == nil {
+		t.Errorf("the registration of other user should be rejected")
+	}
+	creation, session, _ = BeginRegistration(origin, user)
+	body = a.create(t, creation.Response.Challenge.String(), creation.Response.User.ID.(protocol.URLEncodedBase64))
+	credential, err := FinishRegistration(origin, user, session, "key", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed finish registration: %+v", err)
+	}
+	if credential.Name != "key" || credential.CredentialID != b64(a.id) {
+		t.Errorf("unexpected credential: %+v", credential)
+	}
+	if enabled, _ := op.Is2FAEnabled(user); !enabled {
+		t.Errorf("the passkey should enable 2FA")
+	}
+
+	// passwordless login
+	assertion, session, err := BeginLogin(origin, nil)
+	if err != nil {
+		t.Fatalf("failed begin login: %+v", err)
+	}
+	loggedIn, err := FinishLogin(origin, session, 0, bytes.NewReader(a.get(t, assertion.Response.Challenge.String())))
+	if err != nil {
+		t.Fatalf("failed finish login: %+v", err)
+	}
+	if loggedIn.ID != user.ID {
+		t.Errorf("expect user %d, got %d", user.ID, loggedIn.ID)
+	}
+	if _, err := FinishLogin(origin, session, 0, bytes.NewReader(a.get(t, assertion.Response.Challenge.String()))); err == nil {
+		t.Errorf("the session should be used only once")
+	}
+
+	// second factor of the user
+	assertion, session, _ = BeginLogin(origin, user)
+	if _, err := FinishLogin(origin, session, 0, bytes.NewReader(a.get(t, assertion.Response.Challenge.String()))); err == nil {
+		t.Errorf("the session of the second factor can't be used to login without password")
+	}
+	assertion, session, _ = BeginLogin(origin, user)
+	if _, err := FinishLogin(origin, session, user.ID, bytes.NewReader(a.get(t, assertion.Response.Challenge.String()))); err != nil {
+		t.Errorf("failed verify second factor: %+v", err)
+	}
+
+	// the replayed sign count means the authenticator may be cloned
+	a.count = 0
+	assertion, session, _ = BeginLogin(origin, user)
+	if _, err := FinishLogin(origin, session, user.ID, bytes.NewReader(a.get(t, assertion.Response.Challenge.String()))); err == nil {
+		t.Errorf("the cloned authenticator should be rejected")
+	}
+
+	// a wrong signature is rejected
+	other := newAuthenticator(t)
+	other.id, other.userHandle, other.count = a.id, a.userHandle, 100
+	assertion, session, _ = BeginLogin(origin, nil)
+	if _, err := FinishLogin(origin, session, 0, bytes.NewReader(other.get(t, assertion.Response.Challenge.String()))); err == nil {
+		t.Errorf("the assertion signed by other key should be rejected")
+	}
+}
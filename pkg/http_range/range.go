@@ -29,6 +29,40 @@ var (
 	ErrInvalid = errors.New("invalid range")
 )
 
+// ParseContentRange parses a Content-Range header string of a satisfied range as per RFC 7233.
+// The size is -1 if it's unknown.
+// ErrInvalid is returned if s is invalid or unsatisfied range.
+func ParseContentRange(s string) (Range, int64, error) {
+	const b = "bytes "
+	if !strings.HasPrefix(s, b) {
+		return Range{}, 0, ErrInvalid
+	}
+	ra, sizeStr, ok := strings.Cut(s[len(b):], "/")
+	if !ok {
+		return Range{}, 0, ErrInvalid
+	}
+	startStr, endStr, ok := strings.Cut(ra, "-")
+	if !ok {
+		return Range{}, 0, ErrInvalid
+	}
+	start, err := strconv.ParseInt(textproto.TrimString(startStr), 10, 64)
+	if err != nil || start < 0 {
+		return Range{}, 0, ErrInvalid
+	}
+	end, err := strconv.ParseInt(textproto.TrimString(endStr), 10, 64)
+	if err != nil || end < start {
+		return Range{}, 0, ErrInvalid
+	}
+	size := int64(-1)
+	if sizeStr = textproto.TrimString(sizeStr); sizeStr != "*" {
+		size, err = strconv.ParseInt(sizeStr, 10, 64)
+		if err != nil || size <= end {
+			return Range{}, 0, ErrInvalid
+		}
+	}
+	return Range{Start: start, Length: end - start + 1}, size, nil
+}
+
 // ParseRange parses a Range header string as per RFC 7233.
 // ErrNoOverlap is returned if none of the ranges overlap.
 // ErrInvalid is returned if s is invalid range.
@@ -3,6 +3,8 @@ package task
 import "errors"
 
 var (
-	ErrTaskNotFound = errors.New("task not found")
-	ErrTaskRunning  = errors.New("task is running")
+	ErrTaskNotFound  = errors.New("task not found")
+	ErrTaskRunning   = errors.New("task is running")
+	ErrTaskNotPaused = errors.New("task is not paused")
+	ErrPauseDisabled = errors.New("the task can't be paused")
 )
@@ -1,6 +1,9 @@
 package task
 
 import (
+	"container/heap"
+	"sync"
+
 	"github.com/alist-org/alist/v3/pkg/generic_sync"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/pkg/errors"
@@ -9,9 +12,16 @@ import (
 
 type Manager[K comparable] struct {
 	curID    K
-	workerC  chan struct{}
 	updateID func(*K)
 	tasks    generic_sync.MapOf[K, *Task[K]]
+
+	mu      sync.Mutex
+	queue   taskQueue[K]
+	seq     uint64
+	workers int
+	running int
+	// the tasks are not paused, see DisablePause
+	noPause bool
 }
 
 func (tm *Manager[K]) Submit(task *Task[K]) K {
@@ -24,21 +34,132 @@ func (tm *Manager[K]) Submit(task *Task[K]) K {
 	return task.ID
 }
 
+// SubmitChild submit the task as a child of the parent, the child is canceled or paused with the parent
+// and its progress, bytes, state and error are rolled up to the parent.
+// When the parent runs again, the child with the same name succeeded in the last run is kept instead
+func (tm *Manager[K]) SubmitChild(parent, task *Task[K]) K {
+	if kept := parent.takeKept(task.Name); kept != nil {
+		return kept.ID
+	}
+	if task.Priority == 0 {
+		task.Priority = parent.Priority
+	}
+	parent.addChild(task)
+	if parent.isPausing() {
+		task.pause()
+	} else if parent.Ctx != nil && parent.Ctx.Err() != nil {
+		task.Cancel()
+	}
+	return tm.Submit(task)
+}
+
+// do queue the task to run when a worker is free
 func (tm *Manager[K]) do(task *Task[K]) {
-	go func() {
-		log.Debugf("task [%s] waiting for worker", task.Name)
-		select {
-		case <-tm.workerC:
+	tm.mu.Lock()
+	tm.seq++
+	// the task maybe queued before, only the last one is valid
+	task.seq = tm.seq
+	heap.Push(&tm.queue, queued[K]{task: task, seq: tm.seq})
+	tm.mu.Unlock()
+	log.Debugf("task [%s] waiting for worker", task.Name)
+	tm.schedule()
+}
+
+// schedule run the queued tasks until the workers are busy
+func (tm *Manager[K]) schedule() {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	for tm.running < tm.workers && tm.queue.Len() > 0 {
+		item := heap.Pop(&tm.queue).(queued[K])
+		task := item.task
+		// the task is canceled, paused or queued again
+		if item.seq != task.seq {
+			continue
+		}
+		if task.Ctx.Err() != nil {
+			if task.casState(CANCELED, PENDING) {
+				log.Debugf("task [%s] canceled", task.Name)
+			}
+			continue
+		}
+		// the task is canceled or paused if it's not pending
+		if !task.casState(RUNNING, PENDING) {
+			continue
+		}
+		tm.running++
+		go func() {
 			log.Debugf("task [%s] starting", task.Name)
 			task.run()
 			log.Debugf("task [%s] ended", task.Name)
-		case <-task.Ctx.Done():
-			log.Debugf("task [%s] canceled", task.Name)
-			return
-		}
-		// return worker
-		tm.workerC <- struct{}{}
-	}()
+			tm.mu.Lock()
+			tm.running--
+			tm.mu.Unlock()
+			tm.schedule()
+		}()
+	}
+}
+
+// SetWorkers change the max count of the running tasks, the running tasks beyond are not stopped
+func (tm *Manager[K]) SetWorkers(workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+	tm.mu.Lock()
+	tm.workers = workers
+	tm.mu.Unlock()
+	tm.schedule()
+}
+
+func (tm *Manager[K]) GetWorkers() int {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	return tm.workers
+}
+
+// SetPriority change the priority of the task and its descendants, the higher runs first
+func (tm *Manager[K]) SetPriority(tid K, priority int) error {
+	t, ok := tm.Get(tid)
+	if !ok {
+		return errors.WithStack(ErrTaskNotFound)
+	}
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	t.setPriority(priority)
+	heap.Init(&tm.queue)
+	return nil
+}
+
+// DisablePause make the tasks not paused, for the tasks whose work is lost
+// when they are canceled, such as the offline downloads removed from the downloader
+func (tm *Manager[K]) DisablePause() *Manager[K] {
+	tm.noPause = true
+	return tm
+}
+
+// Pause the task and its descendants, the running ones are canceled and run again on resume
+func (tm *Manager[K]) Pause(tid K) error {
+	if tm.noPause {
+		return errors.WithStack(ErrPauseDisabled)
+	}
+	t, ok := tm.Get(tid)
+	if !ok {
+		return errors.WithStack(ErrTaskNotFound)
+	}
+	t.pause()
+	return nil
+}
+
+// Resume the paused task or the paused descendants
+func (tm *Manager[K]) Resume(tid K) error {
+	t, ok := tm.Get(tid)
+	if !ok {
+		return errors.WithStack(ErrTaskNotFound)
+	}
+	if t.GetState() != PAUSED {
+		return errors.WithStack(ErrTaskNotPaused)
+	}
+	tm.rerun(t, PAUSED)
+	return nil
 }
 
 func (tm *Manager[K]) GetAll() []*Task[K] {
@@ -54,15 +175,44 @@ func (tm *Manager[K]) MustGet(tid K) *Task[K] {
 	return task
 }
 
+// Retry the task if it's failed, otherwise retry the failed descendants
 func (tm *Manager[K]) Retry(tid K) error {
 	t, ok := tm.Get(tid)
 	if !ok {
 		return errors.WithStack(ErrTaskNotFound)
 	}
-	tm.do(t)
+	if !t.ownDone() {
+		return errors.WithStack(ErrTaskRunning)
+	}
+	tm.rerun(t, ERRORED, CANCELED)
 	return nil
 }
 
+// rerun the task, or the descendants in the states if the task itself is succeeded
+func (tm *Manager[K]) rerun(t *Task[K], states ...string) {
+	children := t.Children()
+	if t.GetOwnState() == SUCCEEDED && len(children) > 0 {
+		for _, child := range children {
+			if utils.SliceContains(states, child.GetState()) {
+				tm.rerun(child, states...)
+			}
+		}
+		return
+	}
+	// the task is run again, so the children will be submitted again,
+	// the succeeded ones are kept and their descendants in the states are run again
+	for _, child := range t.keepSucceeded() {
+		tm.removeTree(child)
+	}
+	for _, child := range t.Children() {
+		if utils.SliceContains(states, child.GetState()) {
+			tm.rerun(child, states...)
+		}
+	}
+	t.renew()
+	tm.do(t)
+}
+
 func (tm *Manager[K]) Cancel(tid K) error {
 	t, ok := tm.Get(tid)
 	if !ok {
@@ -72,6 +222,7 @@ func (tm *Manager[K]) Cancel(tid K) error {
 	return nil
 }
 
+// Remove the task and its descendants
 func (tm *Manager[K]) Remove(tid K) error {
 	t, ok := tm.Get(tid)
 	if !ok {
@@ -80,10 +231,20 @@ func (tm *Manager[K]) Remove(tid K) error {
 	if !t.Done() {
 		return errors.WithStack(ErrTaskRunning)
 	}
-	tm.tasks.Delete(tid)
+	if t.parent != nil {
+		t.parent.removeChild(t)
+	}
+	tm.removeTree(t)
 	return nil
 }
 
+func (tm *Manager[K]) removeTree(t *Task[K]) {
+	for _, child := range t.Children() {
+		tm.removeTree(child)
+	}
+	tm.tasks.Delete(t.ID)
+}
+
 // RemoveAll removes all tasks from the manager, this maybe shouldn't be used
 // because the task maybe still running.
 func (tm *Manager[K]) RemoveAll() {
@@ -91,7 +252,7 @@ func (tm *Manager[K]) RemoveAll() {
 }
 
 func (tm *Manager[K]) RemoveByStates(states ...string) {
-	tasks := tm.GetAll()
+	tasks := tm.GetRoots()
 	for _, task := range tasks {
 		if utils.SliceContains(states, task.GetState()) {
 			_ = tm.Remove(task.ID)
@@ -99,10 +260,23 @@ func (tm *Manager[K]) RemoveByStates(states ...string) {
 	}
 }
 
+// GetRoots returns the tasks without parent
+func (tm *Manager[K]) GetRoots() []*Task[K] {
+	var tasks []*Task[K]
+	tm.tasks.Range(func(key K, value *Task[K]) bool {
+		if value.parent == nil {
+			tasks = append(tasks, value)
+		}
+		return true
+	})
+	return tasks
+}
+
+// GetByStates returns the root tasks in the states, the sub tasks are rolled up to them
 func (tm *Manager[K]) GetByStates(states ...string) []*Task[K] {
 	var tasks []*Task[K]
 	tm.tasks.Range(func(key K, value *Task[K]) bool {
-		if utils.SliceContains(states, value.GetState()) {
+		if value.parent == nil && utils.SliceContains(states, value.GetState()) {
 			tasks = append(tasks, value)
 		}
 		return true
@@ -111,7 +285,7 @@ func (tm *Manager[K]) GetByStates(states ...string) []*Task[K] {
 }
 
 func (tm *Manager[K]) ListUndone() []*Task[K] {
-	return tm.GetByStates(PENDING, RUNNING, CANCELING)
+	return tm.GetByStates(PENDING, RUNNING, CANCELING, PAUSING, PAUSED)
 }
 
 func (tm *Manager[K]) ListDone() []*Task[K] {
@@ -126,6 +300,34 @@ func (tm *Manager[K]) ClearSucceeded() {
 	tm.RemoveByStates(SUCCEEDED)
 }
 
+// RetryFailed retry all the failed tasks and the failed sub tasks
+func (tm *Manager[K]) RetryFailed() {
+	for _, t := range tm.GetByStates(ERRORED, CANCELED) {
+		_ = tm.Retry(t.ID)
+	}
+}
+
+func (tm *Manager[K]) PauseAll() {
+	if tm.noPause {
+		return
+	}
+	for _, t := range tm.GetByStates(PENDING, RUNNING) {
+		t.pause()
+	}
+}
+
+func (tm *Manager[K]) ResumeAll() {
+	for _, t := range tm.GetByStates(PAUSED) {
+		_ = tm.Resume(t.ID)
+	}
+}
+
+func (tm *Manager[K]) CancelAll() {
+	for _, t := range tm.ListUndone() {
+		t.Cancel()
+	}
+}
+
 func (tm *Manager[K]) RawTasks() *generic_sync.MapOf[K, *Task[K]] {
 	return &tm.tasks
 }
@@ -133,10 +335,7 @@ func (tm *Manager[K]) RawTasks() *generic_sync.MapOf[K, *Task[K]] {
 func NewTaskManager[K comparable](maxWorker int, updateID ...func(*K)) *Manager[K] {
 	tm := &Manager[K]{
 		tasks:   generic_sync.MapOf[K, *Task[K]]{},
-		workerC: make(chan struct{}, maxWorker),
-	}
-	for i := 0; i < maxWorker; i++ {
-		tm.workerC <- struct{}{}
+		workers: maxWorker,
 	}
 	if len(updateID) > 0 {
 		tm.updateID = updateID[0]
@@ -0,0 +1,37 @@
+package task
+
+// queued is a task waiting for the worker, seq is the order of submission
+type queued[K comparable] struct {
+	task *Task[K]
+	seq  uint64
+}
+
+// taskQueue is a heap of the pending tasks, the higher priority first, then the earlier submitted
+type taskQueue[K comparable] []queued[K]
+
+func (q taskQueue[K]) Len() int {
+	return len(q)
+}
+
+func (q taskQueue[K]) Less(i, j int) bool {
+	if q[i].task.Priority != q[j].task.Priority {
+		return q[i].task.Priority > q[j].task.Priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q taskQueue[K]) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *taskQueue[K]) Push(x any) {
+	*q = append(*q, x.(queued[K]))
+}
+
+func (q *taskQueue[K]) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
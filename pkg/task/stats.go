@@ -0,0 +1,156 @@
+package task
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// speedWindow is the duration to compute the current speed
+const speedWindow = 5 * time.Second
+
+// Stats is the transfer statistics of the task and its descendants
+type Stats struct {
+	DoneBytes  int64 `json:"done_bytes"`
+	TotalBytes int64 `json:"total_bytes"`
+	// Speed is the bytes per second in the recent seconds
+	Speed int64 `json:"speed"`
+	// AvgSpeed is the bytes per second since the task started
+	AvgSpeed int64 `json:"avg_speed"`
+	// ETA is the estimated seconds to finish, -1 if unknown
+	ETA int64 `json:"eta"`
+}
+
+type sample struct {
+	at    time.Time
+	bytes int64
+}
+
+// meter records the done bytes to compute the current speed
+type meter struct {
+	mu sync.Mutex
+	// the samples in the window, and the last one before the window as the base
+	samples []sample
+}
+
+func (m *meter) record(bytes int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if n := len(m.samples); n > 1 && now.Sub(m.samples[n-2].at) < 100*time.Millisecond {
+		// merge the frequent samples
+		m.samples[n-1] = sample{at: now, bytes: bytes}
+	} else {
+		m.samples = append(m.samples, sample{at: now, bytes: bytes})
+	}
+	m.prune(now)
+}
+
+func (m *meter) prune(now time.Time) {
+	i := 0
+	for i+1 < len(m.samples) && now.Sub(m.samples[i+1].at) > speedWindow {
+		i++
+	}
+	m.samples = m.samples[i:]
+}
+
+// speed returns the bytes per second from the base sample to now
+func (m *meter) speed(bytes int64) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	m.prune(now)
+	if len(m.samples) == 0 {
+		return 0
+	}
+	base := m.samples[0]
+	elapsed := now.Sub(base.at).Seconds()
+	if elapsed <= 0 || bytes <= base.bytes {
+		return 0
+	}
+	return int64(float64(bytes-base.bytes) / elapsed)
+}
+
+func (m *meter) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.samples = nil
+}
+
+// SetDoneBytes set the bytes transferred by the task
+func (t *Task[K]) SetDoneBytes(done int64) {
+	atomic.StoreInt64(&t.doneBytes, done)
+	t.meter.record(done)
+}
+
+// AddDoneBytes add the bytes transferred by the task, it can be used as the counter of the stream
+func (t *Task[K]) AddDoneBytes(n int64) {
+	t.meter.record(atomic.AddInt64(&t.doneBytes, n))
+}
+
+// ownBytes returns the done and total bytes of the task itself,
+// the done bytes are computed by the progress if they are not counted
+func (t *Task[K]) ownBytes() (done, total int64) {
+	total = atomic.LoadInt64(&t.totalBytes)
+	done = atomic.LoadInt64(&t.doneBytes)
+	if byProgress := total * int64(t.getProgress()) / 100; byProgress > done {
+		done = byProgress
+	}
+	if total > 0 && done > total {
+		done = total
+	}
+	return done, total
+}
+
+// span returns when the task and its descendants started and finished,
+// the finished time is zero if some of them are not done
+func (t *Task[K]) span() (start, end time.Time) {
+	t.mu.Lock()
+	start, end = t.startedAt, t.finishedAt
+	t.mu.Unlock()
+	if !t.ownDone() {
+		end = time.Time{}
+	}
+	for _, child := range t.Children() {
+		s, e := child.span()
+		if start.IsZero() || (!s.IsZero() && s.Before(start)) {
+			start = s
+		}
+		if e.IsZero() || (!end.IsZero() && e.After(end)) {
+			end = e
+		}
+	}
+	return start, end
+}
+
+func (t *Task[K]) speed() int64 {
+	done, _ := t.ownBytes()
+	speed := t.meter.speed(done)
+	for _, child := range t.Children() {
+		speed += child.speed()
+	}
+	return speed
+}
+
+// GetStats returns the transfer statistics rolled up with the descendants
+func (t *Task[K]) GetStats() Stats {
+	stats := Stats{ETA: -1}
+	stats.DoneBytes, stats.TotalBytes = t.GetBytes()
+	if t.Done() {
+		stats.ETA = 0
+	} else {
+		stats.Speed = t.speed()
+		if stats.Speed > 0 && stats.TotalBytes > 0 {
+			// rounded up, so it's 0 only if the task is done
+			stats.ETA = (stats.TotalBytes - stats.DoneBytes + stats.Speed - 1) / stats.Speed
+		}
+	}
+	start, end := t.span()
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if elapsed := end.Sub(start).Seconds(); !start.IsZero() && elapsed > 0 {
+		stats.AvgSpeed = int64(float64(stats.DoneBytes) / elapsed)
+	}
+	return stats
+}
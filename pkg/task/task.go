@@ -3,7 +3,11 @@ package task
 
 import (
 	"context"
+	"fmt"
 	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -16,52 +20,262 @@ var (
 	CANCELING = "canceling"
 	CANCELED  = "canceled"
 	ERRORED   = "errored"
+	PAUSING   = "pausing"
+	PAUSED    = "paused"
 )
 
 type Func[K comparable] func(task *Task[K]) error
 type Callback[K comparable] func(task *Task[K])
 
 type Task[K comparable] struct {
-	ID       K
-	Name     string
-	state    string // pending, running, finished, canceling, canceled, errored
-	status   string
+	ID     K
+	Name   string
+	state  string // pending, running, finished, canceling, canceled, errored, pausing, paused
+	status string
+	// the task with higher priority runs first
+	Priority int
 	progress int
+	// the total and transferred bytes of the task, see GetStats
+	totalBytes int64
+	doneBytes  int64
+	meter      meter
+	startedAt  time.Time
+	finishedAt time.Time
 
 	Error error
 
+	parent *Task[K]
+	// mu guards the state, status, progress, error, pausing, the start and finish times and the children,
+	// since they are written by the worker and read or changed by the others
+	mu       sync.Mutex
+	children []*Task[K]
+	// the children succeeded in the last run, not submitted again yet
+	kept []*Task[K]
+
 	Func     Func[K]
 	callback Callback[K]
 
 	Ctx    context.Context
 	cancel context.CancelFunc
+	// the context is canceled to pause the task
+	pausing bool
+	// the order of the last submission, see Manager.do
+	seq uint64
 }
 
 func (t *Task[K]) SetStatus(status string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.status = status
 }
 
 func (t *Task[K]) SetProgress(percentage int) {
+	t.mu.Lock()
 	t.progress = percentage
+	t.mu.Unlock()
+	if atomic.LoadInt64(&t.totalBytes) > 0 {
+		done, _ := t.ownBytes()
+		t.meter.record(done)
+	}
 }
 
-func (t Task[K]) GetProgress() int {
-	return t.progress
+// SetTotalBytes set the total bytes of the task, the done bytes are computed by the progress
+// if they are not set by SetDoneBytes or AddDoneBytes
+func (t *Task[K]) SetTotalBytes(total int64) {
+	atomic.StoreInt64(&t.totalBytes, total)
+}
+
+// Parent returns the parent task, nil if it's a root task
+func (t *Task[K]) Parent() *Task[K] {
+	return t.parent
+}
+
+// Children returns the sub tasks submitted by the task
+func (t *Task[K]) Children() []*Task[K] {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*Task[K](nil), t.children...)
+}
+
+func (t *Task[K]) addChild(child *Task[K]) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	child.parent = t
+	t.children = append(t.children, child)
+}
+
+// keepSucceeded keep the children succeeded themselves to run the task again,
+// and returns the others removed
+func (t *Task[K]) keepSucceeded() []*Task[K] {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var removed []*Task[K]
+	t.kept = nil
+	for _, child := range t.children {
+		if child.GetOwnState() == SUCCEEDED {
+			t.kept = append(t.kept, child)
+		} else {
+			removed = append(removed, child)
+		}
+	}
+	t.children = append([]*Task[K](nil), t.kept...)
+	return removed
+}
+
+// takeKept returns the kept child with the name, nil if there's none
+func (t *Task[K]) takeKept(name string) *Task[K] {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, child := range t.kept {
+		if child.Name == name {
+			t.kept = append(t.kept[:i], t.kept[i+1:]...)
+			return child
+		}
+	}
+	return nil
+}
+
+func (t *Task[K]) removeChild(child *Task[K]) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range t.children {
+		if t.children[i] == child {
+			t.children = append(t.children[:i], t.children[i+1:]...)
+			return
+		}
+	}
+}
+
+// GetBytes returns the done and total bytes of the task and its descendants
+func (t *Task[K]) GetBytes() (done, total int64) {
+	done, total = t.ownBytes()
+	for _, child := range t.Children() {
+		d, t := child.GetBytes()
+		done, total = done+d, total+t
+	}
+	return done, total
+}
+
+// GetProgress returns the progress of the task, rolled up with the descendants,
+// by the bytes if they are known, otherwise by the count of the tasks
+func (t *Task[K]) GetProgress() int {
+	children := t.Children()
+	progress := t.getProgress()
+	if len(children) == 0 {
+		// the counted bytes maybe ahead of the progress reported by the driver
+		if done, total := t.ownBytes(); total > 0 && progress < 100 {
+			// the task isn't finished even if all the bytes are read
+			if done >= total {
+				return 99
+			}
+			return int(done * 100 / total)
+		}
+		return progress
+	}
+	if done, total := t.GetBytes(); total > 0 {
+		return int(done * 100 / total)
+	}
+	sum := progress
+	for _, child := range children {
+		sum += child.GetProgress()
+	}
+	return sum / (len(children) + 1)
+}
+
+// GetState returns the state of the task rolled up with the descendants,
+// the task is running until all the descendants are done or paused
+func (t *Task[K]) GetState() string {
+	state := t.GetOwnState()
+	if !isDone(state) {
+		return state
+	}
+	var errored, canceled, paused bool
+	for _, child := range t.Children() {
+		switch child.GetState() {
+		case PENDING, RUNNING, CANCELING, PAUSING:
+			if state == CANCELED {
+				return CANCELING
+			}
+			return RUNNING
+		case PAUSED:
+			paused = true
+		case ERRORED:
+			errored = true
+		case CANCELED:
+			canceled = true
+		}
+	}
+	switch {
+	case paused:
+		return PAUSED
+	case state != SUCCEEDED:
+		return state
+	case errored:
+		return ERRORED
+	case canceled:
+		return CANCELED
+	}
+	return SUCCEEDED
 }
 
-func (t Task[K]) GetState() string {
+// GetOwnState returns the state of the task itself without the descendants
+func (t *Task[K]) GetOwnState() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.state
 }
 
-func (t Task[K]) GetStatus() string {
+// casState change the state to new only if it's one of the old states, returns whether it's changed
+func (t *Task[K]) casState(new string, old ...string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, state := range old {
+		if t.state == state {
+			t.state = new
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Task[K]) GetStatus() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.status
 }
 
-func (t Task[K]) GetErrMsg() string {
-	if t.Error == nil {
+func (t *Task[K]) getProgress() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.progress
+}
+
+func (t *Task[K]) isPausing() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.pausing
+}
+
+// GetErrMsg returns the error of the task, or the errors of the descendants
+func (t *Task[K]) GetErrMsg() string {
+	t.mu.Lock()
+	err := t.Error
+	t.mu.Unlock()
+	if err != nil {
+		return err.Error()
+	}
+	failed, first := 0, ""
+	for _, child := range t.Children() {
+		if msg := child.GetErrMsg(); msg != "" {
+			if failed++; first == "" {
+				first = msg
+			}
+		}
+	}
+	if failed == 0 {
 		return ""
 	}
-	return t.Error.Error()
+	return fmt.Sprintf("%d sub tasks failed, the first: %s", failed, first)
 }
 
 func getCurrentGoroutineStack() string {
@@ -71,24 +285,43 @@ func getCurrentGoroutineStack() string {
 }
 
 func (t *Task[K]) run() {
-	t.state = RUNNING
+	t.mu.Lock()
+	t.startedAt = time.Now()
+	t.mu.Unlock()
 	defer func() {
 		if err := recover(); err != nil {
 			log.Errorf("error [%s] while run task [%s],stack trace:\n%s", err, t.Name, getCurrentGoroutineStack())
+			t.mu.Lock()
 			t.Error = errors.Errorf("panic: %+v", err)
 			t.state = ERRORED
+			t.finishedAt = time.Now()
+			t.mu.Unlock()
 		}
 	}()
-	t.Error = t.Func(t)
-	if t.Error != nil {
-		log.Errorf("error [%+v] while run task [%s]", t.Error, t.Name)
+	err := t.Func(t)
+	if err != nil {
+		log.Errorf("error [%+v] while run task [%s]", err, t.Name)
 	}
+	// the state is decided under the lock, so it's not changed by pause or cancel at the same time
+	t.mu.Lock()
+	// the kept children not submitted again are left as they are
+	t.kept = nil
+	t.Error = err
 	if errors.Is(t.Ctx.Err(), context.Canceled) {
-		t.state = CANCELED
-	} else if t.Error != nil {
+		if t.pausing {
+			t.state = PAUSED
+		} else {
+			t.state = CANCELED
+		}
+	} else if err != nil {
 		t.state = ERRORED
 	} else {
 		t.state = SUCCEEDED
+	}
+	t.finishedAt = time.Now()
+	succeeded := t.state == SUCCEEDED
+	t.mu.Unlock()
+	if succeeded {
 		t.SetProgress(100)
 		if t.callback != nil {
 			t.callback(t)
@@ -100,23 +333,87 @@ func (t *Task[K]) retry() {
 	t.run()
 }
 
+func (t *Task[K]) ownDone() bool {
+	return isDone(t.GetOwnState())
+}
+
+func isDone(state string) bool {
+	return state == SUCCEEDED || state == CANCELED || state == ERRORED
+}
+
+// Done returns whether the task and its descendants are done
 func (t *Task[K]) Done() bool {
-	return t.state == SUCCEEDED || t.state == CANCELED || t.state == ERRORED
+	switch t.GetState() {
+	case SUCCEEDED, CANCELED, ERRORED:
+		return true
+	}
+	return false
 }
 
+// Cancel the task and its descendants
 func (t *Task[K]) Cancel() {
-	if t.state == SUCCEEDED || t.state == CANCELED {
+	for _, child := range t.Children() {
+		child.Cancel()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if isDone(t.state) {
 		return
 	}
 	if t.cancel != nil {
 		t.cancel()
 	}
+	t.pausing = false
+	if t.state == PENDING || t.state == PAUSED {
+		t.state = CANCELED
+		return
+	}
 	// maybe can't cancel
 	t.state = CANCELING
 }
 
+// pause the task and its descendants, the running task is canceled
+func (t *Task[K]) pause() {
+	for _, child := range t.Children() {
+		child.pause()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch t.state {
+	case PENDING:
+		t.state = PAUSED
+	case RUNNING:
+		t.pausing = true
+		if t.cancel != nil {
+			t.cancel()
+		}
+		t.state = PAUSING
+	}
+}
+
+func (t *Task[K]) setPriority(priority int) {
+	t.Priority = priority
+	for _, child := range t.Children() {
+		child.setPriority(priority)
+	}
+}
+
+// renew the context of the task to run again
+func (t *Task[K]) renew() {
+	WithCancelCtx(t)
+	t.mu.Lock()
+	t.Error = nil
+	t.progress = 0
+	t.pausing = false
+	t.mu.Unlock()
+	atomic.StoreInt64(&t.doneBytes, 0)
+	t.meter.reset()
+}
+
 func WithCancelCtx[K comparable](task *Task[K]) *Task[K] {
 	ctx, cancel := context.WithCancel(context.Background())
+	task.mu.Lock()
+	defer task.mu.Unlock()
 	task.Ctx = ctx
 	task.cancel = cancel
 	task.state = PENDING
@@ -1,6 +1,7 @@
 package task
 
 import (
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -25,12 +26,12 @@ func TestTask_Manager(t *testing.T) {
 		t.Fatal("task not found")
 	}
 	time.Sleep(time.Millisecond * 100)
-	if task.state != RUNNING {
-		t.Errorf("task status not running: %s", task.state)
+	if task.GetOwnState() != RUNNING {
+		t.Errorf("task status not running: %s", task.GetOwnState())
 	}
 	time.Sleep(time.Second)
-	if task.state != SUCCEEDED {
-		t.Errorf("task status not finished: %s", task.state)
+	if task.GetOwnState() != SUCCEEDED {
+		t.Errorf("task status not finished: %s", task.GetOwnState())
 	}
 }
 
@@ -57,8 +58,8 @@ func TestTask_Cancel(t *testing.T) {
 	time.Sleep(time.Microsecond * 50)
 	task.Cancel()
 	time.Sleep(time.Millisecond)
-	if task.state != CANCELED {
-		t.Errorf("task status not canceled: %s", task.state)
+	if task.GetOwnState() != CANCELED {
+		t.Errorf("task status not canceled: %s", task.GetOwnState())
 	}
 }
 
@@ -82,15 +83,376 @@ func TestTask_Retry(t *testing.T) {
 		t.Fatal("task not found")
 	}
 	time.Sleep(time.Millisecond)
-	if task.Error == nil {
-		t.Error(task.state)
+	if task.GetErrMsg() == "" {
+		t.Error(task.GetOwnState())
 		t.Fatal("task error is nil, but expected error")
 	} else {
-		t.Logf("task error: %s", task.Error)
+		t.Logf("task error: %s", task.GetErrMsg())
 	}
 	task.retry()
 	time.Sleep(time.Millisecond)
-	if task.Error != nil {
-		t.Errorf("task error: %+v, but expected nil", task.Error)
+	if msg := task.GetErrMsg(); msg != "" {
+		t.Errorf("task error: %s, but expected nil", msg)
+	}
+}
+
+func TestTask_Children(t *testing.T) {
+	tm := NewTaskManager(3, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	id := tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name: "parent",
+		Func: func(parent *Task[uint64]) error {
+			for i := 0; i < 3; i++ {
+				i := i
+				tm.SubmitChild(parent, WithCancelCtx(&Task[uint64]{
+					Name: "child",
+					Func: func(task *Task[uint64]) error {
+						task.SetTotalBytes(100)
+						time.Sleep(time.Millisecond * 100)
+						if i == 2 {
+							return errors.New("test error")
+						}
+						return nil
+					},
+				}))
+			}
+			return nil
+		},
+	}))
+	parent := tm.MustGet(id)
+	time.Sleep(time.Millisecond * 50)
+	if parent.GetOwnState() != SUCCEEDED || parent.GetState() != RUNNING {
+		t.Errorf("parent should be running until the children are done: %s %s", parent.GetOwnState(), parent.GetState())
+	}
+	if len(tm.ListUndone()) != 1 {
+		t.Errorf("only the root task should be listed: %d", len(tm.ListUndone()))
+	}
+	time.Sleep(time.Millisecond * 200)
+	if parent.GetState() != ERRORED || parent.GetErrMsg() == "" {
+		t.Errorf("the error of the child should be rolled up: %s %s", parent.GetState(), parent.GetErrMsg())
+	}
+	if done, total := parent.GetBytes(); done != 200 || total != 300 || parent.GetProgress() != 66 {
+		t.Errorf("unexpected bytes %d/%d and progress %d", done, total, parent.GetProgress())
+	}
+	if err := tm.Retry(id); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 200)
+	if parent.GetState() != ERRORED || len(parent.Children()) != 3 {
+		t.Errorf("only the failed child should be retried: %s %d", parent.GetState(), len(parent.Children()))
+	}
+	if err := tm.Remove(id); err != nil {
+		t.Fatal(err)
+	}
+	if len(tm.GetAll()) != 0 {
+		t.Errorf("the children should be removed with the parent: %d", len(tm.GetAll()))
+	}
+}
+
+func TestTask_CancelChildren(t *testing.T) {
+	tm := NewTaskManager(1, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	id := tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name: "parent",
+		Func: func(parent *Task[uint64]) error {
+			for i := 0; i < 3; i++ {
+				tm.SubmitChild(parent, WithCancelCtx(&Task[uint64]{
+					Name: "child",
+					Func: func(task *Task[uint64]) error {
+						<-task.Ctx.Done()
+						return nil
+					},
+				}))
+			}
+			return nil
+		},
+	}))
+	parent := tm.MustGet(id)
+	time.Sleep(time.Millisecond * 50)
+	if err := tm.Cancel(id); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 50)
+	for _, child := range parent.Children() {
+		if child.GetState() != CANCELED {
+			t.Errorf("child should be canceled: %s", child.GetState())
+		}
+	}
+	if parent.GetState() != CANCELED {
+		t.Errorf("parent should be canceled: %s", parent.GetState())
+	}
+}
+
+func TestTask_Priority(t *testing.T) {
+	tm := NewTaskManager(1, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	var mu sync.Mutex
+	var order []int
+	block := make(chan struct{})
+	tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name: "block",
+		Func: func(task *Task[uint64]) error {
+			<-block
+			return nil
+		},
+	}))
+	for _, priority := range []int{0, 2, 1} {
+		priority := priority
+		tm.Submit(WithCancelCtx(&Task[uint64]{
+			Name:     "test",
+			Priority: priority,
+			Func: func(task *Task[uint64]) error {
+				mu.Lock()
+				defer mu.Unlock()
+				order = append(order, priority)
+				return nil
+			},
+		}))
+	}
+	close(block)
+	time.Sleep(time.Millisecond * 100)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(order) != 3 || order[0] != 2 || order[1] != 1 || order[2] != 0 {
+		t.Errorf("tasks should run by the priority: %v", order)
+	}
+}
+
+func TestTask_PauseResume(t *testing.T) {
+	tm := NewTaskManager(1, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	runs := int32(0)
+	id := tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name: "test",
+		Func: func(task *Task[uint64]) error {
+			if atomic.AddInt32(&runs, 1) == 1 {
+				<-task.Ctx.Done()
+			}
+			return nil
+		},
+	}))
+	pending := tm.MustGet(tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name: "pending",
+		Func: func(task *Task[uint64]) error {
+			return nil
+		},
+	})))
+	task := tm.MustGet(id)
+	time.Sleep(time.Millisecond * 50)
+	if err := tm.Pause(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.Pause(pending.ID); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 50)
+	if task.GetState() != PAUSED || pending.GetState() != PAUSED {
+		t.Fatalf("tasks should be paused: %s %s", task.GetState(), pending.GetState())
+	}
+	if len(tm.ListUndone()) != 2 {
+		t.Errorf("paused tasks should be undone: %d", len(tm.ListUndone()))
+	}
+	tm.ResumeAll()
+	time.Sleep(time.Millisecond * 50)
+	if task.GetState() != SUCCEEDED || pending.GetState() != SUCCEEDED || runs != 2 {
+		t.Errorf("tasks should be resumed: %s %s %d", task.GetState(), pending.GetState(), runs)
+	}
+	if err := tm.Resume(id); err == nil {
+		t.Error("succeeded task should not be resumed")
+	}
+}
+
+func TestTask_ResumeChildren(t *testing.T) {
+	tm := NewTaskManager(2, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	var fastRuns, slowRuns int32
+	id := tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name: "parent",
+		Func: func(parent *Task[uint64]) error {
+			tm.SubmitChild(parent, WithCancelCtx(&Task[uint64]{
+				Name: "fast",
+				Func: func(task *Task[uint64]) error {
+					atomic.AddInt32(&fastRuns, 1)
+					return nil
+				},
+			}))
+			tm.SubmitChild(parent, WithCancelCtx(&Task[uint64]{
+				Name: "slow",
+				Func: func(task *Task[uint64]) error {
+					if atomic.AddInt32(&slowRuns, 1) == 1 {
+						<-task.Ctx.Done()
+					}
+					return nil
+				},
+			}))
+			// the parent is paused while listing
+			if utils.IsCanceled(parent.Ctx) {
+				return nil
+			}
+			<-parent.Ctx.Done()
+			return nil
+		},
+	}))
+	parent := tm.MustGet(id)
+	time.Sleep(time.Millisecond * 50)
+	if err := tm.Pause(id); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 50)
+	if parent.GetState() != PAUSED {
+		t.Fatalf("parent should be paused: %s", parent.GetState())
+	}
+	if err := tm.Resume(id); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 50)
+	if err := tm.Cancel(id); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 50)
+	if fastRuns != 1 || slowRuns != 2 || len(parent.Children()) != 2 || len(tm.GetAll()) != 3 {
+		t.Errorf("only the unfinished child should run again: %d %d %d %d", fastRuns, slowRuns, len(parent.Children()), len(tm.GetAll()))
+	}
+	for _, child := range parent.Children() {
+		if child.GetState() != SUCCEEDED {
+			t.Errorf("child should be succeeded: %s %s", child.Name, child.GetState())
+		}
+	}
+}
+
+func TestTask_DisablePause(t *testing.T) {
+	tm := NewTaskManager[string](1).DisablePause()
+	tm.Submit(WithCancelCtx(&Task[string]{
+		ID:   "gid",
+		Name: "download",
+		Func: func(task *Task[string]) error {
+			<-task.Ctx.Done()
+			return nil
+		},
+	}))
+	task := tm.MustGet("gid")
+	time.Sleep(time.Millisecond * 50)
+	if err := tm.Pause("gid"); !errors.Is(err, ErrPauseDisabled) {
+		t.Errorf("pause should be disabled: %v", err)
+	}
+	tm.PauseAll()
+	time.Sleep(time.Millisecond * 50)
+	if task.GetState() != RUNNING {
+		t.Errorf("task should be running: %s", task.GetState())
+	}
+	task.Cancel()
+}
+
+func TestTask_SetWorkers(t *testing.T) {
+	tm := NewTaskManager(1, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	block := make(chan struct{})
+	running := int32(0)
+	for i := 0; i < 4; i++ {
+		tm.Submit(WithCancelCtx(&Task[uint64]{
+			Name: "test",
+			Func: func(task *Task[uint64]) error {
+				atomic.AddInt32(&running, 1)
+				<-block
+				return nil
+			},
+		}))
+	}
+	time.Sleep(time.Millisecond * 50)
+	if atomic.LoadInt32(&running) != 1 {
+		t.Errorf("only 1 task should be running: %d", running)
+	}
+	tm.SetWorkers(3)
+	time.Sleep(time.Millisecond * 50)
+	if atomic.LoadInt32(&running) != 3 {
+		t.Errorf("3 tasks should be running: %d", running)
+	}
+	close(block)
+	time.Sleep(time.Millisecond * 50)
+	if len(tm.ListDone()) != 4 {
+		t.Errorf("all tasks should be done: %d", len(tm.ListDone()))
+	}
+}
+
+func TestTask_Stats(t *testing.T) {
+	tm := NewTaskManager(3, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	block := make(chan struct{})
+	id := tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name: "parent",
+		Func: func(parent *Task[uint64]) error {
+			for i := 0; i < 2; i++ {
+				tm.SubmitChild(parent, WithCancelCtx(&Task[uint64]{
+					Name: "child",
+					Func: func(task *Task[uint64]) error {
+						task.SetTotalBytes(1000)
+						for j := 0; j < 5; j++ {
+							task.AddDoneBytes(100)
+							time.Sleep(time.Millisecond * 20)
+						}
+						<-block
+						return nil
+					},
+				}))
+			}
+			return nil
+		},
+	}))
+	parent := tm.MustGet(id)
+	time.Sleep(time.Millisecond * 200)
+	stats := parent.GetStats()
+	if stats.DoneBytes != 1000 || stats.TotalBytes != 2000 || parent.GetProgress() != 50 {
+		t.Errorf("unexpected bytes %d/%d and progress %d", stats.DoneBytes, stats.TotalBytes, parent.GetProgress())
+	}
+	if stats.Speed <= 0 || stats.AvgSpeed <= 0 || stats.ETA <= 0 {
+		t.Errorf("unexpected speed %d, average speed %d and eta %d", stats.Speed, stats.AvgSpeed, stats.ETA)
+	}
+	close(block)
+	time.Sleep(time.Millisecond * 50)
+	stats = parent.GetStats()
+	if stats.DoneBytes != 2000 || stats.ETA != 0 {
+		t.Errorf("the finished task should have all bytes done: %d, eta %d", stats.DoneBytes, stats.ETA)
+	}
+}
+
+func TestTask_PauseWhileFinishing(t *testing.T) {
+	tm := NewTaskManager(4, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	var tasks []*Task[uint64]
+	for i := 0; i < 100; i++ {
+		id := tm.Submit(WithCancelCtx(&Task[uint64]{
+			Name: "test",
+			Func: func(task *Task[uint64]) error {
+				return nil
+			},
+		}))
+		_ = tm.Pause(id)
+		tasks = append(tasks, tm.MustGet(id))
+	}
+	time.Sleep(time.Millisecond * 100)
+	// the task paused while finishing is either succeeded or paused, and can be resumed
+	for _, task := range tasks {
+		if state := task.GetState(); state != SUCCEEDED && state != PAUSED {
+			t.Fatalf("task should be succeeded or paused, got %s", state)
+		}
+		if task.GetState() == PAUSED {
+			if err := tm.Resume(task.ID); err != nil {
+				t.Errorf("failed resume: %+v", err)
+			}
+		}
+	}
+	time.Sleep(time.Millisecond * 100)
+	for _, task := range tasks {
+		if task.GetState() != SUCCEEDED {
+			t.Errorf("task should be succeeded after resumed, got %s", task.GetState())
+		}
 	}
 }
@@ -6,9 +6,30 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"hash"
 	"strings"
 )
 
+// the types of the hashes
+const (
+	MD5    = "md5"
+	SHA1   = "sha1"
+	SHA256 = "sha256"
+)
+
+// NewHash returns the hash of the type, nil if the type is unknown
+func NewHash(typ string) hash.Hash {
+	switch typ {
+	case MD5:
+		return md5.New()
+	case SHA1:
+		return sha1.New()
+	case SHA256:
+		return sha256.New()
+	}
+	return nil
+}
+
 func GetSHA1Encode(data string) string {
 	h := sha1.New()
 	h.Write([]byte(data))
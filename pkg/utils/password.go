@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+)
+
+// the argon2id parameters recommended by OWASP,
+// keep the memory small since alist often runs on low-end devices
+const (
+	argon2Time    = 2
+	argon2Memory  = 19 * 1024
+	argon2Threads = 1
+	argon2KeyLen  = 32
+	argon2SaltLen = 16
+	argon2Prefix  = "$argon2id$"
+)
+
+// the successful verifications are cached for a while, so that checking
+// the same password repeatedly, such as the meta password of each search result, is cheap
+var passwordCache = cache.NewMemCache[bool]()
+
+// HashPassword returns the argon2id hash of the password with a random salt
+// in the PHC string format, e.g. $argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", errors.WithStack(err)
+	}
+	key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2Prefix, argon2.Version,
+		argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// IsPasswordHashed check if the str is a hash generated by HashPassword
+func IsPasswordHashed(str string) bool {
+	return strings.HasPrefix(str, argon2Prefix)
+}
+
+// VerifyPassword check if the password matches the hash generated by HashPassword
+func VerifyPassword(hash, password string) bool {
+	if hash == "" || password == "" {
+		return false
+	}
+	cacheKey := GetSHA256Encode(hash + "\n" + password)
+	if _, ok := passwordCache.Get(cacheKey); ok {
+		return true
+	}
+	parts := strings.Split(hash, "$")
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false
+	}
+	var memory, iterations uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &threads); err != nil {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false
+	}
+	actual := argon2.IDKey([]byte(password), salt, iterations, memory, threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(actual, key) != 1 {
+		return false
+	}
+	passwordCache.Set(cacheKey, true, cache.WithEx[bool](10*time.Minute))
+	return true
+}
@@ -0,0 +1,23 @@
+package utils
+
+import "testing"
+
+func TestPassword(t *testing.T) {
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsPasswordHashed(hash) {
+		t.Errorf("%s should be recognized as a hash", hash)
+	}
+	another, _ := HashPassword("secret")
+	if hash == another {
+		t.Errorf("the same password should be hashed with different salts")
+	}
+	if !VerifyPassword(hash, "secret") || !VerifyPassword(another, "secret") {
+		t.Errorf("the right password should be verified")
+	}
+	if VerifyPassword(hash, "wrong") || VerifyPassword(hash, "") || VerifyPassword("secret", "secret") {
+		t.Errorf("the wrong password should not be verified")
+	}
+}
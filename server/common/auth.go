@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/pkg/errors"
 )
@@ -12,14 +13,26 @@ var SecretKey []byte
 
 type UserClaims struct {
 	Username string `json:"username"`
+	// the token generation of the user, the token is invalid once it's bumped
+	Gen int `json:"gen"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(username string) (tokenString string, err error) {
+func accessTokenExpiration() time.Duration {
+	if conf.Conf.AccessTokenExpiresIn > 0 {
+		return time.Duration(conf.Conf.AccessTokenExpiresIn) * time.Minute
+	}
+	return time.Duration(conf.Conf.TokenExpiresIn) * time.Hour
+}
+
+// GenerateToken generate the access token of the session, the id of the session is the jti claim
+func GenerateToken(user *model.User, session *model.Session) (tokenString string, err error) {
 	claim := UserClaims{
-		Username: username,
+		Username: user.Username,
+		Gen:      user.TokenGen,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(conf.Conf.TokenExpiresIn) * time.Hour)),
+			ID:        session.ID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(accessTokenExpiration())),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		}}
@@ -50,3 +63,36 @@ func ParseToken(tokenString string) (*UserClaims, error) {
 	}
 	return nil, errors.New("couldn't handle this token")
 }
+
+// eventsTokenSubject is the subject of the event stream tokens, they can't be used as the access tokens
+const eventsTokenSubject = "events"
+
+// EventsTokenExpiration is how long the event stream token can be used to connect
+const EventsTokenExpiration = time.Minute
+
+// GenerateEventsToken generate a short-lived token for the event streams, which is passed in the query
+// since EventSource can't set the Authorization header. It has no session, so it's rejected as an access token
+func GenerateEventsToken(user *model.User) (string, error) {
+	claim := UserClaims{
+		Username: user.Username,
+		Gen:      user.TokenGen,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   eventsTokenSubject,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(EventsTokenExpiration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+		}}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claim)
+	return token.SignedString(SecretKey)
+}
+
+func ParseEventsToken(tokenString string) (*UserClaims, error) {
+	claims, err := ParseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Subject != eventsTokenSubject {
+		return nil, errors.New("not an events token")
+	}
+	return claims, nil
+}
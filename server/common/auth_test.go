@@ -0,0 +1,33 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestEventsToken(t *testing.T) {
+	conf.Conf = conf.DefaultConfig()
+	SecretKey = []byte("secret")
+	user := &model.User{Username: "admin", TokenGen: 2}
+	token, err := GenerateEventsToken(user)
+	if err != nil {
+		t.Fatalf("failed generate: %+v", err)
+	}
+	claims, err := ParseEventsToken(token)
+	if err != nil || claims.Username != "admin" || claims.Gen != 2 {
+		t.Errorf("expect the events token parsed, got %+v %v", claims, err)
+	}
+	// it has no session, so it can't be used as an access token
+	if claims, err := ParseToken(token); err != nil || claims.ID != "" {
+		t.Errorf("expect the events token without session, got %+v %v", claims, err)
+	}
+	access, err := GenerateToken(user, &model.Session{ID: "s"})
+	if err != nil {
+		t.Fatalf("failed generate: %+v", err)
+	}
+	if _, err := ParseEventsToken(access); err == nil {
+		t.Errorf("expect the access token rejected as an events token")
+	}
+}
@@ -55,7 +55,7 @@ func CanAccess(user *model.User, meta *model.Meta, reqPath string, password stri
 		return true
 	}
 	// validate password
-	return meta.Password == password
+	return meta.ValidatePassword(password)
 }
 
 // ShouldProxy TODO need optimize
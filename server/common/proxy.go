@@ -38,6 +38,9 @@ var httpClient *http.Client
 func Proxy(w http.ResponseWriter, r *http.Request, link *model.Link, file model.Obj) error {
 	// read data with native
 	var err error
+	if link.RangeReader != nil {
+		return ServeRange(w, r, file, link.RangeReader)
+	}
 	if link.Data != nil {
 		defer func() {
 			_ = link.Data.Close()
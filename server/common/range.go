@@ -0,0 +1,128 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// ServeRange serve the file read by the RangeReader, the Range and If-Range of the request are handled
+// like http.ServeContent, a single range is responded with 206 and multiple ranges with multipart/byteranges
+func ServeRange(w http.ResponseWriter, r *http.Request, file model.Obj, rr model.RangeReader) error {
+	size := file.GetSize()
+	filename := file.GetName()
+	modTime := file.ModTime()
+	h := w.Header()
+	h.Set("Accept-Ranges", "bytes")
+	h.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, filename, url.PathEscape(filename)))
+	contentType := h.Get("Content-Type")
+	if contentType == "" {
+		contentType = utils.GetMimeType(filename)
+		h.Set("Content-Type", contentType)
+	}
+	if !modTime.IsZero() && h.Get("Last-Modified") == "" {
+		h.Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
+	ranges, err := http_range.ParseRange(r.Header.Get("Range"), size)
+	if err != nil {
+		h.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		http.Error(w, err.Error(), http.StatusRequestedRangeNotSatisfiable)
+		return nil
+	}
+	if !ifRangeMatch(r, h.Get("Etag"), modTime) {
+		// the client has a different version, send the whole file
+		ranges = nil
+	}
+	var sum int64
+	for _, ra := range ranges {
+		sum += ra.Length
+	}
+	if sum > size {
+		// the ranges are larger than the file, send the whole file instead of the overhead
+		ranges = nil
+	}
+	switch len(ranges) {
+	case 0:
+		return serveRange(w, r, rr, http_range.Range{Start: 0, Length: size}, http.StatusOK)
+	case 1:
+		h.Set("Content-Range", ranges[0].ContentRange(size))
+		return serveRange(w, r, rr, ranges[0], http.StatusPartialContent)
+	default:
+		return serveMultiRange(w, r, rr, ranges, size, contentType)
+	}
+}
+
+func serveRange(w http.ResponseWriter, r *http.Request, rr model.RangeReader, ra http_range.Range, status int) error {
+	w.Header().Set("Content-Length", strconv.FormatInt(ra.Length, 10))
+	if r.Method == http.MethodHead || ra.Length == 0 {
+		w.WriteHeader(status)
+		return nil
+	}
+	// open the reader before writing the header, so the error can be responded
+	rc, err := rr.RangeRead(r.Context(), ra)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	w.WriteHeader(status)
+	_, err = io.CopyN(w, rc, ra.Length)
+	return err
+}
+
+func serveMultiRange(w http.ResponseWriter, r *http.Request, rr model.RangeReader, ranges []http_range.Range, size int64, contentType string) error {
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/byteranges; boundary="+mw.Boundary())
+	w.Header().Del("Content-Length")
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusPartialContent)
+		return nil
+	}
+	w.WriteHeader(http.StatusPartialContent)
+	for _, ra := range ranges {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Range": {ra.ContentRange(size)},
+			"Content-Type":  {contentType},
+		})
+		if err != nil {
+			return err
+		}
+		rc, err := rr.RangeRead(r.Context(), ra)
+		if err != nil {
+			return errors.WithMessagef(err, "failed open range %s", ra.ContentRange(size))
+		}
+		_, err = io.CopyN(part, rc, ra.Length)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// ifRangeMatch check the If-Range of the request against the etag or the modification time,
+// true if there is no If-Range
+func ifRangeMatch(r *http.Request, etag string, modTime time.Time) bool {
+	ir := r.Header.Get("If-Range")
+	if ir == "" {
+		return true
+	}
+	if len(ir) > 0 && (ir[0] == '"' || ir[0] == 'W') {
+		// only the strong etag matches
+		return etag != "" && ir == etag && ir[0] == '"'
+	}
+	t, err := http.ParseTime(ir)
+	if err != nil || modTime.IsZero() {
+		return false
+	}
+	return modTime.Truncate(time.Second).Equal(t)
+}
@@ -0,0 +1,81 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+)
+
+func TestServeRange(t *testing.T) {
+	data := []byte("0123456789abcdefghij")
+	modTime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	file := &model.Object{Name: "a.txt", Size: int64(len(data)), Modified: modTime}
+	rr := model.RangeReaderFunc(func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+		end := int64(len(data))
+		if httpRange.Length >= 0 {
+			end = httpRange.Start + httpRange.Length
+		}
+		return io.NopCloser(bytes.NewReader(data[httpRange.Start:end])), nil
+	})
+	serve := func(header map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/d/a.txt", nil)
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		if err := ServeRange(w, r, file, rr); err != nil {
+			t.Fatalf("failed serve: %+v", err)
+		}
+		return w
+	}
+
+	w := serve(nil)
+	if w.Code != http.StatusOK || w.Body.String() != string(data) {
+		t.Errorf("expect the whole file, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = serve(map[string]string{"Range": "bytes=5-9"})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "56789" || w.Header().Get("Content-Range") != "bytes 5-9/20" {
+		t.Errorf("expect the range 5-9, got %d %q %s", w.Code, w.Body.String(), w.Header().Get("Content-Range"))
+	}
+
+	w = serve(map[string]string{"Range": "bytes=-3", "If-Range": modTime.Format(http.TimeFormat)})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "hij" {
+		t.Errorf("expect the last 3 bytes, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = serve(map[string]string{"Range": "bytes=5-9", "If-Range": modTime.Add(time.Hour).Format(http.TimeFormat)})
+	if w.Code != http.StatusOK || w.Body.String() != string(data) {
+		t.Errorf("expect the whole file for a different version, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = serve(map[string]string{"Range": "bytes=30-"})
+	if w.Code != http.StatusRequestedRangeNotSatisfiable || w.Header().Get("Content-Range") != "bytes */20" {
+		t.Errorf("expect 416, got %d %s", w.Code, w.Header().Get("Content-Range"))
+	}
+
+	w = serve(map[string]string{"Range": "bytes=0-1,10-11"})
+	_, params, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if w.Code != http.StatusPartialContent || err != nil {
+		t.Fatalf("expect multipart 206, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	mr := multipart.NewReader(w.Body, params["boundary"])
+	for _, expect := range []string{"01", "ab"} {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatalf("failed read part: %+v", err)
+		}
+		if got, _ := io.ReadAll(part); string(got) != expect {
+			t.Errorf("expect part %q, got %q", expect, got)
+		}
+	}
+}
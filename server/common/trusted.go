@@ -0,0 +1,19 @@
+package common
+
+import (
+	"net/http"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/mtls"
+	"github.com/alist-org/alist/v3/internal/proxyauth"
+)
+
+// TrustedUser returns the user authenticated by the client certificate or
+// the header of the trusted reverse proxy, nil is returned if there's neither
+func TrustedUser(r *http.Request) (*model.User, error) {
+	user, err := mtls.Authenticate(r)
+	if user != nil || err != nil {
+		return user, err
+	}
+	return proxyauth.Authenticate(r)
+}
@@ -0,0 +1,81 @@
+package handles
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListApiTokens(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	tokens, err := op.GetApiTokensByUser(user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, tokens)
+}
+
+type CreateApiTokenReq struct {
+	Name       string   `json:"name" binding:"required"`
+	Scopes     []string `json:"scopes" binding:"required"`
+	PathPrefix string   `json:"path_prefix"`
+	ExpiresIn  int      `json:"expires_in"` // days, 0 means never expire
+}
+
+func CreateApiToken(c *gin.Context) {
+	var req CreateApiTokenReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if user.IsGuest() {
+		common.ErrorStrResp(c, "Guest user can not create api token", 403)
+		return
+	}
+	if !user.IsAdmin() && utils.SliceContains(req.Scopes, model.ScopeAdmin) {
+		common.ErrorStrResp(c, "Only admin can create api token with admin scope", 403)
+		return
+	}
+	apiToken := model.ApiToken{
+		UserID:     user.ID,
+		Name:       req.Name,
+		Scopes:     strings.Join(req.Scopes, ","),
+		PathPrefix: req.PathPrefix,
+	}
+	if req.ExpiresIn > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresIn)
+		apiToken.ExpiresAt = &expiresAt
+	}
+	token, err := op.CreateApiToken(&apiToken)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	// the raw token is only shown once
+	common.SuccessResp(c, gin.H{
+		"token":     token,
+		"api_token": apiToken,
+	})
+}
+
+func RevokeApiToken(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if err := op.DeleteApiToken(user.ID, uint(id)); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -3,15 +3,19 @@ package handles
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"image/png"
 	"time"
 
 	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/ldap"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/webauthn"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
 	"github.com/pquerna/otp/totp"
+	log "github.com/sirupsen/logrus"
 )
 
 var loginCache = cache.NewMemCache[int]()
@@ -21,9 +25,13 @@ var (
 )
 
 type LoginReq struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password"`
-	OtpCode  string `json:"otp_code"`
+	Username     string `json:"username" binding:"required"`
+	Password     string `json:"password"`
+	OtpCode      string `json:"otp_code"`
+	RecoveryCode string `json:"recovery_code"`
+	// the assertion of the passkey as the second factor, with the session returned by the failed login
+	WebAuthnSession string          `json:"webauthn_session"`
+	WebAuthn        json.RawMessage `json:"webauthn"`
 }
 
 func Login(c *gin.Context) {
@@ -41,36 +49,71 @@ func Login(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	user, err := op.GetUserByName(req.Username)
+	// validate password, by the ldap server if it's enabled
+	user, err := ldap.Authenticate(req.Username, req.Password)
 	if err != nil {
 		common.ErrorResp(c, err, 400)
 		loginCache.Set(ip, count+1)
 		return
 	}
-	// validate password
-	if err := user.ValidatePassword(req.Password); err != nil {
-		common.ErrorResp(c, err, 400)
-		loginCache.Set(ip, count+1)
+	// check 2FA
+	ok, data, err := check2FA(c, user, &req)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
 		return
 	}
-	// check 2FA
-	if user.OtpSecret != "" {
-		if !totp.Validate(req.OtpCode, user.OtpSecret) {
-			common.ErrorStrResp(c, "Invalid 2FA code", 402)
-			loginCache.Set(ip, count+1)
-			return
-		}
+	if !ok {
+		c.JSON(200, common.Resp[gin.H]{Code: 402, Message: "Invalid 2FA code", Data: data})
+		loginCache.Set(ip, count+1)
+		return
 	}
 	// generate token
-	token, err := common.GenerateToken(user.Username)
+	resp, err := issueToken(c, user)
 	if err != nil {
 		common.ErrorResp(c, err, 400, true)
 		return
 	}
-	common.SuccessResp(c, gin.H{"token": token})
+	common.SuccessResp(c, resp)
 	loginCache.Del(ip)
 }
 
+// check2FA verify the second factor of the user, which is the totp code, the recovery code or
+// the assertion of the passkey, returns the options to assert the passkeys if it's not passed
+func check2FA(c *gin.Context, user *model.User, req *LoginReq) (bool, gin.H, error) {
+	credentials, err := op.GetWebAuthnCredentialsByUser(user.ID)
+	if err != nil {
+		return false, nil, err
+	}
+	if user.OtpSecret == "" && len(credentials) == 0 {
+		return true, nil, nil
+	}
+	if user.OtpSecret != "" && req.OtpCode != "" && totp.Validate(req.OtpCode, user.OtpSecret) {
+		return true, nil, nil
+	}
+	if req.RecoveryCode != "" {
+		if ok, err := op.UseRecoveryCode(user.ID, req.RecoveryCode); ok || err != nil {
+			return ok, nil, err
+		}
+	}
+	if len(credentials) > 0 && req.WebAuthnSession != "" {
+		_, err := webauthn.FinishLogin(common.GetApiUrl(c.Request), req.WebAuthnSession, user.ID, bytes.NewReader(req.WebAuthn))
+		if err == nil {
+			return true, nil, nil
+		}
+		log.Debugf("failed verify passkey of %s: %+v", user.Username, err)
+	}
+	data := gin.H{"otp": user.OtpSecret != ""}
+	if len(credentials) > 0 {
+		assertion, session, err := webauthn.BeginLogin(common.GetApiUrl(c.Request), user)
+		if err != nil {
+			return false, nil, err
+		}
+		data["webauthn"] = assertion
+		data["webauthn_session"] = session
+	}
+	return false, data, nil
+}
+
 type UserResp struct {
 	model.User
 	Otp bool `json:"otp"`
@@ -161,7 +204,32 @@ func Verify2FA(c *gin.Context) {
 	user.OtpSecret = req.Secret
 	if err := op.UpdateUser(user); err != nil {
 		common.ErrorResp(c, err, 500)
-	} else {
-		common.SuccessResp(c)
+		return
+	}
+	codes, err := op.GenerateRecoveryCodes(user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{"recovery_codes": codes})
+}
+
+// RegenerateRecoveryCodes replace the recovery codes of current user, the old ones are invalidated
+func RegenerateRecoveryCodes(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	enabled, err := op.Is2FAEnabled(user)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if !enabled {
+		common.ErrorStrResp(c, "2FA is not enabled", 400)
+		return
+	}
+	codes, err := op.GenerateRecoveryCodes(user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
 	}
+	common.SuccessResp(c, gin.H{"recovery_codes": codes})
 }
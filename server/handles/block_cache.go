@@ -0,0 +1,26 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/blockcache"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func BlockCacheStats(c *gin.Context) {
+	common.SuccessResp(c, blockcache.GetStats())
+}
+
+type PurgeBlockCacheReq struct {
+	Path string `json:"path"`
+}
+
+func PurgeBlockCache(c *gin.Context) {
+	var req PurgeBlockCacheReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"files": blockcache.Purge(req.Path),
+	})
+}
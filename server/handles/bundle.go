@@ -0,0 +1,87 @@
+package handles
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/bundle"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/alist-org/alist/v3/server/static"
+	"github.com/gin-gonic/gin"
+)
+
+type ExportBundleReq struct {
+	Include    []string `json:"include"`
+	Format     string   `json:"format"`
+	Secrets    string   `json:"secrets"`
+	Passphrase string   `json:"passphrase"`
+}
+
+// ExportBundle returns the bundle as an attachment
+func ExportBundle(c *gin.Context) {
+	var req ExportBundleReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Format == "" {
+		req.Format = bundle.FormatJSON
+	}
+	if req.Secrets == "" {
+		req.Secrets = bundle.SecretsRedact
+	}
+	b, err := bundle.Export(bundle.ExportOptions{Include: req.Include, Secrets: req.Secrets, Passphrase: req.Passphrase})
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	data, err := bundle.Marshal(b, req.Format)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	contentType := "application/json"
+	if req.Format == bundle.FormatYAML {
+		contentType = "application/yaml"
+	}
+	filename := fmt.Sprintf("alist-bundle-%s.%s", time.Now().Format("20060102150405"), req.Format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(200, contentType, data)
+}
+
+type ImportBundleReq struct {
+	// the bundle in json or yaml
+	Content    string `json:"content" binding:"required"`
+	Mode       string `json:"mode"`
+	DryRun     bool   `json:"dry_run"`
+	Passphrase string `json:"passphrase"`
+}
+
+// ImportBundle import the bundle and returns the changes, the changes are
+// returned with the error message if some of them failed
+func ImportBundle(c *gin.Context) {
+	var req ImportBundleReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	b, err := bundle.Unmarshal([]byte(req.Content))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	changes, err := bundle.Import(c, b, bundle.ImportOptions{Mode: req.Mode, DryRun: req.DryRun, Passphrase: req.Passphrase})
+	if changes == nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if !req.DryRun {
+		// the settings may be changed
+		static.UpdateIndex()
+	}
+	if err != nil {
+		c.JSON(200, common.Resp[[]bundle.Change]{Code: 500, Message: err.Error(), Data: changes})
+		return
+	}
+	common.SuccessResp(c, changes)
+}
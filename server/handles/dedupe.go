@@ -0,0 +1,72 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/dedupe"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func DedupeScan(c *gin.Context) {
+	var req dedupe.ScanArgs
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	tid, err := dedupe.Scan(req)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"tid": strconv.FormatUint(tid, 10),
+	})
+}
+
+func DedupeReport(c *gin.Context) {
+	tid, err := str2Uint64K(c.Query("tid"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	report, ok := dedupe.GetReport(tid)
+	if !ok {
+		common.ErrorStrResp(c, "report not found, maybe the scan task is not finished", 404)
+		return
+	}
+	common.SuccessResp(c, report)
+}
+
+func DedupeDeleteReport(c *gin.Context) {
+	tid, err := str2Uint64K(c.Query("tid"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	dedupe.DelReport(tid)
+	common.SuccessResp(c)
+}
+
+func DedupeApply(c *gin.Context) {
+	var req dedupe.ApplyArgs
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	tid, err := str2Uint64K(c.Query("tid"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Tid = tid
+	req.ApiUrl = common.GetApiUrl(c.Request)
+	applyTid, err := dedupe.Apply(req)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"tid": strconv.FormatUint(applyTid, 10),
+	})
+}
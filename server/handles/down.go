@@ -6,7 +6,9 @@ import (
 	stdpath "path"
 	"strings"
 
+	"github.com/alist-org/alist/v3/internal/blockcache"
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/downloader"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -102,6 +104,12 @@ func Proxy(c *gin.Context) {
 				return
 			}
 		}
+		link = blockcache.Wrap(storage, rawPath, link, file)
+		link = downloader.Wrap(storage, link, file, downloader.LinkRefresher(rawPath, model.LinkArgs{
+			Header:  c.Request.Header,
+			Type:    c.Query("type"),
+			HttpReq: c.Request,
+		}))
 		err = common.Proxy(c.Writer, c.Request, link, file)
 		if err != nil {
 			common.ErrorResp(c, err, 500, true)
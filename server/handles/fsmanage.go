@@ -209,24 +209,30 @@ func FsCopy(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
-	var addedTask []string
+	// the ids of the tasks added, so the client can wait them
+	var addedTasks []string
 	for i, name := range req.Names {
-		ok, err := fs.Copy(c, stdpath.Join(srcDir, name), dstDir, len(req.Names) > i+1)
-		if ok {
-			addedTask = append(addedTask, name)
+		t, err := fs.CopyTask(c, stdpath.Join(srcDir, name), dstDir, len(req.Names) > i+1)
+		if t != nil {
+			addedTasks = append(addedTasks, uint64K2Str(t.ID))
 		}
 		if err != nil {
 			common.ErrorResp(c, err, 500)
 			return
 		}
 	}
-	if len(addedTask) > 0 {
-		common.SuccessResp(c, fmt.Sprintf("Added %d tasks", len(addedTask)))
+	if len(addedTasks) > 0 {
+		common.SuccessResp(c, CopyResp{Message: fmt.Sprintf("Added %d tasks", len(addedTasks)), Tasks: addedTasks})
 	} else {
 		common.SuccessResp(c)
 	}
 }
 
+type CopyResp struct {
+	Message string   `json:"message"`
+	Tasks   []string `json:"tasks"`
+}
+
 type RenameReq struct {
 	Path string `json:"path"`
 	Name string `json:"name"`
@@ -45,6 +45,7 @@ type ObjResp struct {
 type FsListResp struct {
 	Content  []ObjResp `json:"content"`
 	Total    int64     `json:"total"`
+	More     bool      `json:"more"` // there are more pages of the paged listing, the total is 0 since it's unknown
 	Readme   string    `json:"readme"`
 	Write    bool      `json:"write"`
 	Provider string    `json:"provider"`
@@ -79,12 +80,11 @@ func FsList(c *gin.Context) {
 		common.ErrorStrResp(c, "Refresh without permission", 403)
 		return
 	}
-	objs, err := fs.List(c, reqPath, &fs.ListArgs{Refresh: req.Refresh})
+	total, objs, more, err := listPage(c, reqPath, &req)
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
 	}
-	total, objs := pagination(objs, &req.PageReq)
 	provider := "unknown"
 	storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
 	if err == nil {
@@ -93,6 +93,7 @@ func FsList(c *gin.Context) {
 	common.SuccessResp(c, FsListResp{
 		Content:  toObjsResp(objs, reqPath, isEncrypt(meta, reqPath)),
 		Total:    int64(total),
+		More:     more,
 		Readme:   getReadme(meta, reqPath),
 		Write:    user.CanWrite() || common.CanWrite(meta, reqPath),
 		Provider: provider,
@@ -179,6 +180,30 @@ func isEncrypt(meta *model.Meta, path string) bool {
 	return true
 }
 
+// listPage fetch only the requested page if the storage support paged listing,
+// otherwise the whole dir is listed and paginated
+func listPage(c *gin.Context, reqPath string, req *ListReq) (int, []model.Obj, bool, error) {
+	if req.PerPage < model.MaxInt {
+		objs, more, err := fs.ListPage(c, reqPath, &fs.ListPageArgs{Refresh: req.Refresh, Page: req.Page, PerPage: req.PerPage})
+		if err == nil {
+			if more {
+				// the total is unknown until the last page is listed
+				return 0, objs, true, nil
+			}
+			return (req.Page-1)*req.PerPage + len(objs), objs, false, nil
+		}
+		if !errors.Is(err, errs.NotImplement) {
+			return 0, nil, false, err
+		}
+	}
+	objs, err := fs.List(c, reqPath, &fs.ListArgs{Refresh: req.Refresh})
+	if err != nil {
+		return 0, nil, false, err
+	}
+	total, objs := pagination(objs, &req.PageReq)
+	return total, objs, false, nil
+}
+
 func pagination(objs []model.Obj, req *model.PageReq) (int, []model.Obj) {
 	pageIndex, pageSize := req.Page, req.PerPage
 	total := len(objs)
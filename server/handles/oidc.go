@@ -0,0 +1,124 @@
+package handles
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/oidc"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCProviderResp struct {
+	Name string `json:"name"`
+}
+
+// OIDCProviders list the providers for the login page
+func OIDCProviders(c *gin.Context) {
+	resp := make([]OIDCProviderResp, 0)
+	if !oidc.Enabled() {
+		common.SuccessResp(c, resp)
+		return
+	}
+	providers, err := oidc.GetProviders()
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	for _, p := range providers {
+		resp = append(resp, OIDCProviderResp{Name: p.Name})
+	}
+	common.SuccessResp(c, resp)
+}
+
+func getOIDCProvider(c *gin.Context) *oidc.Provider {
+	if !oidc.Enabled() {
+		common.ErrorStrResp(c, "OIDC login is not enabled", 403)
+		return nil
+	}
+	p, err := oidc.GetProvider(c.Param("provider"))
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return nil
+	}
+	return p
+}
+
+func oidcRedirectURI(c *gin.Context, p *oidc.Provider) string {
+	return common.GetApiUrl(c.Request) + "/api/auth/oidc/" + url.PathEscape(p.Name) + "/callback"
+}
+
+// setOIDCStateCookie bind the login to the browser, the cookie is sent
+// only with the top-level navigation of the callback from the provider
+func setOIDCStateCookie(c *gin.Context, state string, maxAge int) {
+	secure := strings.HasPrefix(common.GetApiUrl(c.Request), "https://")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidc.StateCookie, state, maxAge, conf.URL.Path+"/api/auth/oidc/", "", secure, true)
+}
+
+func OIDCLogin(c *gin.Context) {
+	p := getOIDCProvider(c)
+	if p == nil {
+		return
+	}
+	authURL, state, err := p.AuthURL(c, oidcRedirectURI(c, p))
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	setOIDCStateCookie(c, state, int(oidc.StateExpiration.Seconds()))
+	c.Redirect(302, authURL)
+}
+
+func OIDCCallback(c *gin.Context) {
+	p := getOIDCProvider(c)
+	if p == nil {
+		return
+	}
+	if e := c.Query("error"); e != "" {
+		common.ErrorStrResp(c, fmt.Sprintf("%s: %s", e, c.Query("error_description")), 400)
+		return
+	}
+	browserState, _ := c.Cookie(oidc.StateCookie)
+	setOIDCStateCookie(c, "", -1)
+	user, err := p.Login(c, c.Query("state"), browserState, c.Query("code"))
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	if user.Disabled {
+		common.ErrorStrResp(c, "This user is disabled", 401)
+		return
+	}
+	resp, err := issueToken(c, user)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	// only post the token to the pages of this site
+	origin := "*"
+	if u, err := url.Parse(common.GetApiUrl(c.Request)); err == nil && u.Host != "" {
+		origin = u.Scheme + "://" + u.Host
+	}
+	data, _ := utils.Json.MarshalToString(resp)
+	originStr, _ := utils.Json.MarshalToString(origin)
+	html := fmt.Sprintf(`<!DOCTYPE html>
+	<head></head>
+	<body>
+	<script>
+	const data = %s
+	if (window.opener) {
+		window.opener.postMessage(data, %s)
+		window.close()
+	} else {
+		localStorage.setItem("token", data.token)
+		window.location.href = "/"
+	}
+	</script>
+	</body>`, data, originStr)
+	c.Data(200, "text/html; charset=utf-8", []byte(html))
+}
@@ -0,0 +1,102 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// issueToken create a session for the user and returns the access and refresh token
+func issueToken(c *gin.Context, user *model.User) (gin.H, error) {
+	session, refresh, err := op.CreateSession(user, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		return nil, err
+	}
+	token, err := common.GenerateToken(user, session)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{"token": token, "refresh_token": refresh}, nil
+}
+
+type RefreshTokenReq struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func RefreshToken(c *gin.Context) {
+	var req RefreshTokenReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	session, refresh, err := op.RefreshSession(req.RefreshToken, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	user, err := op.GetUserById(session.UserID)
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	if user.Disabled {
+		common.ErrorStrResp(c, "Current user is disabled", 401)
+		return
+	}
+	token, err := common.GenerateToken(user, session)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, gin.H{"token": token, "refresh_token": refresh})
+}
+
+func Logout(c *gin.Context) {
+	session, ok := c.Get("session")
+	if !ok {
+		common.ErrorStrResp(c, "Not logged in with a session", 400)
+		return
+	}
+	s := session.(*model.Session)
+	if err := op.DeleteSession(s.UserID, s.ID); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+type SessionResp struct {
+	model.Session
+	Current bool `json:"current"`
+}
+
+func ListSessions(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	sessions, err := op.GetSessionsByUser(user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	current := ""
+	if s, ok := c.Get("session"); ok {
+		current = s.(*model.Session).ID
+	}
+	resp := make([]SessionResp, 0, len(sessions))
+	for _, s := range sessions {
+		if s.IsExpired() {
+			continue
+		}
+		resp = append(resp, SessionResp{Session: s, Current: s.ID == current})
+	}
+	common.SuccessResp(c, resp)
+}
+
+func RevokeSession(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	if err := op.DeleteSession(user.ID, c.Query("id")); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c)
+}
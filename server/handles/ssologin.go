@@ -172,11 +172,14 @@ func SSOLoginCallback(c *gin.Context) {
 				user, err := db.GetUserBySSOID(UserID)
 				if err != nil {
 					common.ErrorResp(c, err, 400)
+					return
 				}
-				token, err := common.GenerateToken(user.Username)
+				resp, err := issueToken(c, user)
 				if err != nil {
 					common.ErrorResp(c, err, 400)
+					return
 				}
+				token := resp["token"]
 				html := fmt.Sprintf(`<!DOCTYPE html>
 				<head></head>
 				<body>
@@ -1,10 +1,14 @@
 package handles
 
 import (
+	"io"
 	"strconv"
+	"time"
 
 	"github.com/alist-org/alist/v3/internal/aria2"
+	"github.com/alist-org/alist/v3/internal/dedupe"
 	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/qbittorrent"
 	"github.com/alist-org/alist/v3/pkg/task"
 	"github.com/alist-org/alist/v3/server/common"
@@ -13,13 +17,22 @@ import (
 
 type TaskInfo struct {
 	ID       string `json:"id"`
+	ParentID string `json:"parent_id"`
 	Name     string `json:"name"`
 	State    string `json:"state"`
 	Status   string `json:"status"`
 	Progress int    `json:"progress"`
+	Priority int    `json:"priority"`
+	task.Stats
+	SubTasks int    `json:"sub_tasks"`
 	Error    string `json:"error"`
 }
 
+type TaskTree struct {
+	TaskInfo
+	Children []TaskTree `json:"children"`
+}
+
 type K2Str[K comparable] func(k K) string
 
 func uint64K2Str(k uint64) string {
@@ -31,14 +44,33 @@ func strK2Str(str string) string {
 }
 
 func getTaskInfo[K comparable](task *task.Task[K], k2Str K2Str[K]) TaskInfo {
-	return TaskInfo{
+	info := TaskInfo{
 		ID:       k2Str(task.ID),
 		Name:     task.Name,
 		State:    task.GetState(),
 		Status:   task.GetStatus(),
 		Progress: task.GetProgress(),
+		Priority: task.Priority,
+		Stats:    task.GetStats(),
+		SubTasks: len(task.Children()),
 		Error:    task.GetErrMsg(),
 	}
+	if parent := task.Parent(); parent != nil {
+		info.ParentID = k2Str(parent.ID)
+	}
+	return info
+}
+
+// getTaskTree returns the task with its descendants, depth < 0 means unlimited
+func getTaskTree[K comparable](task *task.Task[K], k2Str K2Str[K], depth int) TaskTree {
+	tree := TaskTree{TaskInfo: getTaskInfo(task, k2Str), Children: []TaskTree{}}
+	if depth == 0 {
+		return tree
+	}
+	for _, child := range task.Children() {
+		tree.Children = append(tree.Children, getTaskTree(child, k2Str, depth-1))
+	}
+	return tree
 }
 
 func getTaskInfos[K comparable](tasks []*task.Task[K], k2Str K2Str[K]) []TaskInfo {
@@ -66,6 +98,70 @@ func taskRoute[K comparable](g *gin.RouterGroup, manager *task.Manager[K], k2Str
 	g.GET("/done", func(c *gin.Context) {
 		common.SuccessResp(c, getTaskInfos(manager.ListDone(), k2Str))
 	})
+	// stream the undone tasks as server-sent events every interval,
+	// the tasks done since the last event are sent once more with the final state
+	g.GET("/events", func(c *gin.Context) {
+		interval, err := time.ParseDuration(c.DefaultQuery("interval", "1s"))
+		if err != nil || interval < 100*time.Millisecond {
+			common.ErrorStrResp(c, "invalid interval, should be at least 100ms", 400)
+			return
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		c.Header("X-Accel-Buffering", "no")
+		last := map[K]bool{}
+		c.Stream(func(w io.Writer) bool {
+			tasks := manager.ListUndone()
+			current := make(map[K]bool, len(tasks))
+			for _, t := range tasks {
+				current[t.ID] = true
+			}
+			for id := range last {
+				if t, ok := manager.Get(id); ok && !current[id] {
+					tasks = append(tasks, t)
+				}
+			}
+			last = current
+			infos := getTaskInfos(tasks, k2Str)
+			if infos == nil {
+				infos = []TaskInfo{}
+			}
+			c.SSEvent("tasks", infos)
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case <-ticker.C:
+				return true
+			}
+		})
+	})
+	// the tree of the task by tid, or the trees of all the root tasks
+	g.GET("/tree", func(c *gin.Context) {
+		depth, err := strconv.Atoi(c.DefaultQuery("depth", "-1"))
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		tasks := manager.GetRoots()
+		if tid := c.Query("tid"); tid != "" {
+			id, err := str2K(tid)
+			if err != nil {
+				common.ErrorResp(c, err, 400)
+				return
+			}
+			t, ok := manager.Get(id)
+			if !ok {
+				common.ErrorResp(c, task.ErrTaskNotFound, 404)
+				return
+			}
+			tasks = []*task.Task[K]{t}
+		}
+		trees := make([]TaskTree, 0, len(tasks))
+		for _, t := range tasks {
+			trees = append(trees, getTaskTree(t, k2Str, depth))
+		}
+		common.SuccessResp(c, trees)
+	})
 	g.POST("/cancel", func(c *gin.Context) {
 		tid := c.Query("tid")
 		id, err := str2K(tid)
@@ -113,9 +209,72 @@ func taskRoute[K comparable](g *gin.RouterGroup, manager *task.Manager[K], k2Str
 		manager.ClearSucceeded()
 		common.SuccessResp(c)
 	})
+	for path, action := range map[string]func(K) error{
+		"/pause":  manager.Pause,
+		"/resume": manager.Resume,
+	} {
+		action := action
+		g.POST(path, func(c *gin.Context) {
+			id, err := str2K(c.Query("tid"))
+			if err != nil {
+				common.ErrorResp(c, err, 400)
+				return
+			}
+			if err := action(id); err != nil {
+				common.ErrorResp(c, err, 500)
+			} else {
+				common.SuccessResp(c)
+			}
+		})
+	}
+	g.POST("/set_priority", func(c *gin.Context) {
+		id, err := str2K(c.Query("tid"))
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		priority, err := strconv.Atoi(c.Query("priority"))
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		if err := manager.SetPriority(id, priority); err != nil {
+			common.ErrorResp(c, err, 500)
+		} else {
+			common.SuccessResp(c)
+		}
+	})
+	for path, action := range map[string]func(){
+		"/pause_all":    manager.PauseAll,
+		"/resume_all":   manager.ResumeAll,
+		"/cancel_all":   manager.CancelAll,
+		"/retry_failed": manager.RetryFailed,
+	} {
+		action := action
+		g.POST(path, func(c *gin.Context) {
+			action()
+			common.SuccessResp(c)
+		})
+	}
+}
+
+// EventsToken returns a short-lived token to connect the task events by EventSource,
+// pass it as the events_token query since EventSource can't set the Authorization header
+func EventsToken(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	token, err := common.GenerateEventsToken(user)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"token":      token,
+		"expires_in": int(common.EventsTokenExpiration.Seconds()),
+	})
 }
 
 func SetupTaskRoute(g *gin.RouterGroup) {
+	g.POST("/events_token", EventsToken)
 	taskRoute(g.Group("/aria2_down"), aria2.DownTaskManager, strK2Str, str2StrK)
 	taskRoute(g.Group("/aria2_transfer"), aria2.TransferTaskManager, uint64K2Str, str2Uint64K)
 	taskRoute(g.Group("/upload"), fs.UploadTaskManager, uint64K2Str, str2Uint64K)
@@ -123,3 +282,7 @@ func SetupTaskRoute(g *gin.RouterGroup) {
 	taskRoute(g.Group("/qbit_down"), qbittorrent.DownTaskManager, strK2Str, str2StrK)
 	taskRoute(g.Group("/qbit_transfer"), qbittorrent.TransferTaskManager, uint64K2Str, str2Uint64K)
 }
+
+func SetupDedupeTaskRoute(g *gin.RouterGroup) {
+	taskRoute(g, dedupe.TaskManager, uint64K2Str, str2Uint64K)
+}
@@ -61,9 +61,8 @@ func UpdateUser(c *gin.Context) {
 		common.ErrorStrResp(c, "role can not be changed", 400)
 		return
 	}
-	if req.Password == "" {
-		req.Password = user.Password
-	}
+	// keep the old password if a new one is not given
+	req.PwdHash = user.PwdHash
 	if req.OtpSecret == "" {
 		req.OtpSecret = user.OtpSecret
 	}
@@ -0,0 +1,119 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/webauthn"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func BeginWebAuthnRegistration(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	if user.IsGuest() {
+		common.ErrorStrResp(c, "Guest user can not register passkey", 403)
+		return
+	}
+	options, session, err := webauthn.BeginRegistration(common.GetApiUrl(c.Request), user)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{"options": options, "session": session})
+}
+
+// FinishWebAuthnRegistration save the passkey created by the authenticator, the recovery codes
+// are generated and returned if it's the first second factor of the user
+func FinishWebAuthnRegistration(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	if user.IsGuest() {
+		common.ErrorStrResp(c, "Guest user can not register passkey", 403)
+		return
+	}
+	enabled, err := op.Is2FAEnabled(user)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	credential, err := webauthn.FinishRegistration(common.GetApiUrl(c.Request), user,
+		c.Query("session"), c.Query("name"), c.Request.Body)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	resp := gin.H{"credential": credential}
+	if !enabled {
+		codes, err := op.GenerateRecoveryCodes(user.ID)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		resp["recovery_codes"] = codes
+	}
+	common.SuccessResp(c, resp)
+}
+
+func ListWebAuthnCredentials(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	credentials, err := op.GetWebAuthnCredentialsByUser(user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, credentials)
+}
+
+type DeleteWebAuthnCredentialReq struct {
+	ID uint `json:"id" binding:"required"`
+}
+
+func DeleteWebAuthnCredential(c *gin.Context) {
+	var req DeleteWebAuthnCredentialReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if err := op.DeleteWebAuthnCredential(user.ID, req.ID); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// BeginWebAuthnLogin returns the options to login with a discoverable passkey without password
+func BeginWebAuthnLogin(c *gin.Context) {
+	options, session, err := webauthn.BeginLogin(common.GetApiUrl(c.Request), nil)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{"options": options, "session": session})
+}
+
+func FinishWebAuthnLogin(c *gin.Context) {
+	ip := c.ClientIP()
+	count, ok := loginCache.Get(ip)
+	if ok && count >= defaultTimes {
+		common.ErrorStrResp(c, "Too many unsuccessful sign-in attempts have been made, Try again later.", 429)
+		loginCache.Expire(ip, defaultDuration)
+		return
+	}
+	user, err := webauthn.FinishLogin(common.GetApiUrl(c.Request), c.Query("session"), 0, c.Request.Body)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		loginCache.Set(ip, count+1)
+		return
+	}
+	if user.Disabled {
+		common.ErrorStrResp(c, "This user is disabled", 401)
+		return
+	}
+	resp, err := issueToken(c, user)
+	if err != nil {
+		common.ErrorResp(c, err, 400, true)
+		return
+	}
+	common.SuccessResp(c, resp)
+	loginCache.Del(ip)
+}
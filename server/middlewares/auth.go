@@ -2,6 +2,8 @@ package middlewares
 
 import (
 	"crypto/subtle"
+	"fmt"
+	"strings"
 
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -29,6 +31,27 @@ func Auth(c *gin.Context) {
 		return
 	}
 	if token == "" {
+		if eventsToken := c.GetString("events_token"); eventsToken != "" && strings.HasSuffix(c.Request.URL.Path, "/events") {
+			authEvents(c, eventsToken)
+			return
+		}
+		user, err := common.TrustedUser(c.Request)
+		if err != nil {
+			common.ErrorResp(c, err, 401)
+			c.Abort()
+			return
+		}
+		if user != nil {
+			if user.Disabled {
+				common.ErrorStrResp(c, "Current user is disabled", 401)
+				c.Abort()
+				return
+			}
+			c.Set("user", user)
+			log.Debugf("use trusted user: %+v", user)
+			c.Next()
+			return
+		}
 		guest, err := op.GetGuest()
 		if err != nil {
 			common.ErrorResp(c, err, 500)
@@ -45,6 +68,24 @@ func Auth(c *gin.Context) {
 		c.Next()
 		return
 	}
+	if op.IsApiToken(token) {
+		user, apiToken, err := op.GetUserByApiToken(token)
+		if err != nil {
+			common.ErrorResp(c, err, 401)
+			c.Abort()
+			return
+		}
+		if user.Disabled {
+			common.ErrorStrResp(c, "Current user is disabled, replace please", 401)
+			c.Abort()
+			return
+		}
+		c.Set("user", user)
+		c.Set("api_token", apiToken)
+		log.Debugf("use api token [%s] of user: %s", apiToken.Name, user.Username)
+		c.Next()
+		return
+	}
 	userClaims, err := common.ParseToken(token)
 	if err != nil {
 		common.ErrorResp(c, err, 401)
@@ -62,11 +103,64 @@ func Auth(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	if userClaims.Gen != user.TokenGen {
+		common.ErrorStrResp(c, "Token is invalidated, login please", 401)
+		c.Abort()
+		return
+	}
+	if userClaims.ID == "" {
+		common.ErrorStrResp(c, "Session is revoked or expired, login please", 401)
+		c.Abort()
+		return
+	}
+	session, err := op.GetSession(userClaims.ID)
+	if err != nil || session.UserID != user.ID {
+		common.ErrorStrResp(c, "Session is revoked or expired, login please", 401)
+		c.Abort()
+		return
+	}
 	c.Set("user", user)
+	c.Set("session", session)
 	log.Debugf("use login token: %+v", user)
 	c.Next()
 }
 
+// authEvents authorize the event streams by the token in the query
+func authEvents(c *gin.Context, token string) {
+	claims, err := common.ParseEventsToken(token)
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		c.Abort()
+		return
+	}
+	user, err := op.GetUserByName(claims.Username)
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		c.Abort()
+		return
+	}
+	if user.Disabled || claims.Gen != user.TokenGen {
+		common.ErrorStrResp(c, "Token is invalidated, login please", 401)
+		c.Abort()
+		return
+	}
+	c.Set("user", user)
+	log.Debugf("use events token: %+v", user)
+	c.Next()
+}
+
+// EventsToken moves the events token from the query to the context,
+// it should be used before the logger so the token is not written to the logs
+func EventsToken(c *gin.Context) {
+	if strings.Contains(c.Request.URL.RawQuery, "events_token=") {
+		query := c.Request.URL.Query()
+		c.Set("events_token", query.Get("events_token"))
+		query.Del("events_token")
+		c.Request.URL.RawQuery = query.Encode()
+	}
+	c.Next()
+}
+
 func AuthAdmin(c *gin.Context) {
 	user := c.MustGet("user").(*model.User)
 	if !user.IsAdmin() {
@@ -76,3 +170,26 @@ func AuthAdmin(c *gin.Context) {
 		c.Next()
 	}
 }
+
+// Scope reject the requests authorized by the api tokens without the scope
+func Scope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiToken, ok := c.Get("api_token"); ok && !apiToken.(*model.ApiToken).HasScope(scope) {
+			common.ErrorStrResp(c, fmt.Sprintf("The api token has no %s scope", scope), 403)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// NoApiToken reject the requests authorized by the api tokens,
+// used by the routes managing the account such as changing password
+func NoApiToken(c *gin.Context) {
+	if _, ok := c.Get("api_token"); ok {
+		common.ErrorStrResp(c, "The api token can't be used to manage the account", 403)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
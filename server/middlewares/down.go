@@ -27,8 +27,8 @@ func Down(c *gin.Context) {
 		}
 	}
 	c.Set("meta", meta)
-	// verify sign
-	if needSign(meta, rawPath) {
+	// verify sign, the trusted users needn't sign
+	if needSign(meta, rawPath) && !trustedUserCanAccess(c, meta, rawPath) {
 		s := c.Query("sign")
 		err = sign.Verify(rawPath, strings.TrimSuffix(s, "/"))
 		if err != nil {
@@ -40,6 +40,16 @@ func Down(c *gin.Context) {
 	c.Next()
 }
 
+// trustedUserCanAccess check if the user authenticated by the client certificate
+// or the reverse proxy can access the path
+func trustedUserCanAccess(c *gin.Context, meta *model.Meta, path string) bool {
+	user, err := common.TrustedUser(c.Request)
+	if err != nil || user == nil || user.Disabled {
+		return false
+	}
+	return utils.IsSubPath(user.BasePath, path) && common.CanAccess(user, meta, path, "")
+}
+
 // TODO: implement
 // path maybe contains # ? etc.
 func parsePath(path string) string {
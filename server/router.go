@@ -4,6 +4,7 @@ import (
 	"github.com/alist-org/alist/v3/cmd/flags"
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/message"
+	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/alist-org/alist/v3/server/handles"
@@ -42,20 +43,44 @@ func Init(e *gin.Engine) {
 
 	api.POST("/auth/login", handles.Login)
 	auth.GET("/me", handles.CurrentUser)
-	auth.POST("/me/update", handles.UpdateCurrent)
-	auth.POST("/auth/2fa/generate", handles.Generate2FA)
-	auth.POST("/auth/2fa/verify", handles.Verify2FA)
+	auth.POST("/me/update", middlewares.NoApiToken, handles.UpdateCurrent)
+	auth.POST("/auth/2fa/generate", middlewares.NoApiToken, handles.Generate2FA)
+	auth.POST("/auth/2fa/verify", middlewares.NoApiToken, handles.Verify2FA)
+	auth.POST("/auth/2fa/recovery_codes", middlewares.NoApiToken, handles.RegenerateRecoveryCodes)
+	api.POST("/auth/webauthn/login/begin", handles.BeginWebAuthnLogin)
+	api.POST("/auth/webauthn/login/finish", handles.FinishWebAuthnLogin)
+
+	webauthn := auth.Group("/me/webauthn", middlewares.NoApiToken)
+	webauthn.GET("/list", handles.ListWebAuthnCredentials)
+	webauthn.POST("/register/begin", handles.BeginWebAuthnRegistration)
+	webauthn.POST("/register/finish", handles.FinishWebAuthnRegistration)
+	webauthn.POST("/delete", handles.DeleteWebAuthnCredential)
+
+	api.POST("/auth/refresh", handles.RefreshToken)
+	auth.POST("/auth/logout", handles.Logout)
+	auth.GET("/me/sessions", middlewares.NoApiToken, handles.ListSessions)
+	auth.POST("/me/sessions/revoke", middlewares.NoApiToken, handles.RevokeSession)
+
+	apiToken := auth.Group("/me/api_token", middlewares.NoApiToken)
+	apiToken.GET("/list", handles.ListApiTokens)
+	apiToken.POST("/create", handles.CreateApiToken)
+	apiToken.POST("/revoke", handles.RevokeApiToken)
 
 	// github auth
 	api.GET("/auth/sso", handles.SSOLoginRedirect)
 	api.GET("/auth/sso_callback", handles.SSOLoginCallback)
 
+	// oidc auth
+	api.GET("/auth/oidc/providers", handles.OIDCProviders)
+	api.GET("/auth/oidc/:provider/login", handles.OIDCLogin)
+	api.GET("/auth/oidc/:provider/callback", handles.OIDCCallback)
+
 	// no need auth
 	public := api.Group("/public")
 	public.Any("/settings", handles.PublicSettings)
 
 	_fs(auth.Group("/fs"))
-	admin(auth.Group("/admin", middlewares.AuthAdmin))
+	admin(auth.Group("/admin", middlewares.AuthAdmin, middlewares.Scope(model.ScopeAdmin)))
 	if flags.Dev {
 		dev(g.Group("/dev"))
 	}
@@ -89,6 +114,9 @@ func admin(g *gin.RouterGroup) {
 	storage.POST("/enable", handles.EnableStorage)
 	storage.POST("/disable", handles.DisableStorage)
 	storage.POST("/load_all", handles.LoadAllStorages)
+	storage.POST("/reload", handles.ReloadStorage)
+	storage.GET("/health", handles.ListStorageHealth)
+	storage.GET("/status_history", handles.ListStorageStatusHistory)
 
 	driver := g.Group("/driver")
 	driver.GET("/list", handles.ListDriverInfo)
@@ -107,6 +135,21 @@ func admin(g *gin.RouterGroup) {
 	task := g.Group("/task")
 	handles.SetupTaskRoute(task)
 
+	dedupe := g.Group("/dedupe")
+	dedupe.POST("/scan", handles.DedupeScan)
+	dedupe.GET("/report", handles.DedupeReport)
+	dedupe.POST("/delete_report", handles.DedupeDeleteReport)
+	dedupe.POST("/apply", handles.DedupeApply)
+	handles.SetupDedupeTaskRoute(dedupe.Group("/task"))
+
+	bundle := g.Group("/bundle")
+	bundle.POST("/export", handles.ExportBundle)
+	bundle.POST("/import", handles.ImportBundle)
+
+	blockCache := g.Group("/block_cache")
+	blockCache.GET("/stats", handles.BlockCacheStats)
+	blockCache.POST("/purge", handles.PurgeBlockCache)
+
 	ms := g.Group("/message")
 	ms.POST("/get", message.HttpInstance.GetHandle)
 	ms.POST("/send", message.HttpInstance.SendHandle)
@@ -120,24 +163,27 @@ func admin(g *gin.RouterGroup) {
 }
 
 func _fs(g *gin.RouterGroup) {
-	g.Any("/list", handles.FsList)
-	g.Any("/search", middlewares.SearchIndex, handles.Search)
-	g.Any("/get", handles.FsGet)
-	g.Any("/other", handles.FsOther)
-	g.Any("/dirs", handles.FsDirs)
-	g.POST("/mkdir", handles.FsMkdir)
-	g.POST("/rename", handles.FsRename)
-	g.POST("/regex_rename", handles.FsRegexRename)
-	g.POST("/move", handles.FsMove)
-	g.POST("/recursive_move", handles.FsRecursiveMove)
-	g.POST("/copy", handles.FsCopy)
-	g.POST("/remove", handles.FsRemove)
-	g.POST("/remove_empty_directory", handles.FsRemoveEmptyDirectory)
-	g.PUT("/put", middlewares.FsUp, handles.FsStream)
-	g.PUT("/form", middlewares.FsUp, handles.FsForm)
-	g.POST("/link", middlewares.AuthAdmin, handles.Link)
-	g.POST("/add_aria2", handles.AddAria2)
-	g.POST("/add_qbit", handles.AddQbittorrent)
+	read := middlewares.Scope(model.ScopeRead)
+	write := middlewares.Scope(model.ScopeWrite)
+	offline := middlewares.Scope(model.ScopeOfflineDownload)
+	g.Any("/list", read, handles.FsList)
+	g.Any("/search", read, middlewares.SearchIndex, handles.Search)
+	g.Any("/get", read, handles.FsGet)
+	g.Any("/other", read, handles.FsOther)
+	g.Any("/dirs", read, handles.FsDirs)
+	g.POST("/mkdir", write, handles.FsMkdir)
+	g.POST("/rename", write, handles.FsRename)
+	g.POST("/regex_rename", write, handles.FsRegexRename)
+	g.POST("/move", write, handles.FsMove)
+	g.POST("/recursive_move", write, handles.FsRecursiveMove)
+	g.POST("/copy", write, handles.FsCopy)
+	g.POST("/remove", write, handles.FsRemove)
+	g.POST("/remove_empty_directory", write, handles.FsRemoveEmptyDirectory)
+	g.PUT("/put", write, middlewares.FsUp, handles.FsStream)
+	g.PUT("/form", write, middlewares.FsUp, handles.FsForm)
+	g.POST("/link", middlewares.AuthAdmin, middlewares.Scope(model.ScopeAdmin), handles.Link)
+	g.POST("/add_aria2", offline, handles.AddAria2)
+	g.POST("/add_qbit", offline, handles.AddQbittorrent)
 }
 
 func Cors(r *gin.Engine) {
@@ -6,9 +6,12 @@ import (
 	"path"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/ldap"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
 	"github.com/alist-org/alist/v3/server/webdav"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
@@ -45,20 +48,32 @@ func ServeWebDAV(c *gin.Context) {
 
 func WebDAVAuth(c *gin.Context) {
 	guest, _ := op.GetGuest()
-	username, password, ok := c.Request.BasicAuth()
-	if !ok {
-		if c.Request.Method == "OPTIONS" {
-			c.Set("user", guest)
-			c.Next()
+	var apiToken *model.ApiToken
+	// the users authenticated by the client certificate or the reverse proxy needn't basic auth
+	user, err := common.TrustedUser(c.Request)
+	if user == nil && err == nil {
+		username, password, ok := c.Request.BasicAuth()
+		if !ok {
+			if c.Request.Method == "OPTIONS" {
+				c.Set("user", guest)
+				c.Next()
+				return
+			}
+			c.Writer.Header()["WWW-Authenticate"] = []string{`Basic realm="alist"`}
+			c.Status(http.StatusUnauthorized)
+			c.Abort()
 			return
 		}
-		c.Writer.Header()["WWW-Authenticate"] = []string{`Basic realm="alist"`}
-		c.Status(http.StatusUnauthorized)
-		c.Abort()
-		return
+		if op.IsApiToken(password) {
+			user, apiToken, err = op.GetUserByApiToken(password)
+			if err == nil && (user.Username != username || !apiToken.HasScope(model.ScopeWebdav)) {
+				err = errs.InvalidApiToken
+			}
+		} else {
+			user, err = ldap.Authenticate(username, password)
+		}
 	}
-	user, err := op.GetUserByName(username)
-	if err != nil || user.ValidatePassword(password) != nil {
+	if err != nil {
 		if c.Request.Method == "OPTIONS" {
 			c.Set("user", guest)
 			c.Next()
@@ -78,7 +93,8 @@ func WebDAVAuth(c *gin.Context) {
 		c.Abort()
 		return
 	}
-	if !user.CanWebdavManage() && utils.SliceContains([]string{"PUT", "DELETE", "PROPPATCH", "MKCOL", "COPY", "MOVE"}, c.Request.Method) {
+	canManage := user.CanWebdavManage() && (apiToken == nil || apiToken.HasScope(model.ScopeWrite))
+	if !canManage && utils.SliceContains([]string{"PUT", "DELETE", "PROPPATCH", "MKCOL", "COPY", "MOVE"}, c.Request.Method) {
 		if c.Request.Method == "OPTIONS" {
 			c.Set("user", guest)
 			c.Next()
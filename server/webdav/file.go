@@ -62,12 +62,15 @@ func copyFiles(ctx context.Context, src, dst string, overwrite bool) (status int
 	return http.StatusCreated, nil
 }
 
+// listPageSize is the size of the pages to walk a dir, the responses are flushed page by page
+const listPageSize = 1000
+
 // walkFS traverses filesystem fs starting at name up to depth levels.
 //
 // Allowed values for depth are 0, 1 or infiniteDepth. For each visited node,
 // walkFS calls walkFn. If a visited file system node is a directory and
 // walkFn returns path.SkipDir, walkFS will skip traversal of this node.
-func walkFS(ctx context.Context, depth int, name string, info model.Obj, walkFn func(reqPath string, info model.Obj, err error) error) error {
+func walkFS(ctx context.Context, depth int, name string, info model.Obj, walkFn func(reqPath string, info model.Obj, err error) error, flush func()) error {
 	// This implementation is based on Walk's code in the standard path/path package.
 	err := walkFn(name, info, nil)
 	if err != nil {
@@ -83,32 +86,30 @@ func walkFS(ctx context.Context, depth int, name string, info model.Obj, walkFn
 		depth = 0
 	}
 	meta, _ := op.GetNearestMeta(name)
-	// Read directory names.
-	objs, err := fs.List(context.WithValue(ctx, "meta", meta), name, &fs.ListArgs{})
+	// Read directory names page by page, so the responses of a huge dir are streamed
+	listed := false
+	err = fs.WalkPages(context.WithValue(ctx, "meta", meta), name, listPageSize, func(objs []model.Obj) error {
+		listed = true
+		for _, fileInfo := range objs {
+			filename := path.Join(name, fileInfo.GetName())
+			err := walkFS(ctx, depth, filename, fileInfo, walkFn, flush)
+			if err != nil {
+				if !fileInfo.IsDir() || err != filepath.SkipDir {
+					return err
+				}
+			}
+		}
+		flush()
+		return nil
+	})
 	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
 	//if err != nil {
 	//	return walkFn(name, info, err)
 	//}
 	//fileInfos, err := f.Readdir(0)
 	//f.Close()
-	if err != nil {
+	if err != nil && !listed {
 		return walkFn(name, info, err)
 	}
-
-	for _, fileInfo := range objs {
-		filename := path.Join(name, fileInfo.GetName())
-		if err != nil {
-			if err := walkFn(filename, fileInfo, err); err != nil && err != filepath.SkipDir {
-				return err
-			}
-		} else {
-			err = walkFS(ctx, depth, filename, fileInfo, walkFn)
-			if err != nil {
-				if !fileInfo.IsDir() || err != filepath.SkipDir {
-					return err
-				}
-			}
-		}
-	}
-	return nil
+	return err
 }
@@ -15,6 +15,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/alist-org/alist/v3/internal/blockcache"
+	"github.com/alist-org/alist/v3/internal/downloader"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -236,6 +238,8 @@ func (h *Handler) handleGetHeadPost(w http.ResponseWriter, r *http.Request) (sta
 		if err != nil {
 			return http.StatusInternalServerError, err
 		}
+		link = blockcache.Wrap(storage, reqPath, link, fi)
+		link = downloader.Wrap(storage, link, fi, downloader.LinkRefresher(reqPath, model.LinkArgs{Header: r.Header, HttpReq: r}))
 		err = common.Proxy(w, r, link, fi)
 		if err != nil {
 			log.Errorf("webdav proxy error: %+v", err)
@@ -644,7 +648,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 		return mw.write(makePropstatResponse(href, pstats))
 	}
 
-	walkErr := walkFS(ctx, depth, reqPath, fi, walkFn)
+	walkErr := walkFS(ctx, depth, reqPath, fi, walkFn, mw.flush)
 	closeErr := mw.close()
 	if walkErr != nil {
 		return http.StatusInternalServerError, walkErr
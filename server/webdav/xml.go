@@ -371,6 +371,13 @@ func (w *multistatusWriter) writeHeader() error {
 	})
 }
 
+// flush send the written responses to the client, so the client can handle them before the multistatus is completed
+func (w *multistatusWriter) flush() {
+	if f, ok := w.w.(http.Flusher); ok && w.enc != nil {
+		f.Flush()
+	}
+}
+
 // Close completes the marshalling of the multistatus response. It returns
 // an error if the multistatus response could not be completed. If both the
 // return value and field enc of w are nil, then no multistatus response has